@@ -8,16 +8,71 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"os/user"
+	"strconv"
 	"syscall"
 
+	"github.com/piercegov/llm-npc-backend/internal/agents"
 	"github.com/piercegov/llm-npc-backend/internal/api"
 	"github.com/piercegov/llm-npc-backend/internal/cfg"
+	"github.com/piercegov/llm-npc-backend/internal/console"
 	"github.com/piercegov/llm-npc-backend/internal/kg"
+	"github.com/piercegov/llm-npc-backend/internal/llm"
 	"github.com/piercegov/llm-npc-backend/internal/logging"
+	"github.com/piercegov/llm-npc-backend/internal/memory"
 	"github.com/piercegov/llm-npc-backend/internal/npc"
+	"github.com/piercegov/llm-npc-backend/internal/npc/flowtest"
+	"github.com/piercegov/llm-npc-backend/internal/reactions"
 	"github.com/piercegov/llm-npc-backend/internal/tools"
+	"github.com/piercegov/llm-npc-backend/internal/tools/rules"
+	"github.com/piercegov/llm-npc-backend/internal/webhook"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// applySocketPermissions chmods and chowns a freshly-created Unix socket
+// according to config.SocketMode/SocketOwner/SocketGroup, so game clients or
+// a sidecar running as a different user can reach the socket without it
+// being world-writable. Any field left at its zero value is left alone.
+func applySocketPermissions(path string, config cfg.Config) error {
+	if config.SocketMode != 0 {
+		if err := os.Chmod(path, config.SocketMode); err != nil {
+			return fmt.Errorf("chmod socket: %w", err)
+		}
+	}
+
+	if config.SocketOwner == "" && config.SocketGroup == "" {
+		return nil
+	}
+
+	uid, gid := -1, -1
+	if config.SocketOwner != "" {
+		u, err := user.Lookup(config.SocketOwner)
+		if err != nil {
+			return fmt.Errorf("look up socket owner %q: %w", config.SocketOwner, err)
+		}
+		uid, err = strconv.Atoi(u.Uid)
+		if err != nil {
+			return fmt.Errorf("parse uid for socket owner %q: %w", config.SocketOwner, err)
+		}
+	}
+	if config.SocketGroup != "" {
+		g, err := user.LookupGroup(config.SocketGroup)
+		if err != nil {
+			return fmt.Errorf("look up socket group %q: %w", config.SocketGroup, err)
+		}
+		gid, err = strconv.Atoi(g.Gid)
+		if err != nil {
+			return fmt.Errorf("parse gid for socket group %q: %w", config.SocketGroup, err)
+		}
+	}
+
+	if err := os.Chown(path, uid, gid); err != nil {
+		return fmt.Errorf("chown socket: %w", err)
+	}
+	return nil
+}
+
 // getAllToolsUsed extracts all tools used across all inference rounds
 func getAllToolsUsed(rounds []npc.InferenceRound) []npc.ToolResult {
 	var allTools []npc.ToolResult
@@ -40,14 +95,34 @@ func main() {
 	// Reinitialize logger with configured log level
 	logging.InitLogger(config.LogLevel)
 
+	// Install the default and per-route rate limiters used by
+	// api.ApplyDefaultMiddleware
+	api.ConfigureRateLimiting(config)
+
 	// Remove any existing socket file if using Unix socket mode
 	if !*httpMode {
 		os.Remove(config.SocketPath)
 	}
 
-	// Initialize tool registry and scratchpad storage
+	// Initialize tool registry and scratchpad storage, backed by whichever
+	// ScratchpadStore the config selects
 	toolRegistry := tools.NewToolRegistry()
-	scratchpadStorage := tools.NewScratchpadStorage()
+	scratchpadStore, err := tools.NewScratchpadStore(config.ScratchpadStoreURL)
+	if err != nil {
+		logging.Error("Failed to initialize scratchpad store", "error", err, "scratchpad_store_url", config.ScratchpadStoreURL)
+		os.Exit(1)
+	}
+	scratchpadStorage := tools.NewScratchpadStorageWithStore(scratchpadStore)
+
+	// Reactive rule engine: fires registered tools on its own when a Fact
+	// touches a matching Condition, instead of waiting for the LLM to ask.
+	ruleSession := rules.NewRuleSession(rules.NewActionService(toolRegistry))
+	if config.RulesFilePath != "" {
+		if err := ruleSession.LoadRulesFromFile(config.RulesFilePath); err != nil {
+			logging.Error("Failed to load rules file", "error", err, "path", config.RulesFilePath)
+			os.Exit(1)
+		}
+	}
 
 	// Register scratchpad tools
 	if err := tools.RegisterScratchpadTools(toolRegistry, scratchpadStorage); err != nil {
@@ -55,16 +130,103 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Initialize session manager
-	sessionManager := tools.NewSessionManager()
+	// Shared knowledge graph: scratchpad writes and tool invocations project
+	// into it automatically, and query_knowledge exposes it to NPCs directly
+	// alongside their flat scratchpad.
+	knowledgeGraph := kg.NewGraph(kg.KnowledgeGraph{})
+	scratchpadStorage.SetGraph(knowledgeGraph)
+	toolRegistry.SetGraph(knowledgeGraph)
+	if err := tools.RegisterKnowledgeTools(toolRegistry, knowledgeGraph); err != nil {
+		logging.Error("Failed to register knowledge tools", "error", err)
+		os.Exit(1)
+	}
+
+	// Retrieval-augmented memory: embeds and stores NPC dialogue/events so
+	// past ticks can be recalled by similarity instead of replayed in full.
+	memoryStore, err := memory.NewStore(config.MemoryStoreURL)
+	if err != nil {
+		logging.Error("Failed to initialize memory store", "error", err, "memory_store_url", config.MemoryStoreURL)
+		os.Exit(1)
+	}
+	memoryRetriever := memory.NewRetriever(memoryStore, llm.NewOllama(config.OllamaBaseURL))
+
+	// Initialize session manager, backed by whichever SessionStore the config selects
+	sessionStore, err := tools.NewSessionStore(config.SessionStoreURL)
+	if err != nil {
+		logging.Error("Failed to initialize session store", "error", err, "session_store_url", config.SessionStoreURL)
+		os.Exit(1)
+	}
+	sessionManager := tools.NewSessionManagerWithStore(sessionStore)
+
+	// Initialize the prompt registry, so a game designer can iterate on
+	// templates under prompts/ without restarting the backend
+	promptRegistry, err := npc.NewPromptRegistry("prompts")
+	if err != nil {
+		logging.Error("Failed to initialize prompt registry", "error", err)
+		os.Exit(1)
+	}
+	defer promptRegistry.Close()
+
+	// Initialize the agent registry, so a game designer can define named
+	// personas under agents/ and reference one from an NPC's AgentName
+	// without restarting the backend
+	agentRegistry, err := agents.NewRegistry("agents")
+	if err != nil {
+		logging.Error("Failed to initialize agent registry", "error", err)
+		os.Exit(1)
+	}
+	defer agentRegistry.Close()
+
+	// Initialize the pre-LLM reaction engine, so a game designer can script
+	// staged, YAML-authored rules under reactions/ - prompt hints, injected
+	// knowledge-graph nodes, forced/forbidden tools, or a canned response
+	// that skips the LLM call entirely - without restarting the backend.
+	// Its per-rule hit/miss counters register to prometheus.DefaultRegisterer
+	// alongside the rest of the app's metrics.
+	reactionEngine, err := reactions.NewEngineFromDir("reactions", prometheus.DefaultRegisterer)
+	if err != nil {
+		logging.Error("Failed to initialize reaction engine", "error", err)
+		os.Exit(1)
+	}
+	defer reactionEngine.Close()
+
+	// Initialize the webhook subsystem: a Registry of per-NPC subscriptions
+	// and a Dispatcher that delivers NPC lifecycle/tick events to them
+	// through a bounded worker pool, backed by a BoltDB delivery store so
+	// anything still pending survives a restart.
+	webhookStore, err := webhook.NewBoltDeliveryStore(config.WebhookStorePath)
+	if err != nil {
+		logging.Error("Failed to initialize webhook delivery store", "error", err, "path", config.WebhookStorePath)
+		os.Exit(1)
+	}
+	defer webhookStore.Close()
+	webhookRegistry := webhook.NewRegistry()
+	webhookDispatcher := webhook.NewDispatcher(webhookRegistry, webhookStore, config.WebhookWorkers)
+	webhookDispatcher.Start()
+	defer webhookDispatcher.Stop()
+	webhookHandlers := webhook.NewHandlers(webhookRegistry, webhookStore)
+
+	// Initialize NPC storage and handlers, backed by whichever npc.Store the config selects
+	npcStore, err := npc.NewStore(config.NPCStoreURL)
+	if err != nil {
+		logging.Error("Failed to initialize NPC store", "error", err, "npc_store_url", config.NPCStoreURL)
+		os.Exit(1)
+	}
+	npcStorage := npc.NewNPCStorageWithStore(npcStore)
 
-	// Initialize NPC storage and handlers
-	npcStorage := npc.NewNPCStorage()
-	npcHandlers := npc.NewNPCHandlers(npcStorage, toolRegistry, sessionManager)
+	llmProvider, err := llm.NewProvider(config)
+	if err != nil {
+		logging.Error("Failed to initialize LLM provider", "error", err, "llm_provider", config.LLMProvider)
+		os.Exit(1)
+	}
+	npcHandlers := npc.NewNPCHandlers(npcStorage, toolRegistry, sessionManager, promptRegistry, webhookDispatcher, ruleSession, memoryRetriever, llmProvider, agentRegistry, reactionEngine)
 
 	// Initialize tool handlers
 	toolHandlers := tools.NewToolHandlers(sessionManager)
 
+	// Initialize flowtest handlers, so scenarios can be run against a live server session
+	flowtestHandlers := flowtest.NewHandlers(toolRegistry)
+
 	if *httpMode {
 		logging.Info("Starting LLM NPC Backend server",
 			"mode", "HTTP",
@@ -72,7 +234,7 @@ func main() {
 			"log_level", config.LogLevel,
 			"cerebras_base_url", config.BaseUrl,
 			"tools_count", len(toolRegistry.GetTools()),
-			"npc_endpoints", "POST /npc/register, POST /npc/act, GET /npc/list, GET /npc/{id}, DELETE /npc/{id}",
+			"npc_endpoints", "POST /npc/register, POST /npc/act, POST /npc/act/stream, GET /npc/list, GET /npc/{id}, DELETE /npc/{id}, GET /npc/prompts, POST /npc/prompts/reload",
 			"tool_endpoints", "POST /tools/register, GET /tools/session/{id}")
 	} else {
 		logging.Info("Starting LLM NPC Backend server",
@@ -81,7 +243,7 @@ func main() {
 			"log_level", config.LogLevel,
 			"cerebras_base_url", config.BaseUrl,
 			"tools_count", len(toolRegistry.GetTools()),
-			"npc_endpoints", "POST /npc/register, POST /npc/act, GET /npc/list, GET /npc/{id}, DELETE /npc/{id}",
+			"npc_endpoints", "POST /npc/register, POST /npc/act, POST /npc/act/stream, GET /npc/list, GET /npc/{id}, DELETE /npc/{id}, GET /npc/prompts, POST /npc/prompts/reload",
 			"tool_endpoints", "POST /tools/register, GET /tools/session/{id}")
 	}
 
@@ -131,7 +293,7 @@ func main() {
 		}
 
 		// Use ActForTick which now returns detailed results
-		result := mockNPC.ActForTick(mockInput)
+		result := mockNPC.ActForTick(r.Context(), mockInput)
 
 		if !result.Success {
 			api.WriteErrorResponse(w, http.StatusInternalServerError, result.ErrorMessage, api.ErrCodeInternalServer, nil, r.Context())
@@ -151,73 +313,53 @@ func main() {
 		})
 	})
 
-	// Define the console handler for reading scratchpads
-	consoleHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Get all scratchpads from storage
-		allScratchpads := scratchpadStorage.GetAllScratchpads()
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"command": "read_scratchpads",
-			"success": true,
-			"data":    allScratchpads,
-		})
-	})
-
-	// Apply middleware to handlers
-	http.Handle("/", api.ApplyDefaultMiddleware(
-		api.WithMethodValidation(rootHandler, "GET"),
-	))
-
-	http.Handle("/health", api.ApplyDefaultMiddleware(
-		api.WithMethodValidation(healthHandler, "GET"),
-	))
+	// Console registry: introspection commands register themselves here and
+	// are automatically exposed at /console/<name>, discoverable via
+	// /console/_list, instead of each one needing its own handler and route.
+	consoleRegistry := console.NewRegistry()
+	if err := consoleRegistry.Register("read_scratchpads", func(args []string) (any, error) {
+		return scratchpadStorage.GetAllScratchpads(), nil
+	}); err != nil {
+		logging.Error("Failed to register console command", "command", "read_scratchpads", "error", err)
+		os.Exit(1)
+	}
 
-	http.Handle("/npc", api.ApplyDefaultMiddleware(
-		api.WithMethodValidation(npcHandler, "GET"),
-	))
+	// Register routes on a Router, which wires up ApplyDefaultMiddleware and
+	// Go's method+path-parameter ServeMux patterns (e.g. "/npc/{id}") per
+	// route, instead of each handler trimming/splitting r.URL.Path itself.
+	// Nested resources (e.g. a future "/npc/{id}/history") register the same
+	// way, with their own pattern.
+	router := api.NewRouter()
 
-	http.Handle("/console/read_scratchpads", api.ApplyDefaultMiddleware(
-		api.WithMethodValidation(consoleHandler, "GET"),
-	))
+	router.Handle("GET", "/", rootHandler)
+	router.Handle("GET", "/health", healthHandler)
+	router.Handle("GET", "/health/providers", http.HandlerFunc(llm.HealthHandler))
+	router.Handle("GET", "/metrics", promhttp.Handler())
+	router.Handle("GET", "/npc", npcHandler)
+	router.Handle("GET", "/console/", consoleRegistry.Handler())
 
 	// NPC management endpoints
-	http.Handle("/npc/register", api.ApplyDefaultMiddleware(
-		api.WithMethodValidation(http.HandlerFunc(npcHandlers.RegisterHandler), "POST"),
-	))
-
-	http.Handle("/npc/act", api.ApplyDefaultMiddleware(
-		api.WithMethodValidation(http.HandlerFunc(npcHandlers.ActHandler), "POST"),
-	))
-
-	http.Handle("/npc/list", api.ApplyDefaultMiddleware(
-		api.WithMethodValidation(http.HandlerFunc(npcHandlers.ListHandler), "GET"),
-	))
-
-	// NPC-specific endpoints (GET and DELETE /npc/{id})
-	npcGetDeleteHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case "GET":
-			npcHandlers.GetHandler(w, r)
-		case "DELETE":
-			npcHandlers.DeleteHandler(w, r)
-		default:
-			api.WriteErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed", api.ErrCodeMethodNotAllowed, nil, r.Context())
-		}
-	})
-
-	http.Handle("/npc/", api.ApplyDefaultMiddleware(
-		api.WithMethodValidation(npcGetDeleteHandler, "GET", "DELETE"),
-	))
+	router.Handle("POST", "/npc/register", npcHandlers.RegisterHandler)
+	router.Handle("POST", "/npc/act", npcHandlers.ActHandler)
+	router.Handle("POST", "/npc/act/stream", npcHandlers.ActStreamHandler)
+	router.Handle("GET", "/npc/list", npcHandlers.ListHandler)
+	router.Handle("GET", "/npc/{id}", npcHandlers.GetHandler)
+	router.Handle("DELETE", "/npc/{id}", npcHandlers.DeleteHandler)
 
 	// Tool management endpoints
-	http.Handle("/tools/register", api.ApplyDefaultMiddleware(
-		api.WithMethodValidation(http.HandlerFunc(toolHandlers.RegisterHandler), "POST"),
-	))
+	router.Handle("POST", "/tools/register", toolHandlers.RegisterHandler)
+	router.Handle("GET", "/tools/session/{id}", toolHandlers.SessionInfoHandler)
+	router.Handle("GET", "/tools/session/{id}/history", toolHandlers.SessionHistoryHandler)
+
+	router.Handle("POST", "/npc/{id}/webhooks", webhookHandlers.RegisterHandler)
+	router.Handle("GET", "/npc/{id}/webhooks/deliveries", webhookHandlers.DeliveriesHandler)
 
-	http.Handle("/tools/session/", api.ApplyDefaultMiddleware(
-		api.WithMethodValidation(http.HandlerFunc(toolHandlers.SessionInfoHandler), "GET"),
-	))
+	// Prompt registry admin endpoints
+	router.Handle("GET", "/npc/prompts", npcHandlers.PromptsListHandler)
+	router.Handle("POST", "/npc/prompts/reload", npcHandlers.PromptsReloadHandler)
+
+	// Flowtest endpoints
+	router.HandleJSON("POST", "/npc/flowtest/run", flowtestHandlers.RunHandler)
 
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -226,7 +368,8 @@ func main() {
 	if *httpMode {
 		// HTTP mode
 		server := &http.Server{
-			Addr: config.HTTPPort,
+			Addr:    config.HTTPPort,
+			Handler: router,
 		}
 
 		go func() {
@@ -252,6 +395,11 @@ func main() {
 		}
 		defer listener.Close()
 
+		if err := applySocketPermissions(config.SocketPath, config); err != nil {
+			logging.Error("Failed to set Unix socket permissions", "error", err, "socket", config.SocketPath)
+			os.Exit(1)
+		}
+
 		go func() {
 			<-sigChan
 			logging.Info("Shutting down server...")
@@ -262,7 +410,7 @@ func main() {
 		logging.Info("Server listening on Unix socket", "socket", config.SocketPath)
 
 		// Start serving on the Unix socket
-		err = http.Serve(listener, nil)
+		err = http.Serve(listener, router)
 		if err != nil && err != net.ErrClosed {
 			logging.Error("Server error", "error", err)
 			os.Exit(1)
@@ -0,0 +1,63 @@
+// Command flowtest loads NPC conversation scenarios from a directory and runs
+// them against a tool registry, reporting results as JUnit XML and a
+// human-readable summary.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/piercegov/llm-npc-backend/internal/logging"
+	"github.com/piercegov/llm-npc-backend/internal/npc/flowtest"
+	"github.com/piercegov/llm-npc-backend/internal/tools"
+)
+
+func main() {
+	scenarioDir := flag.String("dir", "scenarios", "Directory containing flowtest scenarios (.json/.csv)")
+	junitOut := flag.String("junit-out", "flowtest-results.xml", "Path to write JUnit XML results")
+	recallK := flag.Int("recall-k", 1, "Number of inference rounds considered for the Recall@K soft pass")
+	flag.Parse()
+
+	logging.InitLogger("info")
+
+	scenarios, err := flowtest.LoadScenariosFromDir(*scenarioDir)
+	if err != nil {
+		logging.Error("Failed to load scenarios", "error", err, "dir", *scenarioDir)
+		os.Exit(1)
+	}
+
+	toolRegistry := tools.NewToolRegistry()
+	if err := tools.RegisterScratchpadTools(toolRegistry, tools.NewScratchpadStorage()); err != nil {
+		logging.Error("Failed to register scratchpad tools", "error", err)
+		os.Exit(1)
+	}
+
+	runner := flowtest.NewRunner(toolRegistry)
+	runner.RecallK = *recallK
+
+	report := runner.RunAll(context.Background(), scenarios)
+
+	if err := flowtest.WriteSummary(os.Stdout, report); err != nil {
+		logging.Error("Failed to write summary", "error", err)
+	}
+
+	junitFile, err := os.Create(*junitOut)
+	if err != nil {
+		logging.Error("Failed to create JUnit output file", "error", err, "path", *junitOut)
+		os.Exit(1)
+	}
+	defer junitFile.Close()
+
+	if err := flowtest.WriteJUnitXML(junitFile, report); err != nil {
+		logging.Error("Failed to write JUnit XML", "error", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Ran %d scenario(s), wrote JUnit results to %s\n", len(scenarios), *junitOut)
+
+	if !report.Passed() {
+		os.Exit(1)
+	}
+}
@@ -2,14 +2,13 @@ package main
 
 import (
 	"bufio"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"image/color"
-	"net"
 	"net/http"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -20,34 +19,48 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/widget"
+
+	"github.com/piercegov/llm-npc-backend/internal/console"
+	"github.com/piercegov/llm-npc-backend/internal/logging"
 )
 
+// consoleSocketPath is where cmd/backend listens in Unix socket mode.
+const consoleSocketPath = "/tmp/llm-npc-backend.sock"
+
+// defaultLogCap bounds the in-memory ring buffer so a long backend run
+// doesn't grow the viewer's memory use without limit.
+const defaultLogCap = 5000
+
 type LogViewer struct {
-	logContainer *fyne.Container
-	searchBar    *widget.Entry
-	consoleBar   *widget.Entry
-	logBinding   binding.String
-	allLogs      []string
-	cmd          *exec.Cmd
+	logContainer  *fyne.Container
+	logScroll     *container.Scroll
+	searchBar     *widget.Entry
+	consoleBar    *widget.Entry
+	followTail    *widget.Check
+	logBinding    binding.String
+	logs          *logging.RingBuffer
+	cmd           *exec.Cmd
+	consoleClient *http.Client
 }
 
 func main() {
 	cliMode := flag.Bool("cli", false, "Run in CLI mode instead of GUI")
+	logCap := flag.Int("log-cap", defaultLogCap, "Maximum number of log records to keep in memory")
 	flag.Parse()
 
 	if *cliMode {
 		runCLIMode()
 	} else {
-		runGUIMode()
+		runGUIMode(*logCap)
 	}
 }
 
-func runGUIMode() {
+func runGUIMode(logCap int) {
 	myApp := app.New()
 	myWindow := myApp.NewWindow("LLM NPC Backend - Log Viewer")
 	myWindow.Resize(fyne.NewSize(1000, 700))
 
-	logViewer := NewLogViewer()
+	logViewer := NewLogViewer(logCap)
 	content := logViewer.CreateUI()
 
 	myWindow.SetContent(content)
@@ -62,32 +75,40 @@ func runGUIMode() {
 	}
 }
 
-func NewLogViewer() *LogViewer {
+func NewLogViewer(logCap int) *LogViewer {
 	logBinding := binding.NewString()
 	logContainer := container.NewVBox()
 
 	return &LogViewer{
-		logContainer: logContainer,
-		logBinding:   logBinding,
-		allLogs:      make([]string, 0),
+		logContainer:  logContainer,
+		logBinding:    logBinding,
+		logs:          logging.NewRingBuffer(logCap),
+		consoleClient: console.NewSocketClient(consoleSocketPath),
 	}
 }
 
 func (lv *LogViewer) CreateUI() *fyne.Container {
 	lv.searchBar = widget.NewEntry()
-	lv.searchBar.SetPlaceHolder("Search logs (Cmd+F)...")
+	lv.searchBar.SetPlaceHolder("Search logs (level>=warn, npc_id=guard_01, tool:scratch, text AND text)...")
 	lv.searchBar.OnChanged = lv.searchLogs
 	lv.searchBar.Resize(fyne.NewSize(600, 40))
 
+	lv.followTail = widget.NewCheck("Follow tail", func(followed bool) {
+		if followed {
+			lv.updateDisplay()
+		}
+	})
+	lv.followTail.SetChecked(true)
+
 	lv.consoleBar = widget.NewEntry()
-	lv.consoleBar.SetPlaceHolder("Console command (e.g., 'read_scratchpads')...")
+	lv.consoleBar.SetPlaceHolder("Console command (try 'help')...")
 	lv.consoleBar.OnSubmitted = lv.executeConsoleCommand
 	lv.consoleBar.Resize(fyne.NewSize(600, 40))
 
 	clearBtn := widget.NewButton("Clear Logs", lv.clearLogs)
 
 	toolbar := container.NewBorder(
-		nil, nil, nil, clearBtn,
+		nil, nil, nil, container.NewHBox(lv.followTail, clearBtn),
 		lv.searchBar,
 	)
 
@@ -96,15 +117,15 @@ func (lv *LogViewer) CreateUI() *fyne.Container {
 		lv.consoleBar,
 	)
 
-	logScroll := container.NewScroll(lv.logContainer)
-	logScroll.SetMinSize(fyne.NewSize(900, 550))
+	lv.logScroll = container.NewScroll(lv.logContainer)
+	lv.logScroll.SetMinSize(fyne.NewSize(900, 550))
 
 	return container.NewBorder(
 		container.NewVBox(toolbar, consolebar),
 		nil,
 		nil,
 		nil,
-		logScroll,
+		lv.logScroll,
 	)
 }
 
@@ -172,24 +193,70 @@ func (lv *LogViewer) StartBackendAndStream() {
 }
 
 func (lv *LogViewer) appendLog(text string) {
-	timestamp := time.Now().Format("15:04:05")
-	logLine := fmt.Sprintf("[%s] %s", timestamp, strings.TrimSpace(text))
-
-	lv.allLogs = append(lv.allLogs, logLine)
+	rec, ok := logging.ParseRecord(strings.TrimSpace(text))
+	if !ok {
+		now := time.Now()
+		rec.Time = now
+		rec.Raw = fmt.Sprintf("[%s] %s", now.Format("15:04:05"), strings.TrimSpace(text))
+	}
+	lv.logs.Push(rec)
 
 	fyne.Do(func() {
 		lv.updateDisplayInternal()
 	})
 }
 
-func (lv *LogViewer) createLogEntry(text string, isAlternate bool) fyne.CanvasObject {
-	entry := widget.NewEntry()
-	entry.MultiLine = true
-	entry.Wrapping = fyne.TextWrapWord
-	entry.SetText(text)
-	entry.OnChanged = func(string) {}
-	entry.Disable()
-	entry.Enable()
+// levelColor returns the color a log entry's header is rendered in, so
+// warnings and errors stand out from routine debug/info traffic at a glance.
+func levelColor(level logging.Level) color.Color {
+	switch level {
+	case logging.LevelDebug:
+		return color.RGBA{150, 150, 150, 255}
+	case logging.LevelInfo:
+		return color.RGBA{100, 181, 246, 255}
+	case logging.LevelWarn:
+		return color.RGBA{255, 193, 7, 255}
+	case logging.LevelError:
+		return color.RGBA{244, 67, 54, 255}
+	default:
+		return color.White
+	}
+}
+
+// newFieldsTable renders a record's structured key/value fields, sorted by
+// key so the same field lands in the same place across entries.
+func newFieldsTable(fields map[string]string) fyne.CanvasObject {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rows := container.NewVBox()
+	for _, key := range keys {
+		rows.Add(widget.NewLabel(fmt.Sprintf("%s: %s", key, fields[key])))
+	}
+	return rows
+}
+
+func (lv *LogViewer) createLogEntry(rec logging.Record, isAlternate bool) fyne.CanvasObject {
+	headerText := rec.Raw
+	if rec.Level != "" {
+		timestamp := rec.Time
+		if timestamp.IsZero() {
+			timestamp = time.Now()
+		}
+		headerText = fmt.Sprintf("[%s] %s %s", timestamp.Format("15:04:05"), rec.Level, rec.Message)
+	}
+
+	header := canvas.NewText(headerText, levelColor(rec.Level))
+	header.TextStyle = fyne.TextStyle{Monospace: true}
+
+	body := fyne.CanvasObject(header)
+	if len(rec.Fields) > 0 {
+		fieldsAccordion := widget.NewAccordion(widget.NewAccordionItem("fields", newFieldsTable(rec.Fields)))
+		body = container.NewVBox(header, fieldsAccordion)
+	}
 
 	var bgColor color.Color
 	if isAlternate {
@@ -199,7 +266,7 @@ func (lv *LogViewer) createLogEntry(text string, isAlternate bool) fyne.CanvasOb
 	}
 
 	bg := canvas.NewRectangle(bgColor)
-	return container.NewStack(bg, container.NewPadded(entry))
+	return container.NewStack(bg, container.NewPadded(body))
 }
 
 func (lv *LogViewer) updateDisplay() {
@@ -209,122 +276,75 @@ func (lv *LogViewer) updateDisplay() {
 func (lv *LogViewer) updateDisplayInternal() {
 	lv.logContainer.Objects = nil
 
-	var logsToShow []string
-	searchTerm := lv.searchBar.Text
-	if searchTerm != "" {
-		logsToShow = lv.filterLogs(searchTerm)
-	} else {
-		logsToShow = lv.allLogs
-	}
+	query := logging.ParseQuery(lv.searchBar.Text)
 
-	for i, logLine := range logsToShow {
-		entry := lv.createLogEntry(logLine, i%2 == 1)
-		lv.logContainer.Add(entry)
+	shown := 0
+	for _, rec := range lv.logs.Snapshot() {
+		if !query.Matches(rec) {
+			continue
+		}
+		lv.logContainer.Add(lv.createLogEntry(rec, shown%2 == 1))
+		shown++
 	}
 
 	lv.logContainer.Refresh()
+
+	if lv.followTail != nil && lv.followTail.Checked && lv.logScroll != nil {
+		lv.logScroll.ScrollToBottom()
+	}
 }
 
 func (lv *LogViewer) searchLogs(searchTerm string) {
 	lv.updateDisplay()
 }
 
-func (lv *LogViewer) filterLogs(searchTerm string) []string {
-	if searchTerm == "" {
-		return lv.allLogs
-	}
-
-	var filteredLines []string
-
-	for _, line := range lv.allLogs {
-		if strings.Contains(strings.ToLower(line), strings.ToLower(searchTerm)) {
-			filteredLines = append(filteredLines, line)
-		}
-	}
-
-	return filteredLines
-}
-
 func (lv *LogViewer) clearLogs() {
-	lv.allLogs = make([]string, 0)
+	lv.logs.Clear()
 	lv.updateDisplay()
 }
 
 func (lv *LogViewer) executeConsoleCommand(command string) {
 	lv.appendLog(fmt.Sprintf("> %s", command))
 
-	switch command {
-	case "read_scratchpads":
-		lv.readScratchpads()
+	name, args := console.ParseCommand(command)
+	switch name {
+	case "":
+		// Empty command, do nothing
+	case "help":
+		lv.listCommands()
 	default:
-		lv.appendLog(fmt.Sprintf("Unknown command: %s", command))
+		lv.runCommand(name, args)
 	}
 
 	lv.consoleBar.SetText("")
 }
 
-func (lv *LogViewer) readScratchpads() {
-	socketPath := "/tmp/llm-npc-backend.sock"
-
-	// Create HTTP client that uses Unix domain socket
-	client := &http.Client{
-		Transport: &http.Transport{
-			Dial: func(network, addr string) (net.Conn, error) {
-				return net.Dial("unix", socketPath)
-			},
-		},
-	}
-
-	// Make request to console endpoint
-	resp, err := client.Get("http://unix/console/read_scratchpads")
+// listCommands prints every command the backend's console.Registry has
+// registered, fetched via /console/_list.
+func (lv *LogViewer) listCommands() {
+	names, err := console.List(lv.consoleClient)
 	if err != nil {
-		lv.appendLog(fmt.Sprintf("Failed to read scratchpads: %v", err))
+		lv.appendLog(fmt.Sprintf("Failed to list commands: %v", err))
 		return
 	}
-	defer resp.Body.Close()
+	lv.appendLog(fmt.Sprintf("Available commands: %s", strings.Join(names, ", ")))
+}
 
-	// Parse response
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		lv.appendLog(fmt.Sprintf("Failed to parse response: %v", err))
+// runCommand invokes /console/<name> with the given "key=value" args and
+// pretty-prints whatever data comes back, without needing to know its shape.
+func (lv *LogViewer) runCommand(name string, args []string) {
+	data, err := console.Call(lv.consoleClient, name, args)
+	if err != nil {
+		lv.appendLog(err.Error())
 		return
 	}
-
-	// Display results
-	if success, ok := result["success"].(bool); ok && success {
-		if data, ok := result["data"].(map[string]interface{}); ok {
-			if len(data) == 0 {
-				lv.appendLog("No scratchpads found")
-			} else {
-				lv.appendLog(fmt.Sprintf("Found %d NPCs with scratchpads:", len(data)))
-				for npcID, npcData := range data {
-					if npcInfo, ok := npcData.(map[string]interface{}); ok {
-						count := npcInfo["count"].(float64)
-						lv.appendLog(fmt.Sprintf("  %s: %d entries", npcID, int(count)))
-
-						if entries, ok := npcInfo["entries"].([]interface{}); ok {
-							for _, entry := range entries {
-								if entryMap, ok := entry.(map[string]interface{}); ok {
-									key := entryMap["key"].(string)
-									value := entryMap["value"].(string)
-									timestamp := entryMap["timestamp"].(string)
-									lv.appendLog(fmt.Sprintf("    %s: %s (at %s)", key, value, timestamp))
-								}
-							}
-						}
-					}
-				}
-			}
-		}
-	} else {
-		lv.appendLog("Failed to read scratchpads")
-	}
+	lv.appendLog(console.Pretty(data))
 }
 
 func runCLIMode() {
 	fmt.Println("LLM NPC Backend - CLI Mode")
 	fmt.Println("Starting backend and streaming logs...")
-	fmt.Println("Type 'read_scratchpads' to read scratchpads, 'quit' to exit")
+	fmt.Println("Type 'help' to list console commands, 'quit' to exit")
 	fmt.Println("---")
 
 	// Start the backend process and log streaming
@@ -361,10 +381,11 @@ type CLIViewer struct {
 	cmd           *exec.Cmd
 	promptActive  bool
 	promptMutex   sync.Mutex
+	consoleClient *http.Client
 }
 
 func NewCLIViewer() *CLIViewer {
-	return &CLIViewer{}
+	return &CLIViewer{consoleClient: console.NewSocketClient(consoleSocketPath)}
 }
 
 func (cv *CLIViewer) showPrompt() {
@@ -462,71 +483,35 @@ func (cv *CLIViewer) startBackendAndStream() {
 }
 
 func (cv *CLIViewer) executeCommand(command string) {
-	switch command {
-	case "read_scratchpads":
-		cv.readScratchpads()
+	name, args := console.ParseCommand(command)
+	switch name {
 	case "":
 		// Empty command, do nothing
+	case "help":
+		cv.listCommands()
 	default:
-		cv.printCommandResponse(fmt.Sprintf("Unknown command: %s", command))
-		cv.printCommandResponse("Available commands: read_scratchpads, quit")
+		cv.runCommand(name, args)
 	}
 }
 
-func (cv *CLIViewer) readScratchpads() {
-	socketPath := "/tmp/llm-npc-backend.sock"
-	
-	// Create HTTP client that uses Unix domain socket
-	client := &http.Client{
-		Transport: &http.Transport{
-			Dial: func(network, addr string) (net.Conn, error) {
-				return net.Dial("unix", socketPath)
-			},
-		},
-	}
-	
-	// Make request to console endpoint
-	resp, err := client.Get("http://unix/console/read_scratchpads")
+// listCommands prints every command the backend's console.Registry has
+// registered, fetched via /console/_list.
+func (cv *CLIViewer) listCommands() {
+	names, err := console.List(cv.consoleClient)
 	if err != nil {
-		cv.printCommandResponse(fmt.Sprintf("Failed to read scratchpads: %v", err))
+		cv.printCommandResponse(fmt.Sprintf("Failed to list commands: %v", err))
 		return
 	}
-	defer resp.Body.Close()
-	
-	// Parse response
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		cv.printCommandResponse(fmt.Sprintf("Failed to parse response: %v", err))
+	cv.printCommandResponse(fmt.Sprintf("Available commands: %s (plus 'quit')", strings.Join(names, ", ")))
+}
+
+// runCommand invokes /console/<name> with the given "key=value" args and
+// pretty-prints whatever data comes back, without needing to know its shape.
+func (cv *CLIViewer) runCommand(name string, args []string) {
+	data, err := console.Call(cv.consoleClient, name, args)
+	if err != nil {
+		cv.printCommandResponse(err.Error())
 		return
 	}
-	
-	// Display results
-	if success, ok := result["success"].(bool); ok && success {
-		if data, ok := result["data"].(map[string]interface{}); ok {
-			if len(data) == 0 {
-				cv.printCommandResponse("No scratchpads found")
-			} else {
-				cv.printCommandResponse(fmt.Sprintf("Found %d NPCs with scratchpads:", len(data)))
-				for npcID, npcData := range data {
-					if npcInfo, ok := npcData.(map[string]interface{}); ok {
-						count := npcInfo["count"].(float64)
-						cv.printCommandResponse(fmt.Sprintf("  %s: %d entries", npcID, int(count)))
-						
-						if entries, ok := npcInfo["entries"].([]interface{}); ok {
-							for _, entry := range entries {
-								if entryMap, ok := entry.(map[string]interface{}); ok {
-									key := entryMap["key"].(string)
-									value := entryMap["value"].(string)
-									timestamp := entryMap["timestamp"].(string)
-									cv.printCommandResponse(fmt.Sprintf("    %s: %s (at %s)", key, value, timestamp))
-								}
-							}
-						}
-					}
-				}
-			}
-		}
-	} else {
-		cv.printCommandResponse("Failed to read scratchpads")
-	}
+	cv.printCommandResponse(console.Pretty(data))
 }
@@ -0,0 +1,33 @@
+// Package agents lets a game designer define named personas once — a
+// system-prompt template, an allowed tool subset, a default knowledge-graph
+// depth, and any always-included context — and have NPCs reference one by
+// name at registration time, instead of a caller hand-building a
+// tools.ToolRegistry and npc.PromptOptions for every NPC on every tick. A
+// "merchant" Agent might expose only trade tools; a "narrator" Agent none
+// at all.
+package agents
+
+// Agent is a named bundle of persona, toolset, and knowledge-graph scope.
+type Agent struct {
+	// Name identifies this agent, e.g. "merchant"; it's what NPCs reference
+	// via NPC.AgentName and what on-disk YAML files are keyed by.
+	Name string `yaml:"name"`
+	// PromptTemplate selects the system prompt template this agent uses, in
+	// the same "archetype.locale" / "archetype.locale.vN" / bare-name form
+	// npc.PromptOptions.Template accepts. Empty defers to the NPC/request's
+	// own template resolution.
+	PromptTemplate string `yaml:"prompt_template"`
+	// AllowedTools restricts which tools this agent's NPCs may call, by
+	// name. Nil (the field omitted from YAML) means no restriction: every
+	// tool the caller's ToolRegistry provides is available. A non-nil, empty
+	// list means no tools at all, the shape a "narrator" agent wants.
+	AllowedTools []string `yaml:"allowed_tools"`
+	// KnowledgeGraphDepth is this agent's default NPCTickInput.KnowledgeGraphDepth,
+	// used whenever a per-tick request leaves it at 0.
+	KnowledgeGraphDepth int `yaml:"knowledge_graph_depth"`
+	// AlwaysInclude is appended to the system prompt verbatim, one line per
+	// entry, for lore or rules that should apply to every NPC using this
+	// agent regardless of what the per-tick request supplies (e.g. "Never
+	// reveal the guild's secret handshake.").
+	AlwaysInclude []string `yaml:"always_include"`
+}
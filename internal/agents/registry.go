@@ -0,0 +1,162 @@
+package agents
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+
+	"github.com/piercegov/llm-npc-backend/internal/logging"
+)
+
+// Registry loads Agent definitions from a directory of YAML files (one
+// agent per ".yaml"/".yml" file) and watches it for changes via fsnotify,
+// mirroring npc.PromptRegistry so a designer can iterate on an agent's
+// persona/toolset without restarting the backend.
+type Registry struct {
+	mu     sync.RWMutex
+	dir    string
+	agents map[string]Agent // key: Agent.Name
+
+	watcher *fsnotify.Watcher
+	stop    chan struct{}
+}
+
+// NewRegistry creates a Registry rooted at dir, performs an initial load,
+// and starts watching dir for changes. A missing dir is not an error: the
+// registry starts empty and Get always misses until agents/ is created and
+// reloaded.
+func NewRegistry(dir string) (*Registry, error) {
+	r := &Registry{
+		dir:    dir,
+		agents: make(map[string]Agent),
+		stop:   make(chan struct{}),
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("creating agent registry watcher: %w", err)
+	}
+	if err := watcher.Add(dir); err != nil {
+		logging.Warn("Agent registry: not watching agents directory", "dir", dir, "error", err)
+		watcher.Close()
+	} else {
+		r.watcher = watcher
+		go r.watch()
+	}
+
+	return r, nil
+}
+
+func (r *Registry) watch() {
+	for {
+		select {
+		case event, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				logging.Error("Agent registry: reload failed", "error", err)
+			} else {
+				logging.Info("Agent registry: reloaded agents after filesystem change", "dir", r.dir, "path", event.Name)
+			}
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			logging.Error("Agent registry: watcher error", "error", err)
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// Close stops the filesystem watcher, if one was started.
+func (r *Registry) Close() {
+	close(r.stop)
+	if r.watcher != nil {
+		r.watcher.Close()
+	}
+}
+
+// Reload rescans the agents directory.
+func (r *Registry) Reload() error {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			r.mu.Lock()
+			r.agents = make(map[string]Agent)
+			r.mu.Unlock()
+			return nil
+		}
+		return fmt.Errorf("reading agents directory %q: %w", r.dir, err)
+	}
+
+	loaded := make(map[string]Agent, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+
+		path := filepath.Join(r.dir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			logging.Warn("Agent registry: failed to read agent definition", "path", path, "error", err)
+			continue
+		}
+
+		var agent Agent
+		if err := yaml.Unmarshal(content, &agent); err != nil {
+			logging.Warn("Agent registry: failed to parse agent definition", "path", path, "error", err)
+			continue
+		}
+		if agent.Name == "" {
+			logging.Warn("Agent registry: agent definition missing name, skipping", "path", path)
+			continue
+		}
+
+		loaded[agent.Name] = agent
+	}
+
+	r.mu.Lock()
+	r.agents = loaded
+	r.mu.Unlock()
+	return nil
+}
+
+// Get returns the agent named name, if loaded.
+func (r *Registry) Get(name string) (Agent, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	agent, ok := r.agents[name]
+	return agent, ok
+}
+
+// List returns every loaded agent, sorted by name.
+func (r *Registry) List() []Agent {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]Agent, 0, len(r.agents))
+	for _, agent := range r.agents {
+		list = append(list, agent)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
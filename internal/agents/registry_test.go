@@ -0,0 +1,98 @@
+package agents
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/piercegov/llm-npc-backend/internal/logging"
+)
+
+func init() {
+	logging.InitLogger("debug")
+}
+
+func writeAgentFile(t *testing.T, dir, filename, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, filename), []byte(content), 0644); err != nil {
+		t.Fatalf("writing %s: %v", filename, err)
+	}
+}
+
+func TestRegistry_LoadsAgentsFromDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeAgentFile(t, dir, "merchant.yaml", `
+name: merchant
+prompt_template: merchant
+allowed_tools:
+  - check_inventory
+  - make_trade
+knowledge_graph_depth: 2
+always_include:
+  - "You only discuss trade."
+`)
+	writeAgentFile(t, dir, "narrator.yaml", `
+name: narrator
+prompt_template: narrator
+allowed_tools: []
+`)
+
+	reg, err := NewRegistry(dir)
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	defer reg.Close()
+
+	merchant, ok := reg.Get("merchant")
+	if !ok {
+		t.Fatal("expected to find merchant agent")
+	}
+	if merchant.KnowledgeGraphDepth != 2 || len(merchant.AllowedTools) != 2 {
+		t.Fatalf("unexpected merchant agent: %+v", merchant)
+	}
+
+	narrator, ok := reg.Get("narrator")
+	if !ok {
+		t.Fatal("expected to find narrator agent")
+	}
+	if narrator.AllowedTools == nil || len(narrator.AllowedTools) != 0 {
+		t.Fatalf("expected narrator's AllowedTools to be a non-nil empty slice, got %#v", narrator.AllowedTools)
+	}
+
+	list := reg.List()
+	if len(list) != 2 || list[0].Name != "merchant" || list[1].Name != "narrator" {
+		t.Fatalf("expected List sorted by name, got %+v", list)
+	}
+}
+
+func TestRegistry_MissingDirectoryStartsEmpty(t *testing.T) {
+	reg, err := NewRegistry(filepath.Join(t.TempDir(), "does-not-exist"))
+	if err != nil {
+		t.Fatalf("NewRegistry should tolerate a missing directory, got: %v", err)
+	}
+	defer reg.Close()
+
+	if _, ok := reg.Get("anything"); ok {
+		t.Fatal("expected no agents to be loaded")
+	}
+}
+
+func TestRegistry_SkipsUnparsableAndUnnamedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeAgentFile(t, dir, "broken.yaml", "not: [valid yaml")
+	writeAgentFile(t, dir, "unnamed.yaml", "prompt_template: oops")
+	writeAgentFile(t, dir, "guard.yml", "name: guard\n")
+
+	reg, err := NewRegistry(dir)
+	if err != nil {
+		t.Fatalf("NewRegistry: %v", err)
+	}
+	defer reg.Close()
+
+	if len(reg.List()) != 1 {
+		t.Fatalf("expected only the valid agent to load, got %+v", reg.List())
+	}
+	if _, ok := reg.Get("guard"); !ok {
+		t.Fatal("expected guard agent to load")
+	}
+}
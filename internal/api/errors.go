@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/piercegov/llm-npc-backend/internal/logging"
@@ -51,19 +52,12 @@ var statusToErrorCode = map[int]string{
 // contextKey is a custom type for context keys to avoid collisions
 type contextKey string
 
-// RequestIDKey is the key used to store and retrieve the request ID from context
-const RequestIDKey contextKey = "request_id"
-
-// GetRequestID extracts the request ID from the context
+// GetRequestID extracts the request ID from the context. The request ID
+// itself is stored via logging.WithRequestID (by RequestTracingMiddleware),
+// not a key local to this package, so that lower-level packages such as
+// internal/llm can read it without importing api.
 func GetRequestID(ctx context.Context) string {
-	if ctx == nil {
-		return ""
-	}
-
-	if reqID, ok := ctx.Value(RequestIDKey).(string); ok {
-		return reqID
-	}
-	return ""
+	return logging.RequestIDFromContext(ctx)
 }
 
 // LogRequestError logs an error with the request context and additional fields
@@ -75,6 +69,12 @@ func LogRequestError(ctx context.Context, message string, err error, additionalF
 		fields = append(fields, "request_id", reqID)
 	}
 
+	// Errors that capture their own call stack (e.g. *llm.ProviderError)
+	// implement fmt.Formatter and print it via %+v.
+	if formatter, ok := err.(fmt.Formatter); ok {
+		fields = append(fields, "stack_trace", fmt.Sprintf("%+v", formatter))
+	}
+
 	// Add any additional fields
 	fields = append(fields, additionalFields...)
 
@@ -22,15 +22,20 @@ func PanicRecoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				// Log the panic with stack trace
+				// Log the panic with the goroutine's stack trace
 				stackTrace := debug.Stack()
-				logging.Error("Panic recovered in HTTP handler",
+				fields := []any{
 					"error", err,
-					"request_id", GetRequestID(r.Context()),
-					"path", r.URL.Path,
-					"method", r.Method,
 					"stack_trace", string(stackTrace),
-				)
+				}
+
+				// If the panic value captured its own call stack at
+				// construction time (e.g. *llm.ProviderError), include it too.
+				if formatter, ok := err.(fmt.Formatter); ok {
+					fields = append(fields, "provider_stack_trace", fmt.Sprintf("%+v", formatter))
+				}
+
+				logging.FromContext(r.Context()).Error("Panic recovered in HTTP handler", fields...)
 
 				// Return a generic 500 error to not expose internal details
 				WriteErrorResponse(
@@ -54,7 +59,17 @@ func RequestTracingMiddleware(next http.Handler) http.Handler {
 		requestID := uuid.New().String()
 
 		// Add request ID to context
-		ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
+		ctx := logging.WithRequestID(r.Context(), requestID)
+
+		// Bind a child logger pre-populated with request metadata so downstream
+		// call sites can log via logging.FromContext(r.Context()) instead of
+		// threading the request ID through every call.
+		reqLogger := logging.Logger.With(
+			"request_id", requestID,
+			"method", r.Method,
+			"path", r.URL.Path,
+		)
+		ctx = logging.WithContext(ctx, reqLogger)
 		r = r.WithContext(ctx)
 
 		// Add request ID to response headers
@@ -64,10 +79,7 @@ func RequestTracingMiddleware(next http.Handler) http.Handler {
 		startTime := time.Now()
 
 		// Log incoming request
-		logging.Info("Request started",
-			"request_id", requestID,
-			"method", r.Method,
-			"path", r.URL.Path,
+		reqLogger.Info("Request started",
 			"remote_addr", r.RemoteAddr,
 			"user_agent", r.UserAgent(),
 		)
@@ -86,18 +98,12 @@ func RequestTracingMiddleware(next http.Handler) http.Handler {
 
 		// Log request completion with status code and duration
 		if ww.statusCode >= 400 {
-			logging.Warn("Request completed with error",
-				"request_id", requestID,
-				"method", r.Method,
-				"path", r.URL.Path,
+			reqLogger.Warn("Request completed with error",
 				"status_code", ww.statusCode,
 				"duration_ms", duration.Milliseconds(),
 			)
 		} else {
-			logging.Info("Request completed",
-				"request_id", requestID,
-				"method", r.Method,
-				"path", r.URL.Path,
+			reqLogger.Info("Request completed",
 				"status_code", ww.statusCode,
 				"duration_ms", duration.Milliseconds(),
 			)
@@ -121,11 +127,11 @@ func ValidationMiddleware(allowedMethods []string, requireJSON bool) func(http.H
 			if !methodAllowed {
 				// Set Allow header with accepted methods
 				w.Header().Set("Allow", strings.Join(allowedMethods, ", "))
-				
+
 				WriteErrorResponse(
 					w,
 					http.StatusMethodNotAllowed,
-					fmt.Sprintf("Method %s not allowed, supported methods: %s", 
+					fmt.Sprintf("Method %s not allowed, supported methods: %s",
 						r.Method, strings.Join(allowedMethods, ", ")),
 					ErrCodeMethodNotAllowed,
 					nil,
@@ -194,7 +200,7 @@ func ErrorHandlingMiddleware(next http.Handler) http.Handler {
 		// Add error handling utilities to the request context
 		ctx := context.WithValue(r.Context(), contextKey("error_handler"), true)
 		r = r.WithContext(ctx)
-		
+
 		next.ServeHTTP(w, r)
 	})
 }
@@ -271,12 +277,26 @@ func WithJSONValidation(handler http.HandlerFunc, methods ...string) http.Handle
 	)
 }
 
-// ApplyDefaultMiddleware applies all the standard middleware in the correct order
-func ApplyDefaultMiddleware(handler http.Handler) http.Handler {
-	return ChainMiddleware(
-		handler,
+// ApplyDefaultMiddleware applies all the standard middleware in the correct
+// order. An optional route pattern (the same string the handler is
+// registered under) selects a per-route rate limit configured via
+// ConfigureRateLimiting; omitting it, or not matching an override, falls
+// back to the default rate limit.
+func ApplyDefaultMiddleware(handler http.Handler, route ...string) http.Handler {
+	middlewares := []func(http.Handler) http.Handler{
 		RequestTracingMiddleware,
 		PanicRecoveryMiddleware,
-		ErrorHandlingMiddleware,
-	)
-}
\ No newline at end of file
+	}
+
+	routePattern := ""
+	if len(route) > 0 {
+		routePattern = route[0]
+	}
+	if limiter := rateLimiterForRoute(routePattern); limiter != nil {
+		middlewares = append(middlewares, limiter)
+	}
+
+	middlewares = append(middlewares, ErrorHandlingMiddleware)
+
+	return ChainMiddleware(handler, middlewares...)
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/piercegov/llm-npc-backend/internal/cfg"
+	"github.com/piercegov/llm-npc-backend/internal/logging"
+)
+
+// bucketIdleTimeout is how long a key's bucket can sit unused before the
+// janitor reclaims it.
+const bucketIdleTimeout = 10 * time.Minute
+
+// tokenBucket is one key's token-bucket state: tokens refill continuously at
+// rps and are capped at burst.
+type tokenBucket struct {
+	mu         sync.Mutex
+	tokens     float64
+	lastRefill time.Time
+	lastSeen   time.Time
+}
+
+// RateLimiter is an in-memory, per-key token-bucket rate limiter with a
+// background janitor that evicts buckets idle longer than bucketIdleTimeout
+// so long-running processes don't leak memory over many distinct keys.
+type RateLimiter struct {
+	rps   float64
+	burst int
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// NewRateLimiter creates a RateLimiter allowing rps sustained requests per
+// second per key, with bursts up to burst tokens, and starts its janitor.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	rl := &RateLimiter{
+		rps:     rps,
+		burst:   burst,
+		buckets: make(map[string]*tokenBucket),
+	}
+	go rl.runJanitor()
+	return rl
+}
+
+func (rl *RateLimiter) runJanitor() {
+	ticker := time.NewTicker(bucketIdleTimeout)
+	defer ticker.Stop()
+	for range ticker.C {
+		rl.evictIdleBuckets()
+	}
+}
+
+func (rl *RateLimiter) evictIdleBuckets() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	for key, bucket := range rl.buckets {
+		bucket.mu.Lock()
+		idle := time.Since(bucket.lastSeen) > bucketIdleTimeout
+		bucket.mu.Unlock()
+		if idle {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// Allow reports whether a request for key is within limits. If refused, it
+// also returns how long the caller should wait before retrying.
+func (rl *RateLimiter) Allow(key string) (allowed bool, retryAfter time.Duration, remaining int) {
+	rl.mu.Lock()
+	bucket, ok := rl.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(rl.burst), lastRefill: time.Now()}
+		rl.buckets[key] = bucket
+	}
+	rl.mu.Unlock()
+
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+
+	now := time.Now()
+	bucket.tokens = math.Min(float64(rl.burst), bucket.tokens+now.Sub(bucket.lastRefill).Seconds()*rl.rps)
+	bucket.lastRefill = now
+	bucket.lastSeen = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / rl.rps * float64(time.Second))
+		return false, wait, 0
+	}
+
+	bucket.tokens--
+	return true, 0, int(bucket.tokens)
+}
+
+// RateLimitKeyFunc extracts the bucket key for an incoming request.
+type RateLimitKeyFunc func(*http.Request) string
+
+// DefaultRateLimitKey keys buckets by the request's remote address. It
+// deliberately does not use any client-supplied header (e.g. X-Session-ID):
+// a client that controls its own key can mint a fresh one per request and
+// get a fresh bucket every time, bypassing the limit entirely.
+func DefaultRateLimitKey(r *http.Request) string {
+	return r.RemoteAddr
+}
+
+// RateLimitMiddleware enforces an in-memory token-bucket rate limit of rps
+// requests per second (burst capacity burst) per key, as returned by keyFn.
+// Requests over the limit are rejected with 429, a Retry-After header, and
+// X-RateLimit-Remaining set to 0.
+func RateLimitMiddleware(rps float64, burst int, keyFn RateLimitKeyFunc) func(http.Handler) http.Handler {
+	limiter := NewRateLimiter(rps, burst)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := keyFn(r)
+			allowed, retryAfter, remaining := limiter.Allow(key)
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+
+			if !allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				logging.FromContext(r.Context()).Warn("Rate limit exceeded", "key", key, "path", r.URL.Path)
+				WriteErrorResponse(w, http.StatusTooManyRequests, "Rate limit exceeded, please slow down", ErrCodeRateLimit, nil, r.Context())
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+var (
+	rateLimitMu    sync.Mutex
+	defaultLimiter func(http.Handler) http.Handler
+	routeLimiters  map[string]func(http.Handler) http.Handler
+)
+
+// ConfigureRateLimiting installs the default and per-route rate limiters
+// that ApplyDefaultMiddleware composes into its chain, based on
+// config.RateLimitRPS/RateLimitBurst/RateLimitPerRoute. Call once at startup,
+// before registering routes. A non-positive RateLimitRPS disables rate
+// limiting entirely.
+func ConfigureRateLimiting(config cfg.Config) {
+	rateLimitMu.Lock()
+	defer rateLimitMu.Unlock()
+
+	if config.RateLimitRPS <= 0 {
+		defaultLimiter = nil
+		routeLimiters = nil
+		return
+	}
+
+	defaultLimiter = RateLimitMiddleware(config.RateLimitRPS, config.RateLimitBurst, DefaultRateLimitKey)
+
+	routeLimiters = make(map[string]func(http.Handler) http.Handler, len(config.RateLimitPerRoute))
+	for route, limit := range config.RateLimitPerRoute {
+		routeLimiters[route] = RateLimitMiddleware(limit.RPS, limit.Burst, DefaultRateLimitKey)
+	}
+}
+
+// rateLimiterForRoute returns the configured limiter for route, falling back
+// to the default limiter, or nil if rate limiting hasn't been configured.
+func rateLimiterForRoute(route string) func(http.Handler) http.Handler {
+	rateLimitMu.Lock()
+	defer rateLimitMu.Unlock()
+
+	if route != "" {
+		if limiter, ok := routeLimiters[route]; ok {
+			return limiter
+		}
+	}
+	return defaultLimiter
+}
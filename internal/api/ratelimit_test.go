@@ -0,0 +1,104 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_AllowsWithinBurst(t *testing.T) {
+	rl := NewRateLimiter(1, 3)
+
+	for i := 0; i < 3; i++ {
+		if allowed, _, _ := rl.Allow("client"); !allowed {
+			t.Fatalf("call %d: Allow() = false, want true (within burst)", i)
+		}
+	}
+
+	allowed, retryAfter, remaining := rl.Allow("client")
+	if allowed {
+		t.Fatal("Allow() = true after exhausting burst, want false")
+	}
+	if retryAfter <= 0 {
+		t.Errorf("retryAfter = %v, want > 0", retryAfter)
+	}
+	if remaining != 0 {
+		t.Errorf("remaining = %d, want 0", remaining)
+	}
+}
+
+func TestRateLimiter_RefillsOverTime(t *testing.T) {
+	rl := NewRateLimiter(100, 1)
+
+	if allowed, _, _ := rl.Allow("client"); !allowed {
+		t.Fatal("expected the first call to be allowed")
+	}
+	if allowed, _, _ := rl.Allow("client"); allowed {
+		t.Fatal("expected the second immediate call to be refused")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if allowed, _, _ := rl.Allow("client"); !allowed {
+		t.Fatal("expected a call after refill time to be allowed")
+	}
+}
+
+func TestRateLimiter_KeysAreIndependent(t *testing.T) {
+	rl := NewRateLimiter(1, 1)
+
+	if allowed, _, _ := rl.Allow("client-a"); !allowed {
+		t.Fatal("expected client-a's first call to be allowed")
+	}
+	if allowed, _, _ := rl.Allow("client-b"); !allowed {
+		t.Fatal("expected client-b's first call to be allowed despite client-a exhausting its bucket")
+	}
+}
+
+func TestDefaultRateLimitKey_IgnoresClientSuppliedSessionID(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Session-ID", "session-123")
+	req.RemoteAddr = "10.0.0.1:1234"
+
+	if got := DefaultRateLimitKey(req); got != "10.0.0.1:1234" {
+		t.Errorf("DefaultRateLimitKey() = %q, want %q (a client-supplied header must not override the key)", got, "10.0.0.1:1234")
+	}
+}
+
+func TestDefaultRateLimitKey_UsesRemoteAddr(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+
+	if got := DefaultRateLimitKey(req); got != "10.0.0.1:1234" {
+		t.Errorf("DefaultRateLimitKey() = %q, want %q", got, "10.0.0.1:1234")
+	}
+}
+
+func TestRateLimitMiddleware_RejectsOverLimit(t *testing.T) {
+	handler := RateLimitMiddleware(1, 1, func(*http.Request) string { return "fixed-key" })(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+
+	req := httptest.NewRequest("GET", "/", nil)
+
+	rec1 := httptest.NewRecorder()
+	handler.ServeHTTP(rec1, req)
+	if rec1.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want %d", rec1.Code, http.StatusOK)
+	}
+
+	rec2 := httptest.NewRecorder()
+	handler.ServeHTTP(rec2, req)
+	if rec2.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want %d", rec2.Code, http.StatusTooManyRequests)
+	}
+	if rec2.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header to be set on a rejected request")
+	}
+	if rec2.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("X-RateLimit-Remaining = %q, want %q", rec2.Header().Get("X-RateLimit-Remaining"), "0")
+	}
+}
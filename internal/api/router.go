@@ -0,0 +1,46 @@
+package api
+
+import "net/http"
+
+// Router registers HTTP handlers against method- and path-parameter-aware
+// patterns (e.g. "/npc/{id}/history"), the syntax net/http's ServeMux has
+// understood since Go 1.22, and wraps each one in ApplyDefaultMiddleware so
+// call sites declare routes instead of repeating the middleware chain by
+// hand. It replaces the old pattern of registering a bare prefix like
+// "/npc/" and having the handler itself strings.TrimPrefix/Split the path.
+type Router struct {
+	mux *http.ServeMux
+}
+
+// NewRouter creates an empty Router.
+func NewRouter() *Router {
+	return &Router{mux: http.NewServeMux()}
+}
+
+// Handle registers handler for method at pattern, wrapped in
+// ApplyDefaultMiddleware keyed off pattern for per-route rate limiting.
+// pattern follows http.ServeMux syntax, e.g. "/npc/{id}"; path parameters
+// declared there are read back with PathParam.
+func (rt *Router) Handle(method, pattern string, handler http.HandlerFunc) {
+	rt.mux.Handle(method+" "+pattern, ApplyDefaultMiddleware(handler, pattern))
+}
+
+// HandleJSON is like Handle, additionally validating that the request body
+// is well-formed application/json before handler runs.
+func (rt *Router) HandleJSON(method, pattern string, handler http.HandlerFunc) {
+	rt.mux.Handle(method+" "+pattern, ApplyDefaultMiddleware(WithJSONValidation(handler, method), pattern))
+}
+
+// ServeHTTP implements http.Handler, so a Router can be passed directly to
+// http.Server.Handler or http.Serve.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.mux.ServeHTTP(w, r)
+}
+
+// PathParam returns the value of the named path parameter declared in the
+// pattern a handler was registered under via Router.Handle/HandleJSON, e.g.
+// PathParam(r, "id") for a handler registered at "/npc/{id}". It returns ""
+// if name was not declared in the matched pattern.
+func PathParam(r *http.Request, name string) string {
+	return r.PathValue(name)
+}
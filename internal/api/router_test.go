@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouter_DispatchesByMethodAndPathParam(t *testing.T) {
+	router := NewRouter()
+
+	router.Handle("GET", "/npc/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("get:" + PathParam(r, "id")))
+	})
+	router.Handle("DELETE", "/npc/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("delete:" + PathParam(r, "id")))
+	})
+
+	getRec := httptest.NewRecorder()
+	router.ServeHTTP(getRec, httptest.NewRequest("GET", "/npc/npc-42", nil))
+	if got := getRec.Body.String(); got != "get:npc-42" {
+		t.Errorf("GET body = %q, want %q", got, "get:npc-42")
+	}
+
+	deleteRec := httptest.NewRecorder()
+	router.ServeHTTP(deleteRec, httptest.NewRequest("DELETE", "/npc/npc-42", nil))
+	if got := deleteRec.Body.String(); got != "delete:npc-42" {
+		t.Errorf("DELETE body = %q, want %q", got, "delete:npc-42")
+	}
+}
+
+func TestRouter_MoreSpecificPatternTakesPrecedence(t *testing.T) {
+	router := NewRouter()
+
+	router.Handle("GET", "/npc/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("by-id"))
+	})
+	router.Handle("GET", "/npc/list", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("list"))
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/npc/list", nil))
+	if got := rec.Body.String(); got != "list" {
+		t.Errorf("body = %q, want %q", got, "list")
+	}
+}
+
+func TestRouter_UnmatchedMethodReturns405(t *testing.T) {
+	router := NewRouter()
+	router.Handle("GET", "/npc/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("POST", "/npc/npc-42", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestPathParam_EmptyWhenNotDeclared(t *testing.T) {
+	router := NewRouter()
+	var got string
+	router.Handle("GET", "/health", func(w http.ResponseWriter, r *http.Request) {
+		got = PathParam(r, "id")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health", nil))
+	if got != "" {
+		t.Errorf("PathParam(r, %q) = %q, want empty", "id", got)
+	}
+}
@@ -2,23 +2,60 @@ package cfg
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/piercegov/llm-npc-backend/internal/logging"
 )
 
+// RouteRateLimit overrides the default token-bucket rate limit for a single route.
+type RouteRateLimit struct {
+	RPS   float64
+	Burst int
+}
+
 type Config struct {
-	SocketPath      string
-	HTTPPort        string
-	ApiKey          string
-	BaseUrl         string
-	LogLevel        string
-	OllamaModel     string
-	OllamaBaseURL   string
-	LLMProvider     string
-	LMStudioBaseURL string
-	LMStudioModel   string
-	LMStudioAPIKey  string
+	SocketPath                     string
+	SocketMode                     os.FileMode
+	SocketOwner                    string
+	SocketGroup                    string
+	HTTPPort                       string
+	ApiKey                         string
+	BaseUrl                        string
+	LogLevel                       string
+	OllamaModel                    string
+	OllamaBaseURL                  string
+	OllamaEmbedModel               string
+	OllamaVisionModel              string
+	LLMProvider                    string
+	LMStudioBaseURL                string
+	LMStudioModel                  string
+	LMStudioAPIKey                 string
+	OpenAIBaseURL                  string
+	OpenAIModel                    string
+	OpenAIAPIKey                   string
+	AnthropicBaseURL               string
+	AnthropicModel                 string
+	AnthropicAPIKey                string
+	ExternalProviderAddress        string
+	SessionStoreURL                string
+	NPCStoreURL                    string
+	ScratchpadStoreURL             string
+	MemoryStoreURL                 string
+	RulesFilePath                  string
+	WebhookStorePath               string
+	WebhookWorkers                 int
+	LLMTimeout                     time.Duration
+	LLMRetryMaxAttempts            int
+	LLMRetryBaseDelay              time.Duration
+	LLMRetryMaxDelay               time.Duration
+	CircuitBreakerFailureThreshold int
+	CircuitBreakerCooldown         time.Duration
+	RateLimitRPS                   float64
+	RateLimitBurst                 int
+	RateLimitPerRoute              map[string]RouteRateLimit
 }
 
 func ReadConfig() Config {
@@ -32,6 +69,18 @@ func ReadConfig() Config {
 		socketPath = "/tmp/llm-npc-backend.sock"
 	}
 
+	socketMode := os.FileMode(0)
+	if v := os.Getenv("SOCKET_MODE"); v != "" {
+		if parsed, err := strconv.ParseUint(v, 8, 32); err == nil {
+			socketMode = os.FileMode(parsed)
+		} else {
+			logging.Warn("Invalid SOCKET_MODE, leaving socket permissions at the OS default", "value", v)
+		}
+	}
+
+	socketOwner := os.Getenv("SOCKET_OWNER")
+	socketGroup := os.Getenv("SOCKET_GROUP")
+
 	httpPort := os.Getenv("HTTP_PORT")
 	if httpPort == "" {
 		httpPort = ":8080"
@@ -62,6 +111,18 @@ func ReadConfig() Config {
 		ollamaBaseURL = "http://10.0.0.85:11434"
 	}
 
+	ollamaEmbedModel := os.Getenv("OLLAMA_EMBED_MODEL")
+	if ollamaEmbedModel == "" {
+		ollamaEmbedModel = "nomic-embed-text"
+	}
+
+	// OllamaVisionModel is used instead of OllamaModel whenever a request
+	// carries Images, since chat and vision models are rarely the same.
+	ollamaVisionModel := os.Getenv("OLLAMA_VISION_MODEL")
+	if ollamaVisionModel == "" {
+		ollamaVisionModel = "llava"
+	}
+
 	llmProvider := os.Getenv("LLM_PROVIDER")
 	if llmProvider == "" {
 		llmProvider = "ollama" // Default to Ollama for backward compatibility
@@ -82,33 +143,248 @@ func ReadConfig() Config {
 		lmStudioAPIKey = "lm-studio" // Default API key for LM Studio
 	}
 
+	openAIBaseURL := os.Getenv("OPENAI_BASE_URL")
+	if openAIBaseURL == "" {
+		openAIBaseURL = "https://api.openai.com"
+	}
+
+	openAIModel := os.Getenv("OPENAI_MODEL")
+	if openAIModel == "" {
+		openAIModel = "gpt-4o-mini"
+	}
+
+	openAIAPIKey := os.Getenv("OPENAI_API_KEY")
+
+	anthropicBaseURL := os.Getenv("ANTHROPIC_BASE_URL")
+	if anthropicBaseURL == "" {
+		anthropicBaseURL = "https://api.anthropic.com"
+	}
+
+	anthropicModel := os.Getenv("ANTHROPIC_MODEL")
+	if anthropicModel == "" {
+		anthropicModel = "claude-3-5-haiku-latest"
+	}
+
+	anthropicAPIKey := os.Getenv("ANTHROPIC_API_KEY")
+
+	// ExternalProviderAddress is a host:port for a user-run gRPC model server
+	// registered under the "external"/"grpc" provider name, similar to how
+	// LocalAI fronts arbitrary ggml/falcon backends behind a gRPC LLM service.
+	externalProviderAddress := os.Getenv("EXTERNAL_PROVIDER_ADDRESS")
+
+	sessionStoreURL := os.Getenv("SESSION_STORE_URL")
+	if sessionStoreURL == "" {
+		sessionStoreURL = "memory://"
+	}
+
+	npcStoreURL := os.Getenv("NPC_STORE_URL")
+	if npcStoreURL == "" {
+		npcStoreURL = "memory://"
+	}
+
+	scratchpadStoreURL := os.Getenv("SCRATCHPAD_STORE_URL")
+	if scratchpadStoreURL == "" {
+		scratchpadStoreURL = "memory://"
+	}
+
+	memoryStoreURL := os.Getenv("MEMORY_STORE_URL")
+	if memoryStoreURL == "" {
+		memoryStoreURL = "memory://"
+	}
+
+	// RulesFilePath points at a JSON array of rules.Rule for the reactive rule
+	// engine; left empty, no rules are loaded and only LLM-driven tool calls occur.
+	rulesFilePath := os.Getenv("RULES_FILE_PATH")
+
+	webhookStorePath := os.Getenv("WEBHOOK_STORE_PATH")
+	if webhookStorePath == "" {
+		webhookStorePath = "webhook_deliveries.db"
+	}
+
+	webhookWorkers := 4
+	if v := os.Getenv("WEBHOOK_WORKERS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil {
+			webhookWorkers = parsed
+		} else {
+			logging.Warn("Invalid WEBHOOK_WORKERS, using default", "value", v, "default", webhookWorkers)
+		}
+	}
+
+	llmTimeout := 30 * time.Second
+	if v := os.Getenv("LLM_TIMEOUT"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil {
+			llmTimeout = parsed
+		} else {
+			logging.Warn("Invalid LLM_TIMEOUT, using default", "value", v, "default", llmTimeout)
+		}
+	}
+
+	llmRetryMaxAttempts := 3
+	if v := os.Getenv("LLM_RETRY_MAX_ATTEMPTS"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil {
+			llmRetryMaxAttempts = parsed
+		} else {
+			logging.Warn("Invalid LLM_RETRY_MAX_ATTEMPTS, using default", "value", v, "default", llmRetryMaxAttempts)
+		}
+	}
+
+	llmRetryBaseDelay := 200 * time.Millisecond
+	if v := os.Getenv("LLM_RETRY_BASE_DELAY"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil {
+			llmRetryBaseDelay = parsed
+		} else {
+			logging.Warn("Invalid LLM_RETRY_BASE_DELAY, using default", "value", v, "default", llmRetryBaseDelay)
+		}
+	}
+
+	llmRetryMaxDelay := 10 * time.Second
+	if v := os.Getenv("LLM_RETRY_MAX_DELAY"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil {
+			llmRetryMaxDelay = parsed
+		} else {
+			logging.Warn("Invalid LLM_RETRY_MAX_DELAY, using default", "value", v, "default", llmRetryMaxDelay)
+		}
+	}
+
+	circuitBreakerFailureThreshold := 5
+	if v := os.Getenv("CIRCUIT_BREAKER_FAILURE_THRESHOLD"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil {
+			circuitBreakerFailureThreshold = parsed
+		} else {
+			logging.Warn("Invalid CIRCUIT_BREAKER_FAILURE_THRESHOLD, using default", "value", v, "default", circuitBreakerFailureThreshold)
+		}
+	}
+
+	circuitBreakerCooldown := 30 * time.Second
+	if v := os.Getenv("CIRCUIT_BREAKER_COOLDOWN"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil {
+			circuitBreakerCooldown = parsed
+		} else {
+			logging.Warn("Invalid CIRCUIT_BREAKER_COOLDOWN, using default", "value", v, "default", circuitBreakerCooldown)
+		}
+	}
+
+	rateLimitRPS := 10.0
+	if v := os.Getenv("RATE_LIMIT_RPS"); v != "" {
+		if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+			rateLimitRPS = parsed
+		} else {
+			logging.Warn("Invalid RATE_LIMIT_RPS, using default", "value", v, "default", rateLimitRPS)
+		}
+	}
+
+	rateLimitBurst := 20
+	if v := os.Getenv("RATE_LIMIT_BURST"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil {
+			rateLimitBurst = parsed
+		} else {
+			logging.Warn("Invalid RATE_LIMIT_BURST, using default", "value", v, "default", rateLimitBurst)
+		}
+	}
+
+	rateLimitPerRoute := parseRouteRateLimits(os.Getenv("RATE_LIMIT_PER_ROUTE"))
+
 	return Config{
-		SocketPath:      socketPath,
-		HTTPPort:        httpPort,
-		ApiKey:          apiKey,
-		BaseUrl:         baseURL,
-		LogLevel:        logLevel,
-		OllamaModel:     ollamaModel,
-		OllamaBaseURL:   ollamaBaseURL,
-		LLMProvider:     llmProvider,
-		LMStudioBaseURL: lmStudioBaseURL,
-		LMStudioModel:   lmStudioModel,
-		LMStudioAPIKey:  lmStudioAPIKey,
+		SocketPath:                     socketPath,
+		SocketMode:                     socketMode,
+		SocketOwner:                    socketOwner,
+		SocketGroup:                    socketGroup,
+		HTTPPort:                       httpPort,
+		ApiKey:                         apiKey,
+		BaseUrl:                        baseURL,
+		LogLevel:                       logLevel,
+		OllamaModel:                    ollamaModel,
+		OllamaBaseURL:                  ollamaBaseURL,
+		OllamaEmbedModel:               ollamaEmbedModel,
+		OllamaVisionModel:              ollamaVisionModel,
+		LLMProvider:                    llmProvider,
+		LMStudioBaseURL:                lmStudioBaseURL,
+		LMStudioModel:                  lmStudioModel,
+		LMStudioAPIKey:                 lmStudioAPIKey,
+		OpenAIBaseURL:                  openAIBaseURL,
+		OpenAIModel:                    openAIModel,
+		OpenAIAPIKey:                   openAIAPIKey,
+		AnthropicBaseURL:               anthropicBaseURL,
+		AnthropicModel:                 anthropicModel,
+		AnthropicAPIKey:                anthropicAPIKey,
+		ExternalProviderAddress:        externalProviderAddress,
+		SessionStoreURL:                sessionStoreURL,
+		NPCStoreURL:                    npcStoreURL,
+		ScratchpadStoreURL:             scratchpadStoreURL,
+		MemoryStoreURL:                 memoryStoreURL,
+		RulesFilePath:                  rulesFilePath,
+		WebhookStorePath:               webhookStorePath,
+		WebhookWorkers:                 webhookWorkers,
+		LLMTimeout:                     llmTimeout,
+		LLMRetryMaxAttempts:            llmRetryMaxAttempts,
+		LLMRetryBaseDelay:              llmRetryBaseDelay,
+		LLMRetryMaxDelay:               llmRetryMaxDelay,
+		CircuitBreakerFailureThreshold: circuitBreakerFailureThreshold,
+		RateLimitRPS:                   rateLimitRPS,
+		RateLimitBurst:                 rateLimitBurst,
+		RateLimitPerRoute:              rateLimitPerRoute,
+		CircuitBreakerCooldown:         circuitBreakerCooldown,
 	}
 }
 
 func NewConfig(socketPath, httpPort, apiKey, baseUrl, logLevel, ollamaModel string) Config {
 	return Config{
-		SocketPath:      socketPath,
-		HTTPPort:        httpPort,
-		ApiKey:          apiKey,
-		BaseUrl:         baseUrl,
-		LogLevel:        logLevel,
-		OllamaModel:     ollamaModel,
-		OllamaBaseURL:   "http://10.0.0.85:11434", // Default Ollama base URL
-		LLMProvider:     "ollama", // Default for backward compatibility
-		LMStudioBaseURL: "http://localhost:1234",
-		LMStudioModel:   "model",
-		LMStudioAPIKey:  "lm-studio",
+		SocketPath:                     socketPath,
+		HTTPPort:                       httpPort,
+		ApiKey:                         apiKey,
+		BaseUrl:                        baseUrl,
+		LogLevel:                       logLevel,
+		OllamaModel:                    ollamaModel,
+		OllamaBaseURL:                  "http://10.0.0.85:11434", // Default Ollama base URL
+		LLMProvider:                    "ollama",                 // Default for backward compatibility
+		LMStudioBaseURL:                "http://localhost:1234",
+		LMStudioModel:                  "model",
+		LMStudioAPIKey:                 "lm-studio",
+		SessionStoreURL:                "memory://",
+		NPCStoreURL:                    "memory://",
+		ScratchpadStoreURL:             "memory://",
+		WebhookStorePath:               "webhook_deliveries.db",
+		WebhookWorkers:                 4,
+		LLMTimeout:                     30 * time.Second,
+		LLMRetryMaxAttempts:            3,
+		LLMRetryBaseDelay:              200 * time.Millisecond,
+		LLMRetryMaxDelay:               10 * time.Second,
+		CircuitBreakerFailureThreshold: 5,
+		CircuitBreakerCooldown:         30 * time.Second,
+		RateLimitRPS:                   10,
+		RateLimitBurst:                 20,
+	}
+}
+
+// parseRouteRateLimits parses RATE_LIMIT_PER_ROUTE, a comma-separated list of
+// "route:rps:burst" entries (e.g. "/npc/act:2:5,/tools/register:5:10"),
+// skipping and warning on malformed entries.
+func parseRouteRateLimits(raw string) map[string]RouteRateLimit {
+	if raw == "" {
+		return nil
+	}
+
+	limits := make(map[string]RouteRateLimit)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			logging.Warn("Invalid RATE_LIMIT_PER_ROUTE entry, skipping", "entry", entry)
+			continue
+		}
+
+		rps, rpsErr := strconv.ParseFloat(parts[1], 64)
+		burst, burstErr := strconv.Atoi(parts[2])
+		if rpsErr != nil || burstErr != nil {
+			logging.Warn("Invalid RATE_LIMIT_PER_ROUTE entry, skipping", "entry", entry)
+			continue
+		}
+
+		limits[parts[0]] = RouteRateLimit{RPS: rps, Burst: burst}
 	}
+	return limits
 }
@@ -0,0 +1,66 @@
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// NewSocketClient returns an *http.Client that dials socketPath instead of
+// the usual DNS/TCP resolution, for talking to a backend's Unix-socket HTTP
+// server. The host in request URLs is ignored, so callers use "unix" as a
+// placeholder (e.g. "http://unix/console/_list").
+func NewSocketClient(socketPath string) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Dial: func(network, addr string) (net.Conn, error) {
+				return net.Dial("unix", socketPath)
+			},
+		},
+	}
+}
+
+// Call invokes /console/<name> on a backend reachable through client,
+// passing args as repeated "arg" query parameters, and returns the decoded
+// data on success.
+func Call(client *http.Client, name string, args []string) (any, error) {
+	u := url.URL{Scheme: "http", Host: "unix", Path: "/console/" + name}
+	q := u.Query()
+	for _, arg := range args {
+		q.Add("arg", arg)
+	}
+	u.RawQuery = q.Encode()
+
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach console command %q: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	var result commandResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("command %q failed: %s", name, result.Error)
+	}
+	return result.Data, nil
+}
+
+// List fetches the sorted command names from /console/_list, for
+// autocomplete and a "help" command.
+func List(client *http.Client) ([]string, error) {
+	resp, err := client.Get("http://unix/console/_list")
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach console command list: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result commandResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return result.Commands, nil
+}
@@ -0,0 +1,65 @@
+package console
+
+import (
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestCallAndListOverUnixSocket(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("greet", func(args []string) (any, error) {
+		if len(args) == 0 {
+			return nil, nil
+		}
+		return args[0], nil
+	}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "console.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Failed to listen on unix socket: %v", err)
+	}
+	defer listener.Close()
+
+	go http.Serve(listener, r.Handler())
+
+	client := NewSocketClient(socketPath)
+
+	names, err := List(client)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(names) != 1 || names[0] != "greet" {
+		t.Errorf("List() = %v, want [greet]", names)
+	}
+
+	result, err := Call(client, "greet", []string{"name=guard_01"})
+	if err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if result != "name=guard_01" {
+		t.Errorf("Call() result = %v, want %q", result, "name=guard_01")
+	}
+}
+
+func TestCall_UnknownCommandReturnsError(t *testing.T) {
+	r := NewRegistry()
+
+	socketPath := filepath.Join(t.TempDir(), "console.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Failed to listen on unix socket: %v", err)
+	}
+	defer listener.Close()
+
+	go http.Serve(listener, r.Handler())
+
+	client := NewSocketClient(socketPath)
+	if _, err := Call(client, "nope", nil); err == nil {
+		t.Fatal("Call() error = nil, want an error for an unregistered command")
+	}
+}
@@ -0,0 +1,17 @@
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Pretty renders a console command's decoded result as indented JSON, so
+// viewers can display the output of any command without knowing its shape
+// ahead of time.
+func Pretty(data any) string {
+	b, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", data)
+	}
+	return string(b)
+}
@@ -0,0 +1,18 @@
+package console
+
+import "testing"
+
+func TestPretty(t *testing.T) {
+	got := Pretty(map[string]any{"count": 2})
+	want := "{\n  \"count\": 2\n}"
+	if got != want {
+		t.Errorf("Pretty() = %q, want %q", got, want)
+	}
+}
+
+func TestPretty_UnmarshalableFallsBackToFmt(t *testing.T) {
+	got := Pretty(make(chan int))
+	if got == "" {
+		t.Error("Pretty() returned an empty string for an unmarshalable value")
+	}
+}
@@ -0,0 +1,119 @@
+// Package console lets any part of the backend register an introspection
+// command (e.g. dump memory, force a tool call, replay the last prompt, hot-
+// reload the system prompt) that is automatically exposed over the Unix
+// socket as /console/<name> and discoverable via /console/_list. Viewers talk
+// to a Registry only through this generic HTTP surface, so adding a new
+// command is a one-file drop-in rather than an edit to every viewer.
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Handler is a console command's implementation. args are the raw
+// "key=value" tokens following the command name, e.g. ["npc_id=guard_01"]
+// for the input "inspect_npc npc_id=guard_01".
+type Handler func(args []string) (any, error)
+
+// Registry holds named console commands and serves them over HTTP.
+type Registry struct {
+	mu       sync.RWMutex
+	handlers map[string]Handler
+}
+
+// NewRegistry creates an empty command registry.
+func NewRegistry() *Registry {
+	return &Registry{handlers: make(map[string]Handler)}
+}
+
+// Register adds a named command. It returns an error if name is already
+// registered, or is "_list" (reserved for the discovery endpoint).
+func (r *Registry) Register(name string, handler Handler) error {
+	if name == "_list" {
+		return fmt.Errorf("console: %q is a reserved command name", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.handlers[name]; exists {
+		return fmt.Errorf("console: command %q already registered", name)
+	}
+	r.handlers[name] = handler
+	return nil
+}
+
+// Names returns every registered command name, sorted, for autocomplete and
+// a "help" command.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.handlers))
+	for name := range r.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Execute runs the named command with the given "key=value" args.
+func (r *Registry) Execute(name string, args []string) (any, error) {
+	r.mu.RLock()
+	handler, ok := r.handlers[name]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown command: %s", name)
+	}
+	return handler(args)
+}
+
+// ParseCommand splits a console input line (e.g. "inspect_npc
+// npc_id=guard_01") into its command name and "key=value" argument tokens.
+func ParseCommand(line string) (name string, args []string) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	return fields[0], fields[1:]
+}
+
+// commandResponse is the JSON shape returned by both /console/<name> and
+// /console/_list.
+type commandResponse struct {
+	Command  string   `json:"command,omitempty"`
+	Success  bool     `json:"success"`
+	Data     any      `json:"data,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	Commands []string `json:"commands,omitempty"`
+}
+
+// Handler returns an http.HandlerFunc that serves every registered command
+// at /console/<name>, plus /console/_list returning the sorted command names,
+// so viewers can discover what's available without hardcoding a list.
+// Arguments are passed as repeated "arg" query parameters.
+func (r *Registry) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := strings.TrimPrefix(req.URL.Path, "/console/")
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if name == "_list" {
+			json.NewEncoder(w).Encode(commandResponse{Success: true, Commands: r.Names()})
+			return
+		}
+
+		result, err := r.Execute(name, req.URL.Query()["arg"])
+		if err != nil {
+			json.NewEncoder(w).Encode(commandResponse{Command: name, Success: false, Error: err.Error()})
+			return
+		}
+		json.NewEncoder(w).Encode(commandResponse{Command: name, Success: true, Data: result})
+	}
+}
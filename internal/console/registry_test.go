@@ -0,0 +1,142 @@
+package console
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRegistry_RegisterAndExecute(t *testing.T) {
+	r := NewRegistry()
+
+	if err := r.Register("echo", func(args []string) (any, error) {
+		return args, nil
+	}); err != nil {
+		t.Fatalf("Register() error = %v, want nil", err)
+	}
+
+	result, err := r.Execute("echo", []string{"a=1"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v, want nil", err)
+	}
+	if !reflect.DeepEqual(result, []string{"a=1"}) {
+		t.Errorf("Execute() result = %v, want [a=1]", result)
+	}
+}
+
+func TestRegistry_RegisterDuplicateNameErrors(t *testing.T) {
+	r := NewRegistry()
+	handler := func(args []string) (any, error) { return nil, nil }
+
+	if err := r.Register("dump", handler); err != nil {
+		t.Fatalf("first Register() error = %v, want nil", err)
+	}
+	if err := r.Register("dump", handler); err == nil {
+		t.Fatal("second Register() error = nil, want an error for a duplicate name")
+	}
+}
+
+func TestRegistry_RegisterReservedNameErrors(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("_list", func(args []string) (any, error) { return nil, nil }); err == nil {
+		t.Fatal("Register(\"_list\") error = nil, want an error since the name is reserved")
+	}
+}
+
+func TestRegistry_ExecuteUnknownCommandErrors(t *testing.T) {
+	r := NewRegistry()
+	if _, err := r.Execute("nope", nil); err == nil {
+		t.Fatal("Execute() error = nil, want an error for an unregistered command")
+	}
+}
+
+func TestRegistry_NamesIsSorted(t *testing.T) {
+	r := NewRegistry()
+	handler := func(args []string) (any, error) { return nil, nil }
+	for _, name := range []string{"zebra", "alpha", "mango"} {
+		if err := r.Register(name, handler); err != nil {
+			t.Fatalf("Register(%q) error = %v", name, err)
+		}
+	}
+
+	want := []string{"alpha", "mango", "zebra"}
+	if got := r.Names(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		line     string
+		wantName string
+		wantArgs []string
+	}{
+		{"inspect_npc npc_id=guard_01", "inspect_npc", []string{"npc_id=guard_01"}},
+		{"help", "help", []string{}},
+		{"", "", nil},
+		{"  ", "", nil},
+	}
+
+	for _, tt := range tests {
+		name, args := ParseCommand(tt.line)
+		if name != tt.wantName || !reflect.DeepEqual(args, tt.wantArgs) {
+			t.Errorf("ParseCommand(%q) = (%q, %v), want (%q, %v)", tt.line, name, args, tt.wantName, tt.wantArgs)
+		}
+	}
+}
+
+func TestRegistry_HandlerServesListAndCommands(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register("ping", func(args []string) (any, error) {
+		return map[string]string{"args": args[0]}, nil
+	}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	server := httptest.NewServer(r.Handler())
+	defer server.Close()
+
+	listResp, err := http.Get(server.URL + "/console/_list")
+	if err != nil {
+		t.Fatalf("GET /console/_list error = %v", err)
+	}
+	defer listResp.Body.Close()
+
+	var listResult commandResponse
+	if err := json.NewDecoder(listResp.Body).Decode(&listResult); err != nil {
+		t.Fatalf("Failed to decode /console/_list response: %v", err)
+	}
+	if !listResult.Success || !reflect.DeepEqual(listResult.Commands, []string{"ping"}) {
+		t.Errorf("/console/_list = %+v, want success with commands=[ping]", listResult)
+	}
+
+	cmdResp, err := http.Get(server.URL + "/console/ping?arg=hello")
+	if err != nil {
+		t.Fatalf("GET /console/ping error = %v", err)
+	}
+	defer cmdResp.Body.Close()
+
+	var cmdResult commandResponse
+	if err := json.NewDecoder(cmdResp.Body).Decode(&cmdResult); err != nil {
+		t.Fatalf("Failed to decode /console/ping response: %v", err)
+	}
+	if !cmdResult.Success || cmdResult.Command != "ping" {
+		t.Errorf("/console/ping = %+v, want a successful ping response", cmdResult)
+	}
+
+	errResp, err := http.Get(server.URL + "/console/missing")
+	if err != nil {
+		t.Fatalf("GET /console/missing error = %v", err)
+	}
+	defer errResp.Body.Close()
+
+	var errResult commandResponse
+	if err := json.NewDecoder(errResp.Body).Decode(&errResult); err != nil {
+		t.Fatalf("Failed to decode /console/missing response: %v", err)
+	}
+	if errResult.Success || errResult.Error == "" {
+		t.Errorf("/console/missing = %+v, want a failure with a non-empty error", errResult)
+	}
+}
@@ -0,0 +1,184 @@
+package kg
+
+import "sync"
+
+// Graph is an indexed, mutable view over a KnowledgeGraph. Nodes/Edges
+// (KnowledgeGraph's own fields) are plain slices so the struct stays easy to
+// marshal and persist; Graph adds the by-ID, by-NPC, and by-entity indexes
+// callers like tools.ToolRegistry and tools.ScratchpadStorage need so a
+// lookup during a tick doesn't have to scan every node or edge.
+//
+// Nodes opt into the NPC/entity indexes by setting a "npc_id" or
+// "entity_id" string in their Data, the same convention the tools package
+// uses when it projects scratchpad writes and tool invocations into the graph.
+type Graph struct {
+	mu        sync.RWMutex
+	nodes     map[string]Node
+	edgesFrom map[string][]Edge
+	edgesTo   map[string][]Edge
+	byNPC     map[string]map[string]struct{} // npc_id -> node IDs
+	byEntity  map[string]map[string]struct{} // entity_id -> node IDs
+}
+
+// NewGraph builds an indexed Graph from seed, the KnowledgeGraph already
+// persisted alongside an NPC (or a zero value for a fresh one).
+func NewGraph(seed KnowledgeGraph) *Graph {
+	g := &Graph{
+		nodes:     make(map[string]Node),
+		edgesFrom: make(map[string][]Edge),
+		edgesTo:   make(map[string][]Edge),
+		byNPC:     make(map[string]map[string]struct{}),
+		byEntity:  make(map[string]map[string]struct{}),
+	}
+	for _, node := range seed.Nodes {
+		g.upsertNodeLocked(node)
+	}
+	for _, edge := range seed.Edges {
+		g.addEdgeLocked(edge)
+	}
+	return g
+}
+
+// Snapshot flattens the graph back into a plain KnowledgeGraph, the shape
+// NPCStorage.UpdateKnowledgeGraph persists.
+func (g *Graph) Snapshot() KnowledgeGraph {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	snapshot := KnowledgeGraph{
+		Nodes: make([]Node, 0, len(g.nodes)),
+	}
+	for _, node := range g.nodes {
+		snapshot.Nodes = append(snapshot.Nodes, node)
+	}
+	for _, edges := range g.edgesFrom {
+		snapshot.Edges = append(snapshot.Edges, edges...)
+	}
+	return snapshot
+}
+
+// UpsertNode inserts node or replaces the existing node with the same ID.
+func (g *Graph) UpsertNode(node Node) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.upsertNodeLocked(node)
+}
+
+func (g *Graph) upsertNodeLocked(node Node) {
+	g.nodes[node.ID] = node
+
+	if npcID, ok := node.Data["npc_id"].(string); ok && npcID != "" {
+		if g.byNPC[npcID] == nil {
+			g.byNPC[npcID] = make(map[string]struct{})
+		}
+		g.byNPC[npcID][node.ID] = struct{}{}
+	}
+	if entityID, ok := node.Data["entity_id"].(string); ok && entityID != "" {
+		if g.byEntity[entityID] == nil {
+			g.byEntity[entityID] = make(map[string]struct{})
+		}
+		g.byEntity[entityID][node.ID] = struct{}{}
+	}
+}
+
+// AddEdge appends edge to the graph, indexing it by both endpoints.
+func (g *Graph) AddEdge(edge Edge) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.addEdgeLocked(edge)
+}
+
+func (g *Graph) addEdgeLocked(edge Edge) {
+	g.edgesFrom[edge.Source] = append(g.edgesFrom[edge.Source], edge)
+	g.edgesTo[edge.Target] = append(g.edgesTo[edge.Target], edge)
+}
+
+// Node returns the node with id, if any.
+func (g *Graph) Node(id string) (Node, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	node, ok := g.nodes[id]
+	return node, ok
+}
+
+// NodesForNPC returns every node tagged with Data["npc_id"] == npcID, an
+// O(1) index lookup rather than a scan over every node.
+func (g *Graph) NodesForNPC(npcID string) []Node {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.nodesFromIndexLocked(g.byNPC[npcID])
+}
+
+// NodesForEntity returns every node tagged with Data["entity_id"] == entityID.
+func (g *Graph) NodesForEntity(entityID string) []Node {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.nodesFromIndexLocked(g.byEntity[entityID])
+}
+
+func (g *Graph) nodesFromIndexLocked(ids map[string]struct{}) []Node {
+	nodes := make([]Node, 0, len(ids))
+	for id := range ids {
+		nodes = append(nodes, g.nodes[id])
+	}
+	return nodes
+}
+
+// Neighbors returns every node reachable from nodeID by a single outgoing edge.
+func (g *Graph) Neighbors(nodeID string) []Node {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var neighbors []Node
+	for _, edge := range g.edgesFrom[nodeID] {
+		if node, ok := g.nodes[edge.Target]; ok {
+			neighbors = append(neighbors, node)
+		}
+	}
+	return neighbors
+}
+
+// KHop returns every node reachable from nodeID by at most k outgoing-edge
+// hops, excluding nodeID itself. A k of 0 returns no nodes.
+func (g *Graph) KHop(nodeID string, k int) []Node {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	visited := map[string]bool{nodeID: true}
+	frontier := []string{nodeID}
+	var result []Node
+
+	for hop := 0; hop < k && len(frontier) > 0; hop++ {
+		var next []string
+		for _, id := range frontier {
+			for _, edge := range g.edgesFrom[id] {
+				if visited[edge.Target] {
+					continue
+				}
+				visited[edge.Target] = true
+				if node, ok := g.nodes[edge.Target]; ok {
+					result = append(result, node)
+				}
+				next = append(next, edge.Target)
+			}
+		}
+		frontier = next
+	}
+	return result
+}
+
+// Query returns every node for which predicate returns true. Unlike
+// NodesForNPC/NodesForEntity, this is a full scan: it's the escape hatch for
+// ad-hoc pattern matching over Data that isn't covered by an index.
+func (g *Graph) Query(predicate func(Node) bool) []Node {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var matches []Node
+	for _, node := range g.nodes {
+		if predicate(node) {
+			matches = append(matches, node)
+		}
+	}
+	return matches
+}
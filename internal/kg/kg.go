@@ -1,6 +1,8 @@
 package kg
 
-// TODO: KnowledgeGraph is currently just in memory, but will be persisted in SpacetimeDB in the future.
+// KnowledgeGraph is persisted alongside its owning NPC via npc.Store
+// (NPCStorage.UpdateKnowledgeGraph); SpacetimeDB remains a possible future
+// backend for it, but isn't implemented yet.
 type KnowledgeGraph struct {
 	Nodes []Node `json:"nodes"`
 	Edges []Edge `json:"edges"`
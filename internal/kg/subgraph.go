@@ -0,0 +1,125 @@
+package kg
+
+// AnchorResolver resolves the node IDs a bounded walk should start from.
+// Callers compose one from ByID/ByName, or supply their own to key off
+// something Data-specific (e.g. a "npc_id"/"entity_id" match, like the
+// byNPC/byEntity indexes use).
+type AnchorResolver func(g *Graph) []string
+
+// ByID resolves anchors that are already node IDs, passing through only the
+// ones present in the graph.
+func ByID(ids ...string) AnchorResolver {
+	return func(g *Graph) []string {
+		var resolved []string
+		for _, id := range ids {
+			if _, ok := g.Node(id); ok {
+				resolved = append(resolved, id)
+			}
+		}
+		return resolved
+	}
+}
+
+// ByName resolves anchors by matching Data["name"] against names, for
+// graphs where nodes carry a human-readable name distinct from their ID.
+func ByName(names ...string) AnchorResolver {
+	wanted := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		wanted[name] = struct{}{}
+	}
+	return func(g *Graph) []string {
+		var resolved []string
+		for _, node := range g.Query(func(n Node) bool {
+			name, _ := n.Data["name"].(string)
+			_, ok := wanted[name]
+			return ok
+		}) {
+			resolved = append(resolved, node.ID)
+		}
+		return resolved
+	}
+}
+
+// CombineAnchors merges the anchors returned by every resolver, deduplicated.
+func CombineAnchors(resolvers ...AnchorResolver) AnchorResolver {
+	return func(g *Graph) []string {
+		seen := make(map[string]struct{})
+		var combined []string
+		for _, resolve := range resolvers {
+			for _, id := range resolve(g) {
+				if _, ok := seen[id]; ok {
+					continue
+				}
+				seen[id] = struct{}{}
+				combined = append(combined, id)
+			}
+		}
+		return combined
+	}
+}
+
+// Subgraph is the result of a BoundedSubgraph walk: the pruned graph, plus
+// the order nodes were first visited in, so callers can debug why a node
+// did or didn't make it into the result (e.g. pruned by depth, or skipped
+// because its source hub was already over perNodeBudget).
+type Subgraph struct {
+	Graph          KnowledgeGraph
+	TraversalOrder []string
+}
+
+// BoundedSubgraph performs a depth-limited BFS from every node resolve
+// returns, up to depth outgoing-edge hops, tracking a visited set so cycles
+// don't revisit a node. perNodeBudget caps how many outgoing edges a single
+// node contributes to the frontier on a given hop (0 means unbounded), so
+// one high-degree hub can't blow the whole walk's size on its own. Anchors
+// themselves are always included regardless of depth; a depth of 0 returns
+// just the resolved anchors with no edges.
+func (g *Graph) BoundedSubgraph(resolve AnchorResolver, depth int, perNodeBudget int) Subgraph {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	visited := make(map[string]bool)
+	var order []string
+	frontier := make([]string, 0)
+	for _, id := range resolve(g) {
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		order = append(order, id)
+		frontier = append(frontier, id)
+	}
+
+	var edges []Edge
+	for hop := 0; hop < depth && len(frontier) > 0; hop++ {
+		var next []string
+		for _, id := range frontier {
+			outgoing := g.edgesFrom[id]
+			if perNodeBudget > 0 && len(outgoing) > perNodeBudget {
+				outgoing = outgoing[:perNodeBudget]
+			}
+			for _, edge := range outgoing {
+				edges = append(edges, edge)
+				if visited[edge.Target] {
+					continue
+				}
+				visited[edge.Target] = true
+				order = append(order, edge.Target)
+				next = append(next, edge.Target)
+			}
+		}
+		frontier = next
+	}
+
+	nodes := make([]Node, 0, len(order))
+	for _, id := range order {
+		if node, ok := g.nodes[id]; ok {
+			nodes = append(nodes, node)
+		}
+	}
+
+	return Subgraph{
+		Graph:          KnowledgeGraph{Nodes: nodes, Edges: edges},
+		TraversalOrder: order,
+	}
+}
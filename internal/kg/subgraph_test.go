@@ -0,0 +1,76 @@
+package kg
+
+import "testing"
+
+func TestBoundedSubgraph_RespectsDepthAndTracksCycles(t *testing.T) {
+	g := NewGraph(KnowledgeGraph{
+		Nodes: []Node{
+			{ID: "a", Data: map[string]interface{}{"name": "A"}},
+			{ID: "b", Data: map[string]interface{}{"name": "B"}},
+			{ID: "c", Data: map[string]interface{}{"name": "C"}},
+		},
+		Edges: []Edge{
+			{Source: "a", Target: "b"},
+			{Source: "b", Target: "c"},
+			{Source: "c", Target: "a"}, // cycle back to the anchor
+		},
+	})
+
+	result := g.BoundedSubgraph(ByID("a"), 1, 0)
+	if len(result.Graph.Nodes) != 2 {
+		t.Fatalf("expected 2 nodes within 1 hop of a, got %d: %v", len(result.Graph.Nodes), result.Graph.Nodes)
+	}
+
+	deep := g.BoundedSubgraph(ByID("a"), 5, 0)
+	if len(deep.Graph.Nodes) != 3 {
+		t.Fatalf("expected the cycle not to revisit nodes, got %d nodes: %v", len(deep.Graph.Nodes), deep.Graph.Nodes)
+	}
+}
+
+func TestBoundedSubgraph_PerNodeBudgetCapsHubFanout(t *testing.T) {
+	g := NewGraph(KnowledgeGraph{
+		Nodes: []Node{
+			{ID: "hub"},
+			{ID: "leaf-1"},
+			{ID: "leaf-2"},
+			{ID: "leaf-3"},
+		},
+		Edges: []Edge{
+			{Source: "hub", Target: "leaf-1"},
+			{Source: "hub", Target: "leaf-2"},
+			{Source: "hub", Target: "leaf-3"},
+		},
+	})
+
+	result := g.BoundedSubgraph(ByID("hub"), 1, 2)
+	if len(result.Graph.Edges) != 2 {
+		t.Fatalf("expected perNodeBudget to cap hub's fanout at 2 edges, got %d", len(result.Graph.Edges))
+	}
+}
+
+func TestByName_MatchesOnDataName(t *testing.T) {
+	g := NewGraph(KnowledgeGraph{
+		Nodes: []Node{
+			{ID: "npc-1", Data: map[string]interface{}{"name": "Elara"}},
+			{ID: "npc-2", Data: map[string]interface{}{"name": "Gaius"}},
+		},
+	})
+
+	resolved := ByName("Elara")(g)
+	if len(resolved) != 1 || resolved[0] != "npc-1" {
+		t.Fatalf("expected ByName to resolve only npc-1, got %v", resolved)
+	}
+}
+
+func TestCombineAnchors_Deduplicates(t *testing.T) {
+	g := NewGraph(KnowledgeGraph{
+		Nodes: []Node{
+			{ID: "a", Data: map[string]interface{}{"name": "A"}},
+		},
+	})
+
+	resolved := CombineAnchors(ByID("a"), ByName("A"))(g)
+	if len(resolved) != 1 {
+		t.Fatalf("expected duplicate anchors to collapse to 1, got %v", resolved)
+	}
+}
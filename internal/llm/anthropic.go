@@ -0,0 +1,261 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/piercegov/llm-npc-backend/internal/logging"
+)
+
+// defaultAnthropicTimeout is used when Anthropic.Timeout is left zero,
+// matching defaultLMStudioTimeout's rationale.
+const defaultAnthropicTimeout = 30 * time.Second
+
+// defaultAnthropicMaxTokens is used when Anthropic.MaxTokens is left zero.
+// Anthropic's Messages API requires max_tokens on every request, unlike
+// Ollama/OpenAI where it's optional.
+const defaultAnthropicMaxTokens = 1024
+
+// anthropicAPIVersion is the anthropic-version header value this client
+// speaks; bump it alongside any request/response shape changes below.
+const anthropicAPIVersion = "2023-06-01"
+
+// anthropicMessage is one entry in the Messages API's "messages" array.
+// Unlike Ollama/OpenAI, the system prompt is a top-level request field, not
+// a message with role "system".
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// anthropicTool describes a tool in Anthropic's shape: a flat input_schema
+// rather than the nested {"type":"function","function":{...}} OpenAI/Ollama
+// use.
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+	Tools     []anthropicTool    `json:"tools,omitempty"`
+	MaxTokens int                `json:"max_tokens"`
+}
+
+// anthropicContentBlock is one entry in a response's "content" array: either
+// a "text" block or a "tool_use" block, distinguished by Type.
+type anthropicContentBlock struct {
+	Type  string                 `json:"type"`
+	Text  string                 `json:"text,omitempty"`
+	ID    string                 `json:"id,omitempty"`
+	Name  string                 `json:"name,omitempty"`
+	Input map[string]interface{} `json:"input,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content    []anthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+type anthropicErrorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Anthropic implements LLMProvider against Anthropic's Messages API.
+// GenerateStream falls back to a single final chunk via
+// generateStreamFallback rather than consuming Anthropic's
+// server-sent-events stream, matching the repo's general posture of adding
+// real streaming only to providers that need it for current NPC traffic.
+type Anthropic struct {
+	BaseURL   string
+	Model     string
+	APIKey    string
+	MaxTokens int
+	Timeout   time.Duration
+}
+
+// NewAnthropic creates a new Anthropic provider instance.
+func NewAnthropic(baseURL, model, apiKey string, timeout time.Duration) *Anthropic {
+	if timeout <= 0 {
+		timeout = defaultAnthropicTimeout
+	}
+	return &Anthropic{
+		BaseURL:   baseURL,
+		Model:     model,
+		APIKey:    apiKey,
+		MaxTokens: defaultAnthropicMaxTokens,
+		Timeout:   timeout,
+	}
+}
+
+// buildAnthropicRequest translates an LLMRequest into Anthropic's Messages
+// API payload.
+func (a *Anthropic) buildAnthropicRequest(request LLMRequest) anthropicRequest {
+	var tools []anthropicTool
+	if len(request.Tools) > 0 {
+		tools = make([]anthropicTool, len(request.Tools))
+		for i, tool := range request.Tools {
+			properties := make(map[string]interface{}, len(tool.Parameters))
+			var required []string
+			for name, param := range tool.Parameters {
+				properties[name] = map[string]interface{}{
+					"type":        string(param.Type),
+					"description": param.Description,
+				}
+				if param.Required {
+					required = append(required, name)
+				}
+			}
+			tools[i] = anthropicTool{
+				Name:        tool.Name,
+				Description: tool.Description,
+				InputSchema: map[string]interface{}{
+					"type":       "object",
+					"properties": properties,
+					"required":   required,
+				},
+			}
+		}
+	}
+
+	maxTokens := a.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultAnthropicMaxTokens
+	}
+
+	return anthropicRequest{
+		Model:  a.Model,
+		System: request.SystemPrompt,
+		Messages: []anthropicMessage{
+			{Role: "user", Content: request.Prompt},
+		},
+		Tools:     tools,
+		MaxTokens: maxTokens,
+	}
+}
+
+// Generate implements LLMProvider.
+func (a *Anthropic) Generate(ctx context.Context, request LLMRequest) (LLMResponse, error) {
+	anthropicReq := a.buildAnthropicRequest(request)
+
+	jsonBody, err := json.Marshal(anthropicReq)
+	if err != nil {
+		logging.Error("Failed to marshal Anthropic request body", "error", err)
+		return LLMResponse{}, err
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, a.BaseURL+"/v1/messages", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		logging.Error("Error creating Anthropic request", "error", err)
+		return LLMResponse{}, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set("x-api-key", a.APIKey)
+	httpRequest.Header.Set("anthropic-version", anthropicAPIVersion)
+	if requestID := logging.RequestIDFromContext(ctx); requestID != "" {
+		httpRequest.Header.Set("X-Request-ID", requestID)
+	}
+
+	client := &http.Client{Timeout: a.Timeout}
+	response, err := client.Do(httpRequest)
+	if err != nil {
+		logging.Error("Failed to send request to Anthropic", "error", err)
+		return LLMResponse{}, classifyTransportError(ctx, "anthropic", a.Model, err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		logging.Error("Failed to read Anthropic response body", "error", err)
+		return LLMResponse{}, NewProviderError("anthropic", a.Model, err, "failed to read response")
+	}
+
+	if response.StatusCode != http.StatusOK {
+		logging.Error("Anthropic returned non-200 status",
+			"status_code", response.StatusCode,
+			"body", string(body),
+		)
+		return LLMResponse{}, classifyAnthropicStatus(a.Model, response.StatusCode, body)
+	}
+
+	var parsed anthropicResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		logging.Error("Failed to unmarshal Anthropic response", "error", err, "body", string(body))
+		return LLMResponse{}, NewProviderError("anthropic", a.Model, err, "invalid response format")
+	}
+
+	var textContent string
+	var toolUses []ToolUse
+	for _, block := range parsed.Content {
+		switch block.Type {
+		case "text":
+			textContent += block.Text
+		case "tool_use":
+			toolUses = append(toolUses, ToolUse{ToolName: block.Name, ToolArgs: block.Input})
+		}
+	}
+
+	return LLMResponse{
+		StatusCode: response.StatusCode,
+		Response:   textContent,
+		ToolUses:   toolUses,
+		Provider:   "anthropic",
+		Usage: &LLMUsage{
+			PromptTokens:     parsed.Usage.InputTokens,
+			CompletionTokens: parsed.Usage.OutputTokens,
+			TotalTokens:      parsed.Usage.InputTokens + parsed.Usage.OutputTokens,
+		},
+	}, nil
+}
+
+// GenerateStream implements LLMProvider by running Generate to completion
+// and emitting its result as a single final chunk.
+func (a *Anthropic) GenerateStream(ctx context.Context, request LLMRequest) (<-chan LLMChunk, error) {
+	return generateStreamFallback(ctx, a.Generate, request)
+}
+
+// classifyAnthropicStatus maps an Anthropic HTTP status/body pair onto this
+// package's sentinel errors, mirroring classifyOllamaStatus.
+func classifyAnthropicStatus(model string, statusCode int, body []byte) error {
+	var parsed anthropicErrorResponse
+	message := string(body)
+	if err := json.Unmarshal(body, &parsed); err == nil && parsed.Error.Message != "" {
+		message = parsed.Error.Message
+	}
+
+	var baseErr error
+	switch statusCode {
+	case http.StatusBadRequest:
+		baseErr = ErrBadRequest
+	case http.StatusUnauthorized, http.StatusForbidden:
+		baseErr = ErrUnauthorized
+	case http.StatusNotFound:
+		baseErr = ErrModelNotFound
+		message = fmt.Sprintf("model '%s' not found: %s", model, message)
+	case http.StatusTooManyRequests:
+		baseErr = ErrRateLimited
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
+		baseErr = ErrProviderUnavailable
+	case http.StatusGatewayTimeout:
+		baseErr = ErrTimeout
+	default:
+		baseErr = fmt.Errorf("unexpected status code: %d", statusCode)
+	}
+
+	return NewProviderError("anthropic", model, baseErr, message)
+}
@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"io"
+)
+
+// Embedder is implemented by providers that can turn text into vector
+// embeddings, e.g. for semantic recall over a tools.ScratchpadStorage.
+type Embedder interface {
+	Embed(ctx context.Context, request EmbedRequest) (EmbedResponse, error)
+}
+
+// Transcriber is implemented by providers that can turn spoken audio (e.g. a
+// player's voice input) into text.
+type Transcriber interface {
+	Transcribe(ctx context.Context, audio io.Reader, opts TranscribeOptions) (TranscribeResponse, error)
+}
+
+// Synthesizer is implemented by providers that can turn text into spoken
+// audio, e.g. so an NPC can speak its response back to the player.
+type Synthesizer interface {
+	Synthesize(ctx context.Context, request TTSRequest) (io.ReadCloser, error)
+}
+
+// EmbedRequest is a batch of text to embed in one call.
+type EmbedRequest struct {
+	Input []string
+}
+
+// EmbedResponse holds one embedding vector per entry in EmbedRequest.Input,
+// in the same order.
+type EmbedResponse struct {
+	Embeddings [][]float64
+	Usage      *LLMUsage
+}
+
+// TranscribeOptions configures a Transcriber.Transcribe call.
+type TranscribeOptions struct {
+	// Filename is sent alongside the audio bytes so the provider can infer
+	// the format (e.g. "input.wav"); required by LM Studio's multipart API.
+	Filename string
+	// Language is an optional ISO-639-1 hint (e.g. "en"); empty lets the
+	// provider auto-detect.
+	Language string
+}
+
+// TranscribeResponse is the text recognized from a Transcriber.Transcribe call.
+type TranscribeResponse struct {
+	Text string
+}
+
+// TTSRequest configures a Synthesizer.Synthesize call.
+type TTSRequest struct {
+	Input string
+	// Voice selects the provider's voice/speaker, if it supports more than one.
+	Voice string
+	// ResponseFormat is the desired audio encoding (e.g. "mp3", "wav"); empty
+	// uses the provider's default.
+	ResponseFormat string
+}
@@ -0,0 +1,237 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// CircuitState is one of the three circuit breaker states.
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitStatus is a point-in-time snapshot of a CircuitBreaker, as reported
+// by GET /health/providers.
+type CircuitStatus struct {
+	Provider         string    `json:"provider"`
+	State            string    `json:"state"`
+	ConsecutiveFails int       `json:"consecutive_failures"`
+	LastErrorAt      time.Time `json:"last_error_at,omitempty"`
+	LastError        string    `json:"last_error,omitempty"`
+}
+
+// CircuitBreaker wraps an LLMProvider with a closed -> open -> half-open
+// state machine. It trips to open after FailureThreshold consecutive
+// failures caused by ErrProviderUnavailable or ErrTimeout; while open, calls
+// are short-circuited with a synthetic *ProviderError instead of reaching
+// the wrapped provider. After Cooldown elapses it allows a single half-open
+// probe through, whose result decides whether to close or re-open.
+type CircuitBreaker struct {
+	name             string
+	provider         LLMProvider
+	FailureThreshold int
+	Cooldown         time.Duration
+
+	mu                    sync.Mutex
+	state                 CircuitState
+	consecutiveFails      int
+	lastError             error
+	lastErrorAt           time.Time
+	openedAt              time.Time
+	halfOpenProbeInFlight bool
+}
+
+// NewCircuitBreaker creates a closed CircuitBreaker named name (used in
+// status reporting and synthetic errors) wrapping provider.
+func NewCircuitBreaker(name string, provider LLMProvider, failureThreshold int, cooldown time.Duration) *CircuitBreaker {
+	cb := &CircuitBreaker{
+		name:             name,
+		provider:         provider,
+		FailureThreshold: failureThreshold,
+		Cooldown:         cooldown,
+		state:            CircuitClosed,
+	}
+	registerCircuitBreaker(cb)
+	return cb
+}
+
+func (c *CircuitBreaker) Generate(ctx context.Context, request LLMRequest) (LLMResponse, error) {
+	if !c.allowRequest() {
+		return LLMResponse{}, NewProviderErrorCtx(ctx, c.name, "", ErrProviderUnavailable, "circuit open")
+	}
+
+	response, err := c.provider.Generate(ctx, request)
+	c.recordResult(err)
+	return response, err
+}
+
+// GenerateStream implements the LLMProvider interface, applying the same
+// open-circuit short-circuiting as Generate, then forwarding the wrapped
+// provider's own stream rather than buffering it through Generate: once the
+// stream has started, a mid-stream failure closes it with an error chunk
+// instead of being retried, since chunks may already be in the caller's
+// hands.
+func (c *CircuitBreaker) GenerateStream(ctx context.Context, request LLMRequest) (<-chan LLMChunk, error) {
+	if !c.allowRequest() {
+		return nil, NewProviderErrorCtx(ctx, c.name, "", ErrProviderUnavailable, "circuit open")
+	}
+
+	upstream, err := c.provider.GenerateStream(ctx, request)
+	if err != nil {
+		c.recordResult(err)
+		return nil, err
+	}
+
+	out := make(chan LLMChunk)
+	go func() {
+		defer close(out)
+		var streamErr error
+		for chunk := range upstream {
+			out <- chunk
+			if chunk.Err != nil {
+				streamErr = chunk.Err
+			}
+		}
+		c.recordResult(streamErr)
+	}()
+	return out, nil
+}
+
+// allowRequest reports whether a call should reach the wrapped provider,
+// transitioning open -> half-open once Cooldown has elapsed.
+func (c *CircuitBreaker) allowRequest() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case CircuitClosed:
+		return true
+	case CircuitOpen:
+		if time.Since(c.openedAt) < c.Cooldown {
+			return false
+		}
+		c.state = CircuitHalfOpen
+		c.halfOpenProbeInFlight = true
+		return true
+	case CircuitHalfOpen:
+		if c.halfOpenProbeInFlight {
+			return false
+		}
+		c.halfOpenProbeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// recordResult updates circuit state based on the outcome of a call that allowRequest let through.
+func (c *CircuitBreaker) recordResult(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state == CircuitHalfOpen {
+		c.halfOpenProbeInFlight = false
+		if err == nil {
+			c.state = CircuitClosed
+			c.consecutiveFails = 0
+			return
+		}
+		c.state = CircuitOpen
+		c.openedAt = time.Now()
+		c.lastError = err
+		c.lastErrorAt = time.Now()
+		return
+	}
+
+	if err == nil {
+		c.consecutiveFails = 0
+		return
+	}
+
+	if !isCircuitTrippingError(err) {
+		return
+	}
+
+	c.consecutiveFails++
+	c.lastError = err
+	c.lastErrorAt = time.Now()
+	if c.consecutiveFails >= c.FailureThreshold {
+		c.state = CircuitOpen
+		c.openedAt = time.Now()
+	}
+}
+
+// Status returns a point-in-time snapshot of the breaker's state.
+func (c *CircuitBreaker) Status() CircuitStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := CircuitStatus{
+		Provider:         c.name,
+		State:            c.state.String(),
+		ConsecutiveFails: c.consecutiveFails,
+		LastErrorAt:      c.lastErrorAt,
+	}
+	if c.lastError != nil {
+		status.LastError = c.lastError.Error()
+	}
+	return status
+}
+
+// isCircuitTrippingError reports whether err should count toward tripping
+// the circuit: ErrProviderUnavailable or ErrTimeout, unwrapped from a
+// *ProviderError if necessary.
+func isCircuitTrippingError(err error) bool {
+	var provErr *ProviderError
+	if errors.As(err, &provErr) {
+		err = provErr.Err
+	}
+	return errors.Is(err, ErrProviderUnavailable) || errors.Is(err, ErrTimeout)
+}
+
+var (
+	circuitRegistryMu sync.Mutex
+	circuitRegistry   []*CircuitBreaker
+)
+
+// registerCircuitBreaker adds cb to the process-wide registry used by
+// ProviderHealthSnapshot/HealthHandler.
+func registerCircuitBreaker(cb *CircuitBreaker) {
+	circuitRegistryMu.Lock()
+	defer circuitRegistryMu.Unlock()
+	circuitRegistry = append(circuitRegistry, cb)
+}
+
+// ProviderHealthSnapshot returns the current status of every CircuitBreaker
+// created via NewCircuitBreaker during this process's lifetime.
+func ProviderHealthSnapshot() []CircuitStatus {
+	circuitRegistryMu.Lock()
+	breakers := make([]*CircuitBreaker, len(circuitRegistry))
+	copy(breakers, circuitRegistry)
+	circuitRegistryMu.Unlock()
+
+	statuses := make([]CircuitStatus, len(breakers))
+	for i, cb := range breakers {
+		statuses[i] = cb.Status()
+	}
+	return statuses
+}
@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_TripsAfterConsecutiveFailures(t *testing.T) {
+	inner := &fakeProvider{wantErr: true, err: ErrProviderUnavailable}
+	cb := NewCircuitBreaker("test-trip", inner, 2, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if _, err := cb.Generate(context.Background(), LLMRequest{}); !errors.Is(err, ErrProviderUnavailable) {
+			t.Fatalf("call %d error = %v, want ErrProviderUnavailable", i, err)
+		}
+	}
+	if inner.calls != 2 {
+		t.Fatalf("expected the provider to be called twice before tripping, got %d", inner.calls)
+	}
+
+	if status := cb.Status(); status.State != CircuitOpen.String() {
+		t.Fatalf("Status().State = %q, want %q", status.State, CircuitOpen.String())
+	}
+
+	// Circuit is open: the call should be short-circuited without reaching the provider.
+	if _, err := cb.Generate(context.Background(), LLMRequest{}); err == nil {
+		t.Fatal("Generate() error = nil, want a synthetic circuit-open error")
+	}
+	if inner.calls != 2 {
+		t.Errorf("expected the provider not to be called while the circuit is open, got %d calls", inner.calls)
+	}
+}
+
+func TestCircuitBreaker_DoesNotTripOnNonQualifyingErrors(t *testing.T) {
+	inner := &fakeProvider{wantErr: true, err: ErrBadRequest}
+	cb := NewCircuitBreaker("test-no-trip", inner, 1, time.Minute)
+
+	if _, err := cb.Generate(context.Background(), LLMRequest{}); !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("Generate() error = %v, want ErrBadRequest", err)
+	}
+	if status := cb.Status(); status.State != CircuitClosed.String() {
+		t.Fatalf("Status().State = %q, want %q (ErrBadRequest shouldn't trip the circuit)", status.State, CircuitClosed.String())
+	}
+}
+
+func TestCircuitBreaker_HalfOpenProbeCloses(t *testing.T) {
+	inner := &fakeProvider{wantErr: true, err: ErrTimeout}
+	cb := NewCircuitBreaker("test-half-open-close", inner, 1, 10*time.Millisecond)
+
+	if _, err := cb.Generate(context.Background(), LLMRequest{}); err == nil {
+		t.Fatal("expected the first call to fail and trip the circuit")
+	}
+	if status := cb.Status(); status.State != CircuitOpen.String() {
+		t.Fatalf("Status().State = %q, want %q", status.State, CircuitOpen.String())
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	inner.wantErr = false
+
+	if _, err := cb.Generate(context.Background(), LLMRequest{}); err != nil {
+		t.Fatalf("half-open probe error = %v, want nil (probe succeeds)", err)
+	}
+	if status := cb.Status(); status.State != CircuitClosed.String() {
+		t.Fatalf("Status().State after successful probe = %q, want %q", status.State, CircuitClosed.String())
+	}
+}
+
+func TestCircuitBreaker_HalfOpenProbeReopens(t *testing.T) {
+	inner := &fakeProvider{wantErr: true, err: ErrTimeout}
+	cb := NewCircuitBreaker("test-half-open-reopen", inner, 1, 10*time.Millisecond)
+
+	if _, err := cb.Generate(context.Background(), LLMRequest{}); err == nil {
+		t.Fatal("expected the first call to fail and trip the circuit")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, err := cb.Generate(context.Background(), LLMRequest{}); err == nil {
+		t.Fatal("expected the half-open probe to fail again")
+	}
+	if status := cb.Status(); status.State != CircuitOpen.String() {
+		t.Fatalf("Status().State after failed probe = %q, want %q", status.State, CircuitOpen.String())
+	}
+}
+
+func TestProviderHealthSnapshot_IncludesRegisteredBreakers(t *testing.T) {
+	before := len(ProviderHealthSnapshot())
+
+	NewCircuitBreaker("test-snapshot", &fakeProvider{}, 3, time.Minute)
+
+	after := ProviderHealthSnapshot()
+	if len(after) != before+1 {
+		t.Fatalf("ProviderHealthSnapshot() returned %d entries, want %d", len(after), before+1)
+	}
+}
@@ -1,5 +1,11 @@
 package llm
 
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
 // ParameterType represents the type of a tool parameter
 type ParameterType string
 
@@ -9,16 +15,60 @@ const (
 	TypeBoolean ParameterType = "boolean"
 	TypeObject  ParameterType = "object"
 	TypeArray   ParameterType = "array" // TODO: needs a secondary type for items
+	// TypeImage marks a tool parameter that expects image bytes (e.g. an NPC
+	// "look at what the player is showing me" tool), so it's declared
+	// distinctly from a plain string/base64 blob.
+	TypeImage ParameterType = "image"
 )
 
 type LLMProvider interface {
-	Generate(request LLMRequest) (LLMResponse, error)
+	// Generate takes ctx so callers can cancel an in-flight call or enforce a
+	// deadline (e.g. the player walking away mid-conversation); implementers
+	// thread it through to the underlying HTTP request.
+	Generate(ctx context.Context, request LLMRequest) (LLMResponse, error)
+	// GenerateStream is Generate's incremental counterpart: it returns a
+	// channel of LLMChunk as soon as the provider starts responding, rather
+	// than blocking until the full response is assembled. The channel is
+	// closed once the final chunk (FinishReason set, or Err set) has been
+	// sent, or ctx is canceled. Providers without native streaming support
+	// emit the whole response as a single final chunk.
+	GenerateStream(ctx context.Context, request LLMRequest) (<-chan LLMChunk, error)
 }
 
 type LLMResponse struct {
 	StatusCode int
 	Response   string
 	ToolUses   []ToolUse
+	// Retry is set by providers that performed their own HTTP-level retries
+	// to produce this response (e.g. LMStudio riding out a 429 or 5xx), so
+	// callers like the log viewer can surface retry storms instead of just a
+	// slow response. LMStudio sets it on every response it returns, with
+	// Attempts 1 when the first attempt succeeded; providers without their own
+	// retry loop leave it nil.
+	Retry *RetryMetrics
+	// Structured holds Response re-exposed as a parsed JSON value, set when
+	// ResponseFormat was FormatJSON or FormatJSONSchema and the model's
+	// reply was valid JSON. Nil for FormatText, or if the model ignored the
+	// requested format and replied with non-JSON text.
+	Structured json.RawMessage
+	// Provider names the concrete provider that produced this response (e.g.
+	// "anthropic", "ollama"), set by that provider's Generate and carried
+	// through CircuitBreaker/Retrier/FallbackProvider untouched, so callers
+	// can label metrics without needing to know which provider they called.
+	Provider string
+	// Usage reports token accounting for this response, if the provider
+	// returned one; nil otherwise.
+	Usage *LLMUsage
+}
+
+// RetryMetrics summarizes a provider's own retry attempts for a single
+// Generate call, distinct from the outer Retrier that NewProvider wraps
+// every provider with: that one retries whole Generate calls as a black box
+// and never sees per-attempt HTTP detail.
+type RetryMetrics struct {
+	Attempts       int
+	LastStatusCode int
+	TotalLatency   time.Duration
 }
 
 type ToolUse struct {
@@ -30,16 +80,117 @@ type LLMRequest struct {
 	SystemPrompt string
 	Prompt       string
 	Tools        []Tool
+	// ToolMode selects how Tools are surfaced to the model. The zero value
+	// (ToolModeNative) preserves existing behavior: Tools are sent via the
+	// provider's native tool-calling request field.
+	ToolMode ToolMode
+	// Images are raw image bytes (JPEG/PNG) attached to the prompt, for
+	// vision-capable models (e.g. llava, llama3.2-vision). A provider that
+	// doesn't support vision should ignore them rather than error, same as
+	// it would ignore Tools it can't use natively.
+	Images [][]byte
+	// ResponseFormat constrains the shape of the model's reply. The nil zero
+	// value behaves like FormatText. A provider that doesn't support
+	// structured output should ignore it rather than error.
+	ResponseFormat ResponseFormat
+}
+
+// ResponseFormat selects how an LLMRequest's reply should be structured, so
+// callers needing machine-parseable output (e.g. an NPC action like
+// {"action": "move", "target": "tavern"}) don't have to scrape it out of
+// free-form text.
+type ResponseFormat interface {
+	isResponseFormat()
+}
+
+// textResponseFormat is FormatText's concrete type.
+type textResponseFormat struct{}
+
+func (textResponseFormat) isResponseFormat() {}
+
+// FormatText requests free-form text output. It's equivalent to leaving
+// LLMRequest.ResponseFormat nil; it exists so callers can be explicit.
+var FormatText ResponseFormat = textResponseFormat{}
+
+// jsonResponseFormat is FormatJSON's concrete type.
+type jsonResponseFormat struct{}
+
+func (jsonResponseFormat) isResponseFormat() {}
+
+// FormatJSON asks the model to emit a single JSON value, without enforcing
+// any particular shape (Ollama's "format": "json" mode).
+var FormatJSON ResponseFormat = jsonResponseFormat{}
+
+// FormatJSONSchema asks the model to emit JSON matching Schema, a JSON
+// Schema document, and makes the provider validate the reply against it
+// before returning, surfacing a mismatch as an ErrBadRequest-wrapped
+// ProviderError so the caller can retry instead of acting on bad data.
+type FormatJSONSchema struct {
+	Schema json.RawMessage
 }
 
+func (FormatJSONSchema) isResponseFormat() {}
+
+// ToolMode selects how LLMRequest.Tools are surfaced to the underlying
+// model, so callers can work around models that silently ignore a native
+// tools request field.
+type ToolMode string
+
+const (
+	// ToolModeNative sends Tools via the provider's native tool-calling
+	// field (e.g. Ollama's "tools" array).
+	ToolModeNative ToolMode = "native"
+	// ToolModePromptInjected describes each tool as JSON schema in the
+	// system prompt instead, for models without native tool support, and
+	// parses a {"tool": "<name>", "tool_input": {...}} JSON reply back into
+	// ToolUses.
+	ToolModePromptInjected ToolMode = "prompt_injected"
+	// ToolModeAuto probes the model once (cached per model name) to decide
+	// between ToolModeNative and ToolModePromptInjected.
+	ToolModeAuto ToolMode = "auto"
+)
+
 type Tool struct {
 	Name        string
 	Description string
 	Parameters  map[string]ToolParameter
 }
 
+// ToolParameter describes a single parameter of a Tool, JSON-Schema-ish
+// enough for tools.validateArgs to enforce real constraints instead of just
+// presence/type. Every constraint below is optional and only applies to the
+// matching Type; tools that set nothing but Type/Description/Required keep
+// working exactly as before.
 type ToolParameter struct {
 	Type        ParameterType
 	Description string
 	Required    bool
+
+	// Numeric constraints, used when Type is TypeNumber.
+	Minimum *float64
+	Maximum *float64
+
+	// String constraints, used when Type is TypeString. Format, when set to
+	// "date-time", makes tools.validateArgs parse the value as RFC3339 and
+	// hand the handler a time.Time instead of the raw string.
+	Pattern   string
+	Enum      []string
+	MinLength *int
+	MaxLength *int
+	Format    string
+
+	// Array constraints, used when Type is TypeArray. Items, if set,
+	// validates every element against a single shared schema.
+	Items    *ToolParameter
+	MinItems *int
+	MaxItems *int
+
+	// Object constraints, used when Type is TypeObject.
+	Properties map[string]ToolParameter
+
+	// Composition: if set, the value must satisfy at least one (AnyOf) or
+	// exactly one (OneOf) of the listed sub-schemas, in place of the Type
+	// and other constraints above.
+	OneOf []ToolParameter
+	AnyOf []ToolParameter
 }
@@ -1,8 +1,14 @@
 package llm
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
+	"runtime"
+	"time"
+
+	"github.com/piercegov/llm-npc-backend/internal/logging"
 )
 
 // ErrProviderUnavailable indicates the LLM provider is unreachable
@@ -29,6 +35,19 @@ type ProviderError struct {
 	Model    string
 	Err      error
 	Message  string
+	// RetryAfter carries a provider-supplied backoff hint for ErrRateLimited
+	// errors (e.g. parsed from a Retry-After header). Zero means no hint.
+	RetryAfter time.Duration
+	// Timestamp is when the error was constructed.
+	Timestamp time.Time
+	// RequestID is the originating request's ID, populated by
+	// NewProviderErrorCtx. Empty when the error was built without a context.
+	RequestID string
+
+	// stack is the call stack captured at construction time, as raw program
+	// counters. It's formatted lazily, via Format, since most errors are
+	// never printed with %+v.
+	stack []uintptr
 }
 
 func (e *ProviderError) Error() string {
@@ -42,30 +61,97 @@ func (e *ProviderError) Unwrap() error {
 	return e.Err
 }
 
-// NewProviderError creates a new provider error with context
+// Format implements fmt.Formatter so that %+v prints the error's message
+// followed by its captured call stack, with runtime and constructor frames
+// skipped. Other verbs fall back to Error().
+func (e *ProviderError) Format(s fmt.State, verb rune) {
+	if verb != 'v' || !s.Flag('+') {
+		fmt.Fprint(s, e.Error())
+		return
+	}
+
+	fmt.Fprintln(s, e.Error())
+	frames := runtime.CallersFrames(e.stack)
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(s, "\t%s\n\t\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+}
+
+// stackSkip is the number of frames runtime.Callers should skip to land on
+// the caller of NewProviderError/NewProviderErrorCtx, past runtime.Callers
+// itself, captureStack, and the constructor.
+const stackSkip = 3
+
+// captureStack captures the current call stack, skipping skip frames.
+func captureStack(skip int) []uintptr {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(skip, pcs)
+	return pcs[:n]
+}
+
+// NewProviderError creates a new provider error with context, capturing the
+// call stack at this point for later debugging via Format's %+v.
 func NewProviderError(provider, model string, err error, message string) *ProviderError {
 	return &ProviderError{
-		Provider: provider,
-		Model:    model,
-		Err:      err,
-		Message:  message,
+		Provider:  provider,
+		Model:     model,
+		Err:       err,
+		Message:   message,
+		Timestamp: time.Now(),
+		stack:     captureStack(stackSkip),
 	}
 }
 
+// NewProviderErrorCtx is NewProviderError plus the request ID extracted from
+// ctx (if any), so operators can correlate a provider failure back to the
+// request that triggered it.
+func NewProviderErrorCtx(ctx context.Context, provider, model string, err error, message string) *ProviderError {
+	return &ProviderError{
+		Provider:  provider,
+		Model:     model,
+		Err:       err,
+		Message:   message,
+		Timestamp: time.Now(),
+		RequestID: logging.RequestIDFromContext(ctx),
+		stack:     captureStack(stackSkip),
+	}
+}
+
+// classifyTransportError maps a failed HTTP round trip to a *ProviderError,
+// keeping an explicitly canceled or expired ctx (the caller gave up) distinct
+// from a provider-side network timeout or connection failure: the former
+// should never be retried, while the latter are what IsRetryable expects.
+func classifyTransportError(ctx context.Context, provider, model string, err error) *ProviderError {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return NewProviderErrorCtx(ctx, provider, model, ctxErr, "request canceled")
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return NewProviderErrorCtx(ctx, provider, model, ErrTimeout, "request timed out")
+	}
+
+	return NewProviderErrorCtx(ctx, provider, model, ErrProviderUnavailable, "failed to connect to provider")
+}
+
 // IsRetryable determines if an error is temporary and can be retried
 func IsRetryable(err error) bool {
 	if err == nil {
 		return false
 	}
-	
+
 	// Check if it's a wrapped error
 	var provErr *ProviderError
 	if errors.As(err, &provErr) {
 		err = provErr.Err
 	}
-	
+
 	// Rate limiting and timeouts are typically retryable
-	return errors.Is(err, ErrRateLimited) || 
+	return errors.Is(err, ErrRateLimited) ||
 		errors.Is(err, ErrTimeout) ||
 		errors.Is(err, ErrProviderUnavailable)
-}
\ No newline at end of file
+}
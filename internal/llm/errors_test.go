@@ -1,8 +1,13 @@
 package llm
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/piercegov/llm-npc-backend/internal/logging"
 )
 
 func TestProviderError(t *testing.T) {
@@ -45,6 +50,36 @@ func TestProviderError(t *testing.T) {
 	}
 }
 
+func TestProviderError_FormatPlusVIncludesStack(t *testing.T) {
+	err := NewProviderError("ollama", "qwen3:1.7b", ErrTimeout, "request timed out")
+
+	plain := fmt.Sprintf("%v", err)
+	if plain != err.Error() {
+		t.Errorf("%%v = %q, want %q", plain, err.Error())
+	}
+
+	verbose := fmt.Sprintf("%+v", err)
+	if !strings.HasPrefix(verbose, err.Error()) {
+		t.Errorf("%%+v = %q, want it to start with %q", verbose, err.Error())
+	}
+	if !strings.Contains(verbose, "TestProviderError_FormatPlusVIncludesStack") {
+		t.Errorf("%%+v = %q, want it to contain this test function in the captured stack", verbose)
+	}
+}
+
+func TestNewProviderErrorCtx_PopulatesRequestID(t *testing.T) {
+	ctx := logging.WithRequestID(context.Background(), "req-123")
+
+	err := NewProviderErrorCtx(ctx, "ollama", "qwen3:1.7b", ErrTimeout, "request timed out")
+
+	if err.RequestID != "req-123" {
+		t.Errorf("RequestID = %q, want %q", err.RequestID, "req-123")
+	}
+	if err.Timestamp.IsZero() {
+		t.Error("Timestamp = zero value, want it to be set")
+	}
+}
+
 func TestIsRetryable(t *testing.T) {
 	tests := []struct {
 		name    string
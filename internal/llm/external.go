@@ -0,0 +1,34 @@
+package llm
+
+import "context"
+
+// ExternalProvider is a placeholder LLMProvider for a user-run model server
+// reachable over gRPC, registered by address (similar to how LocalAI fronts
+// arbitrary ggml/falcon backends behind a gRPC LLM service). Wiring this up
+// for real needs a generated gRPC client from a .proto service definition,
+// which in turn needs google.golang.org/grpc vendored into the build; go.mod
+// lists grpc only as an indirect dependency and no generated client exists
+// in this repo, so this stays
+// a typed extension point that fails clearly rather than a working client,
+// the same way memory.NewStore reserves "sqlite://"/"chromem://" without
+// implementing them yet.
+type ExternalProvider struct {
+	Address string
+}
+
+// NewExternalProvider registers a gRPC model server at address under the
+// "external"/"grpc" provider name. Generate and GenerateStream return
+// ErrProviderUnavailable until a gRPC client is vendored in.
+func NewExternalProvider(address string) *ExternalProvider {
+	return &ExternalProvider{Address: address}
+}
+
+func (e *ExternalProvider) Generate(ctx context.Context, request LLMRequest) (LLMResponse, error) {
+	return LLMResponse{}, NewProviderErrorCtx(ctx, "external", e.Address, ErrProviderUnavailable,
+		"external gRPC provider is registered but not yet implemented: no grpc client is vendored in this build")
+}
+
+func (e *ExternalProvider) GenerateStream(ctx context.Context, request LLMRequest) (<-chan LLMChunk, error) {
+	return nil, NewProviderErrorCtx(ctx, "external", e.Address, ErrProviderUnavailable,
+		"external gRPC provider is registered but not yet implemented: no grpc client is vendored in this build")
+}
@@ -8,11 +8,62 @@ import (
 	"github.com/piercegov/llm-npc-backend/internal/logging"
 )
 
-// NewProvider creates an LLM provider based on the configuration
+// NewProvider creates an LLM provider from the configuration. LLMProvider
+// accepts a comma-separated, ordered list (e.g. "cerebras,ollama,lmstudio"):
+// with a single entry it returns that provider wrapped in a Retrier; with
+// more than one it returns a FallbackProvider that advances through the list
+// on retryable failures. Either way, every concrete provider is transparently
+// wrapped with a Retrier so transient failures are retried with exponential
+// backoff before they count against the fallback chain.
 func NewProvider(config cfg.Config) (LLMProvider, error) {
-	provider := strings.ToLower(config.LLMProvider)
+	names := splitProviderNames(config.LLMProvider)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no LLM provider configured")
+	}
+
+	retrier := NewRetrier(config.LLMRetryMaxAttempts, config.LLMRetryBaseDelay, config.LLMRetryMaxDelay, 0)
+
+	buildGuarded := func(name string) (LLMProvider, error) {
+		provider, err := newRawProvider(name, config)
+		if err != nil {
+			return nil, err
+		}
+		breaker := NewCircuitBreaker(name, provider, config.CircuitBreakerFailureThreshold, config.CircuitBreakerCooldown)
+		return WithRetry(breaker, retrier), nil
+	}
+
+	if len(names) == 1 {
+		return buildGuarded(names[0])
+	}
+
+	var entries []fallbackEntry
+	for _, name := range names {
+		provider, err := buildGuarded(name)
+		if err != nil {
+			logging.Warn("Skipping unsupported provider in LLM_PROVIDER fallback chain", "provider", name, "error", err)
+			continue
+		}
+		entries = append(entries, newFallbackEntry(name, provider))
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no supported LLM providers in fallback chain: %s", config.LLMProvider)
+	}
 
-	switch provider {
+	logging.Info("Creating LLM fallback chain", "providers", names)
+	return NewFallbackProvider(entries, defaultFallbackFailureThreshold, defaultFallbackCooldown), nil
+}
+
+// disableInnerRetry is applied to every LMStudio (and OpenAI, which is just
+// an LMStudio pointed at a different base URL) buildGuarded constructs: the
+// outer Retrier it's wrapped with already retries the whole Generate call,
+// so leaving LMStudio's own internal retry loop at its default would stack
+// up to MaxAttempts^2 attempts with both layers' backoff, unlike every other
+// provider here, and would delay the circuit breaker from observing failures.
+var disableInnerRetry = WithRetryPolicy(RetryPolicy{MaxAttempts: 1})
+
+// newRawProvider builds the concrete LLMProvider named by name, unwrapped.
+func newRawProvider(name string, config cfg.Config) (LLMProvider, error) {
+	switch strings.ToLower(name) {
 	case "ollama":
 		logging.Info("Creating Ollama provider", "model", config.OllamaModel)
 		return NewOllama("11434"), nil
@@ -22,9 +73,37 @@ func NewProvider(config cfg.Config) (LLMProvider, error) {
 			"base_url", config.LMStudioBaseURL,
 			"model", config.LMStudioModel,
 		)
-		return NewLMStudio(config.LMStudioBaseURL, config.LMStudioModel, config.LMStudioAPIKey), nil
+		return NewLMStudio(config.LMStudioBaseURL, config.LMStudioModel, config.LMStudioAPIKey, config.LLMTimeout, disableInnerRetry), nil
+
+	case "openai":
+		logging.Info("Creating OpenAI provider", "base_url", config.OpenAIBaseURL, "model", config.OpenAIModel)
+		return NewOpenAI(config.OpenAIBaseURL, config.OpenAIModel, config.OpenAIAPIKey, config.LLMTimeout, disableInnerRetry), nil
+
+	case "anthropic":
+		logging.Info("Creating Anthropic provider", "base_url", config.AnthropicBaseURL, "model", config.AnthropicModel)
+		return NewAnthropic(config.AnthropicBaseURL, config.AnthropicModel, config.AnthropicAPIKey, config.LLMTimeout), nil
+
+	case "external", "grpc":
+		if config.ExternalProviderAddress == "" {
+			return nil, fmt.Errorf("external provider requires EXTERNAL_PROVIDER_ADDRESS to be set")
+		}
+		logging.Info("Creating external gRPC provider", "address", config.ExternalProviderAddress)
+		return NewExternalProvider(config.ExternalProviderAddress), nil
 
 	default:
-		return nil, fmt.Errorf("unsupported LLM provider: %s", config.LLMProvider)
+		return nil, fmt.Errorf("unsupported LLM provider: %s", name)
+	}
+}
+
+// splitProviderNames parses a comma-separated LLM_PROVIDER value into an
+// ordered list of trimmed, non-empty provider names.
+func splitProviderNames(raw string) []string {
+	var names []string
+	for _, part := range strings.Split(raw, ",") {
+		trimmed := strings.TrimSpace(part)
+		if trimmed != "" {
+			names = append(names, trimmed)
+		}
 	}
+	return names
 }
@@ -0,0 +1,219 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/piercegov/llm-npc-backend/internal/logging"
+)
+
+const (
+	// defaultFallbackFailureThreshold is how many consecutive failures a
+	// provider in a fallback chain tolerates before entering cooldown.
+	defaultFallbackFailureThreshold = 3
+	// defaultFallbackCooldown is how long a provider is skipped once it trips
+	// defaultFallbackFailureThreshold, instead of being retried on every request.
+	defaultFallbackCooldown = 30 * time.Second
+)
+
+// providerHealth tracks consecutive failures for one entry in a fallback chain.
+type providerHealth struct {
+	mu               sync.Mutex
+	consecutiveFails int
+	lastFailureAt    time.Time
+}
+
+func (h *providerHealth) recordSuccess() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.consecutiveFails = 0
+}
+
+func (h *providerHealth) recordFailure() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.consecutiveFails++
+	h.lastFailureAt = time.Now()
+}
+
+// inCooldown reports whether this provider has failed enough consecutive
+// times, recently enough, to be skipped rather than tried again.
+func (h *providerHealth) inCooldown(threshold int, cooldown time.Duration) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.consecutiveFails < threshold {
+		return false
+	}
+	return time.Since(h.lastFailureAt) < cooldown
+}
+
+// fallbackEntry is one named, health-tracked provider in a FallbackProvider chain.
+type fallbackEntry struct {
+	name     string
+	provider LLMProvider
+	health   *providerHealth
+}
+
+// newFallbackEntry wraps provider with a fresh health tracker.
+func newFallbackEntry(name string, provider LLMProvider) fallbackEntry {
+	return fallbackEntry{name: name, provider: provider, health: &providerHealth{}}
+}
+
+// FallbackProvider tries a series of named providers in order, advancing to
+// the next only when the current one fails with an error IsRetryable
+// considers transient, or ErrModelNotFound/ErrUnauthorized. A provider that
+// fails FailureThreshold times in a row is skipped for Cooldown instead of
+// being tried on every request.
+type FallbackProvider struct {
+	entries          []fallbackEntry
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+// NewFallbackProvider builds a FallbackProvider over the given ordered,
+// already-constructed providers.
+func NewFallbackProvider(entries []fallbackEntry, failureThreshold int, cooldown time.Duration) *FallbackProvider {
+	return &FallbackProvider{
+		entries:          entries,
+		FailureThreshold: failureThreshold,
+		Cooldown:         cooldown,
+	}
+}
+
+func (f *FallbackProvider) Generate(ctx context.Context, request LLMRequest) (LLMResponse, error) {
+	var lastErr error
+	attempted := 0
+
+	for i := range f.entries {
+		entry := &f.entries[i]
+
+		if entry.health.inCooldown(f.FailureThreshold, f.Cooldown) {
+			logging.Warn("Skipping LLM provider in cooldown",
+				"provider", entry.name,
+				"hop", i+1,
+				"total_providers", len(f.entries),
+			)
+			continue
+		}
+
+		attempted++
+		response, err := entry.provider.Generate(ctx, request)
+		if err == nil {
+			entry.health.recordSuccess()
+			return response, nil
+		}
+
+		entry.health.recordFailure()
+		lastErr = err
+
+		if !shouldFallThrough(err) {
+			logging.Error("LLM provider returned a non-fallback error, aborting chain",
+				"provider", entry.name,
+				"hop", i+1,
+				"error", err,
+			)
+			return LLMResponse{}, err
+		}
+
+		logging.Warn("Falling back to next LLM provider",
+			"failed_provider", entry.name,
+			"hop", i+1,
+			"total_providers", len(f.entries),
+			"error", err,
+		)
+	}
+
+	if attempted == 0 {
+		return LLMResponse{}, fmt.Errorf("all %d LLM providers in the fallback chain are in cooldown", len(f.entries))
+	}
+
+	return LLMResponse{}, lastErr
+}
+
+// GenerateStream mirrors Generate's chain-walking logic but forwards the
+// first entry's own stream instead of buffering it: an entry that fails to
+// even start streaming falls through to the next entry exactly as Generate
+// would, but once an entry's stream has started this commits to it, since
+// chunks may already be in the caller's hands and there's nothing left to
+// fall back from.
+func (f *FallbackProvider) GenerateStream(ctx context.Context, request LLMRequest) (<-chan LLMChunk, error) {
+	var lastErr error
+	attempted := 0
+
+	for i := range f.entries {
+		entry := &f.entries[i]
+
+		if entry.health.inCooldown(f.FailureThreshold, f.Cooldown) {
+			logging.Warn("Skipping LLM provider in cooldown",
+				"provider", entry.name,
+				"hop", i+1,
+				"total_providers", len(f.entries),
+			)
+			continue
+		}
+
+		attempted++
+		upstream, err := entry.provider.GenerateStream(ctx, request)
+		if err == nil {
+			out := make(chan LLMChunk)
+			go func(entry *fallbackEntry) {
+				defer close(out)
+				var streamErr error
+				for chunk := range upstream {
+					out <- chunk
+					if chunk.Err != nil {
+						streamErr = chunk.Err
+					}
+				}
+				if streamErr == nil {
+					entry.health.recordSuccess()
+				} else {
+					entry.health.recordFailure()
+				}
+			}(entry)
+			return out, nil
+		}
+
+		entry.health.recordFailure()
+		lastErr = err
+
+		if !shouldFallThrough(err) {
+			logging.Error("LLM provider returned a non-fallback error, aborting chain",
+				"provider", entry.name,
+				"hop", i+1,
+				"error", err,
+			)
+			return nil, err
+		}
+
+		logging.Warn("Falling back to next LLM provider",
+			"failed_provider", entry.name,
+			"hop", i+1,
+			"total_providers", len(f.entries),
+			"error", err,
+		)
+	}
+
+	if attempted == 0 {
+		return nil, fmt.Errorf("all %d LLM providers in the fallback chain are in cooldown", len(f.entries))
+	}
+
+	return nil, lastErr
+}
+
+// shouldFallThrough reports whether err should advance a FallbackProvider to
+// the next provider in the chain, rather than aborting the whole chain.
+func shouldFallThrough(err error) bool {
+	if IsRetryable(err) {
+		return true
+	}
+
+	var provErr *ProviderError
+	if errors.As(err, &provErr) {
+		err = provErr.Err
+	}
+	return errors.Is(err, ErrModelNotFound) || errors.Is(err, ErrUnauthorized)
+}
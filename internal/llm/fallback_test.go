@@ -0,0 +1,123 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/piercegov/llm-npc-backend/internal/logging"
+)
+
+func init() {
+	logging.InitLogger("debug")
+}
+
+// fakeProvider is a minimal LLMProvider stub for exercising FallbackProvider.
+type fakeProvider struct {
+	calls   int
+	err     error
+	wantErr bool
+}
+
+func (f *fakeProvider) Generate(ctx context.Context, request LLMRequest) (LLMResponse, error) {
+	f.calls++
+	if f.wantErr {
+		return LLMResponse{}, f.err
+	}
+	return LLMResponse{Response: "ok"}, nil
+}
+
+func (f *fakeProvider) GenerateStream(ctx context.Context, request LLMRequest) (<-chan LLMChunk, error) {
+	return generateStreamFallback(ctx, f.Generate, request)
+}
+
+func TestFallbackProvider_AdvancesOnRetryableError(t *testing.T) {
+	primary := &fakeProvider{wantErr: true, err: ErrProviderUnavailable}
+	secondary := &fakeProvider{wantErr: false}
+
+	fp := NewFallbackProvider([]fallbackEntry{
+		newFallbackEntry("primary", primary),
+		newFallbackEntry("secondary", secondary),
+	}, 3, time.Minute)
+
+	response, err := fp.Generate(context.Background(), LLMRequest{})
+	if err != nil {
+		t.Fatalf("Generate() error = %v, want nil", err)
+	}
+	if response.Response != "ok" {
+		t.Errorf("Generate() response = %+v, want fallback provider's response", response)
+	}
+	if primary.calls != 1 || secondary.calls != 1 {
+		t.Errorf("expected both providers to be tried once, got primary=%d secondary=%d", primary.calls, secondary.calls)
+	}
+}
+
+func TestFallbackProvider_AbortsOnNonFallbackError(t *testing.T) {
+	primary := &fakeProvider{wantErr: true, err: ErrBadRequest}
+	secondary := &fakeProvider{wantErr: false}
+
+	fp := NewFallbackProvider([]fallbackEntry{
+		newFallbackEntry("primary", primary),
+		newFallbackEntry("secondary", secondary),
+	}, 3, time.Minute)
+
+	_, err := fp.Generate(context.Background(), LLMRequest{})
+	if !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("Generate() error = %v, want ErrBadRequest", err)
+	}
+	if secondary.calls != 0 {
+		t.Errorf("expected secondary provider not to be tried, got %d calls", secondary.calls)
+	}
+}
+
+func TestFallbackProvider_SkipsProviderInCooldownAfterThreshold(t *testing.T) {
+	primary := &fakeProvider{wantErr: true, err: ErrTimeout}
+	secondary := &fakeProvider{wantErr: false}
+
+	fp := NewFallbackProvider([]fallbackEntry{
+		newFallbackEntry("primary", primary),
+		newFallbackEntry("secondary", secondary),
+	}, 2, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		if _, err := fp.Generate(context.Background(), LLMRequest{}); err != nil {
+			t.Fatalf("Generate() call %d error = %v, want nil (secondary succeeds)", i, err)
+		}
+	}
+	if primary.calls != 2 {
+		t.Fatalf("expected primary to be tried twice before tripping cooldown, got %d", primary.calls)
+	}
+
+	// Third call: primary has now failed FailureThreshold times in a row and
+	// should be skipped entirely.
+	if _, err := fp.Generate(context.Background(), LLMRequest{}); err != nil {
+		t.Fatalf("Generate() error = %v, want nil", err)
+	}
+	if primary.calls != 2 {
+		t.Errorf("expected primary to be skipped while in cooldown, got %d calls", primary.calls)
+	}
+}
+
+func TestFallbackProvider_AllProvidersInCooldown(t *testing.T) {
+	only := &fakeProvider{wantErr: true, err: ErrTimeout}
+
+	fp := NewFallbackProvider([]fallbackEntry{
+		newFallbackEntry("only", only),
+	}, 1, time.Minute)
+
+	if _, err := fp.Generate(context.Background(), LLMRequest{}); err == nil {
+		t.Fatal("Generate() error = nil, want an error from the first (failing) call")
+	}
+	if only.calls != 1 {
+		t.Fatalf("expected the provider to be tried once before tripping cooldown, got %d", only.calls)
+	}
+
+	_, err := fp.Generate(context.Background(), LLMRequest{})
+	if err == nil {
+		t.Fatal("Generate() error = nil, want an error once the only provider is in cooldown")
+	}
+	if only.calls != 1 {
+		t.Errorf("expected the provider not to be retried while in cooldown, got %d calls", only.calls)
+	}
+}
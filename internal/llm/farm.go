@@ -0,0 +1,407 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/piercegov/llm-npc-backend/internal/logging"
+)
+
+// FarmPolicy selects which healthy endpoint a FarmOllama request lands on.
+type FarmPolicy string
+
+const (
+	RoundRobin    FarmPolicy = "round_robin"
+	LeastInflight FarmPolicy = "least_inflight"
+	PriorityFirst FarmPolicy = "priority_first"
+)
+
+// FarmEndpoint describes one Ollama instance in a FarmOllama pool: its own
+// base URL, the model it serves, an operator-chosen Group tag (e.g. a GPU
+// class), and a Priority used by PriorityFirst.
+type FarmEndpoint struct {
+	BaseURL  string
+	Model    string
+	Group    string
+	Priority int
+}
+
+// Where narrows which FarmEndpoints a farm request is allowed to land on. A
+// zero Where matches every endpoint.
+type Where struct {
+	Group string
+	Model string
+}
+
+func (w Where) matches(e FarmEndpoint) bool {
+	if w.Group != "" && w.Group != e.Group {
+		return false
+	}
+	if w.Model != "" && w.Model != e.Model {
+		return false
+	}
+	return true
+}
+
+// FarmEndpointStatus is a point-in-time snapshot of one FarmEndpoint,
+// reported by FarmOllama.FarmAdminHandler.
+type FarmEndpointStatus struct {
+	BaseURL     string    `json:"base_url"`
+	Model       string    `json:"model"`
+	Group       string    `json:"group"`
+	Priority    int       `json:"priority"`
+	Healthy     bool      `json:"healthy"`
+	Inflight    int       `json:"inflight"`
+	LastChecked time.Time `json:"last_checked"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// farmEndpointState is the mutable runtime state FarmOllama tracks per
+// FarmEndpoint: health and loaded-model info from the last /api/tags probe,
+// and the inflight count the LeastInflight policy ranks on.
+type farmEndpointState struct {
+	endpoint FarmEndpoint
+
+	mu          sync.Mutex
+	healthy     bool
+	inflight    int
+	lastChecked time.Time
+	lastError   error
+}
+
+func (s *farmEndpointState) status() FarmEndpointStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := FarmEndpointStatus{
+		BaseURL:     s.endpoint.BaseURL,
+		Model:       s.endpoint.Model,
+		Group:       s.endpoint.Group,
+		Priority:    s.endpoint.Priority,
+		Healthy:     s.healthy,
+		Inflight:    s.inflight,
+		LastChecked: s.lastChecked,
+	}
+	if s.lastError != nil {
+		status.LastError = s.lastError.Error()
+	}
+	return status
+}
+
+// FarmOllama is an LLMProvider backed by a pool of Ollama endpoints (e.g. one
+// per GPU box). A background health check marks endpoints healthy/unhealthy
+// by what /api/tags reports, Generate/GenerateStream pick an eligible
+// endpoint by FarmPolicy, and a retryable failure falls through to the next
+// eligible endpoint up to retryBudget additional attempts.
+type FarmOllama struct {
+	endpoints    []*farmEndpointState
+	policy       FarmPolicy
+	retryBudget  int
+	healthClient *http.Client
+
+	rrCounter uint64
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewFarmOllama creates a FarmOllama over endpoints, selecting among
+// healthy, selector-matching endpoints with policy. retryBudget is how many
+// additional endpoints a single Generate/GenerateStream call may try after
+// the first attempt fails with a retryable error; 0 means no cross-endpoint
+// retry. Call Start to begin background health checks before routing
+// traffic through it.
+func NewFarmOllama(endpoints []FarmEndpoint, policy FarmPolicy, retryBudget int) *FarmOllama {
+	states := make([]*farmEndpointState, len(endpoints))
+	for i, endpoint := range endpoints {
+		states[i] = &farmEndpointState{endpoint: endpoint, healthy: true}
+	}
+
+	return &FarmOllama{
+		endpoints:    states,
+		policy:       policy,
+		retryBudget:  retryBudget,
+		healthClient: &http.Client{Timeout: 5 * time.Second},
+		stopCh:       make(chan struct{}),
+	}
+}
+
+// Start probes every endpoint once synchronously, then launches a background
+// goroutine that re-probes every interval until Stop is called.
+func (f *FarmOllama) Start(interval time.Duration) {
+	f.checkAll()
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				f.checkAll()
+			case <-f.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the background health-check goroutine started by Start.
+func (f *FarmOllama) Stop() {
+	close(f.stopCh)
+	f.wg.Wait()
+}
+
+func (f *FarmOllama) checkAll() {
+	var wg sync.WaitGroup
+	for _, state := range f.endpoints {
+		wg.Add(1)
+		go func(s *farmEndpointState) {
+			defer wg.Done()
+			f.checkOne(s)
+		}(state)
+	}
+	wg.Wait()
+}
+
+// ollamaTagsResponse is the subset of GET /api/tags this package cares
+// about: which models an Ollama instance currently has loaded.
+type ollamaTagsResponse struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+// checkOne pings state's /api/tags and marks it healthy only if it responds
+// and (when a model is configured) reports that model loaded.
+func (f *FarmOllama) checkOne(state *farmEndpointState) {
+	request, err := http.NewRequest("GET", state.endpoint.BaseURL+"/api/tags", nil)
+	if err != nil {
+		f.recordHealth(state, false, err)
+		return
+	}
+
+	response, err := f.healthClient.Do(request)
+	if err != nil {
+		f.recordHealth(state, false, err)
+		return
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		f.recordHealth(state, false, err)
+		return
+	}
+
+	if response.StatusCode != http.StatusOK {
+		f.recordHealth(state, false, fmt.Errorf("unexpected status %d", response.StatusCode))
+		return
+	}
+
+	var parsed ollamaTagsResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		f.recordHealth(state, false, err)
+		return
+	}
+
+	hasModel := state.endpoint.Model == ""
+	for _, model := range parsed.Models {
+		if model.Name == state.endpoint.Model {
+			hasModel = true
+			break
+		}
+	}
+	if !hasModel {
+		f.recordHealth(state, false, fmt.Errorf("model %q not loaded", state.endpoint.Model))
+		return
+	}
+
+	f.recordHealth(state, true, nil)
+}
+
+func (f *FarmOllama) recordHealth(state *farmEndpointState, healthy bool, err error) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.healthy = healthy
+	state.lastChecked = time.Now()
+	state.lastError = err
+	if !healthy {
+		logging.Warn("Farm endpoint unhealthy", "base_url", state.endpoint.BaseURL, "error", err)
+	}
+}
+
+// eligible returns the endpoints currently healthy and matching where,
+// ordered by f.policy's preference.
+func (f *FarmOllama) eligible(where Where) []*farmEndpointState {
+	var candidates []*farmEndpointState
+	for _, state := range f.endpoints {
+		state.mu.Lock()
+		healthy := state.healthy
+		state.mu.Unlock()
+		if healthy && where.matches(state.endpoint) {
+			candidates = append(candidates, state)
+		}
+	}
+
+	switch f.policy {
+	case LeastInflight:
+		sort.Slice(candidates, func(i, j int) bool {
+			candidates[i].mu.Lock()
+			inflightI := candidates[i].inflight
+			candidates[i].mu.Unlock()
+			candidates[j].mu.Lock()
+			inflightJ := candidates[j].inflight
+			candidates[j].mu.Unlock()
+			return inflightI < inflightJ
+		})
+	case PriorityFirst:
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].endpoint.Priority > candidates[j].endpoint.Priority
+		})
+	default: // RoundRobin
+		if len(candidates) > 0 {
+			offset := int(atomic.AddUint64(&f.rrCounter, 1)) % len(candidates)
+			candidates = append(candidates[offset:], candidates[:offset]...)
+		}
+	}
+
+	return candidates
+}
+
+// Generate implements LLMProvider, routing to any eligible endpoint.
+func (f *FarmOllama) Generate(ctx context.Context, request LLMRequest) (LLMResponse, error) {
+	return f.GenerateWhere(ctx, request, Where{})
+}
+
+// GenerateWhere is Generate with an explicit Where selector, e.g. pinning a
+// boss-fight NPC to a specific GPU group or model.
+func (f *FarmOllama) GenerateWhere(ctx context.Context, request LLMRequest, where Where) (LLMResponse, error) {
+	candidates := f.eligible(where)
+	if len(candidates) == 0 {
+		return LLMResponse{}, NewProviderErrorCtx(ctx, "ollama-farm", where.Model, ErrProviderUnavailable, "no healthy endpoint matches selector")
+	}
+
+	attempts := f.retryBudget + 1
+	if attempts > len(candidates) {
+		attempts = len(candidates)
+	}
+
+	var lastErr error
+	for i := 0; i < attempts; i++ {
+		response, err := f.generateOn(ctx, candidates[i], request)
+		if err == nil {
+			return response, nil
+		}
+		lastErr = err
+		if !IsRetryable(err) {
+			return LLMResponse{}, err
+		}
+	}
+	return LLMResponse{}, lastErr
+}
+
+// generateOn issues request against state's endpoint, reusing
+// buildOllamaRequestMap/classifyOllamaStatus from ollama.go so this pool
+// member behaves exactly like a standalone Ollama provider would.
+func (f *FarmOllama) generateOn(ctx context.Context, state *farmEndpointState, request LLMRequest) (LLMResponse, error) {
+	state.mu.Lock()
+	state.inflight++
+	state.mu.Unlock()
+	defer func() {
+		state.mu.Lock()
+		state.inflight--
+		state.mu.Unlock()
+	}()
+
+	model := state.endpoint.Model
+	requestMap := buildOllamaRequestMap(request, model, false)
+
+	jsonBody, err := json.Marshal(requestMap)
+	if err != nil {
+		return LLMResponse{}, err
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, "POST", state.endpoint.BaseURL+"/api/chat", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return LLMResponse{}, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	if requestID := logging.RequestIDFromContext(ctx); requestID != "" {
+		httpRequest.Header.Set("X-Request-ID", requestID)
+	}
+
+	response, err := (&http.Client{}).Do(httpRequest)
+	if err != nil {
+		return LLMResponse{}, classifyTransportError(ctx, "ollama-farm", model, err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return LLMResponse{}, NewProviderError("ollama-farm", model, err, "failed to read response")
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return LLMResponse{}, classifyOllamaStatus(model, response.StatusCode, body)
+	}
+
+	var parsedResp ollamaResponse
+	if err := json.Unmarshal(body, &parsedResp); err != nil {
+		return LLMResponse{}, NewProviderError("ollama-farm", model, err, "invalid response format")
+	}
+
+	toolUses := make([]ToolUse, len(parsedResp.Message.ToolCalls))
+	for i, call := range parsedResp.Message.ToolCalls {
+		toolUses[i] = ToolUse{ToolName: call.Function.Name, ToolArgs: call.Function.Arguments}
+	}
+
+	return LLMResponse{
+		StatusCode: response.StatusCode,
+		Response:   parsedResp.Message.Content,
+		ToolUses:   toolUses,
+		Provider:   "ollama-farm",
+		Usage: &LLMUsage{
+			PromptTokens:     parsedResp.PromptEvalCount,
+			CompletionTokens: parsedResp.EvalCount,
+			TotalTokens:      parsedResp.PromptEvalCount + parsedResp.EvalCount,
+		},
+	}, nil
+}
+
+// GenerateStream implements LLMProvider. FarmOllama doesn't yet stream
+// incrementally across the pool, so it falls back to Generate and emits the
+// whole response as a single final chunk, same as any other non-streaming
+// LLMProvider.
+func (f *FarmOllama) GenerateStream(ctx context.Context, request LLMRequest) (<-chan LLMChunk, error) {
+	return generateStreamFallback(ctx, f.Generate, request)
+}
+
+// FarmStatusResponse is the body of GET /admin/farm.
+type FarmStatusResponse struct {
+	Endpoints []FarmEndpointStatus `json:"endpoints"`
+	Policy    FarmPolicy           `json:"policy"`
+}
+
+// FarmAdminHandler reports health and inflight state for every endpoint in
+// f, so an operator can check capacity before pinning traffic at a group
+// with Where.
+func (f *FarmOllama) FarmAdminHandler(w http.ResponseWriter, r *http.Request) {
+	statuses := make([]FarmEndpointStatus, len(f.endpoints))
+	for i, state := range f.endpoints {
+		statuses[i] = state.status()
+	}
+
+	response := FarmStatusResponse{Endpoints: statuses, Policy: f.policy}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
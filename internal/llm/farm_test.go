@@ -0,0 +1,150 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTagsServer returns a test server whose /api/tags reports modelsLoaded,
+// and whose /api/chat responds with reply (or a 500 if reply is empty).
+func newTagsServer(t *testing.T, modelsLoaded []string, reply string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tags":
+			models := make([]map[string]string, len(modelsLoaded))
+			for i, name := range modelsLoaded {
+				models[i] = map[string]string{"name": name}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"models": models})
+		case "/api/chat":
+			if reply == "" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"message": map[string]interface{}{"role": "assistant", "content": reply},
+				"done":    true,
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestFarmOllama_HealthCheckMarksMissingModelUnhealthy(t *testing.T) {
+	server := newTagsServer(t, []string{"other-model"}, "hi")
+	defer server.Close()
+
+	farm := NewFarmOllama([]FarmEndpoint{{BaseURL: server.URL, Model: "wanted-model"}}, RoundRobin, 0)
+	farm.checkAll()
+
+	statuses := farm.endpoints[0].status()
+	if statuses.Healthy {
+		t.Errorf("expected endpoint without the wanted model loaded to be unhealthy")
+	}
+}
+
+func TestFarmOllama_HealthCheckMarksLoadedModelHealthy(t *testing.T) {
+	server := newTagsServer(t, []string{"wanted-model"}, "hi")
+	defer server.Close()
+
+	farm := NewFarmOllama([]FarmEndpoint{{BaseURL: server.URL, Model: "wanted-model"}}, RoundRobin, 0)
+	farm.checkAll()
+
+	if !farm.endpoints[0].status().Healthy {
+		t.Errorf("expected endpoint with the wanted model loaded to be healthy")
+	}
+}
+
+func TestFarmOllama_GenerateRoutesToHealthyEndpoint(t *testing.T) {
+	dead := newTagsServer(t, []string{"model-a"}, "")
+	defer dead.Close()
+	alive := newTagsServer(t, []string{"model-a"}, "from the alive endpoint")
+	defer alive.Close()
+
+	farm := NewFarmOllama([]FarmEndpoint{
+		{BaseURL: dead.URL, Model: "model-a", Priority: 1},
+		{BaseURL: alive.URL, Model: "model-a", Priority: 0},
+	}, RoundRobin, 0)
+	farm.checkAll()
+	// Manually mark dead unhealthy since its /api/chat (not /api/tags) is what fails.
+	farm.recordHealth(farm.endpoints[0], false, context.DeadlineExceeded)
+
+	response, err := farm.Generate(context.Background(), LLMRequest{Prompt: "hello"})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if response.Response != "from the alive endpoint" {
+		t.Errorf("expected response from the alive endpoint, got %q", response.Response)
+	}
+}
+
+func TestFarmOllama_GenerateRetriesOnNextEndpointAfterFailure(t *testing.T) {
+	failing := newTagsServer(t, []string{"model-a"}, "")
+	defer failing.Close()
+	working := newTagsServer(t, []string{"model-a"}, "second endpoint succeeded")
+	defer working.Close()
+
+	farm := NewFarmOllama([]FarmEndpoint{
+		{BaseURL: failing.URL, Model: "model-a"},
+		{BaseURL: working.URL, Model: "model-a"},
+	}, PriorityFirst, 1)
+
+	response, err := farm.Generate(context.Background(), LLMRequest{Prompt: "hello"})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if response.Response != "second endpoint succeeded" {
+		t.Errorf("expected retry to land on the working endpoint, got %q", response.Response)
+	}
+}
+
+func TestFarmOllama_GenerateWhereFiltersBySelector(t *testing.T) {
+	groupA := newTagsServer(t, []string{"model-a"}, "from group a")
+	defer groupA.Close()
+	groupB := newTagsServer(t, []string{"model-a"}, "from group b")
+	defer groupB.Close()
+
+	farm := NewFarmOllama([]FarmEndpoint{
+		{BaseURL: groupA.URL, Model: "model-a", Group: "a"},
+		{BaseURL: groupB.URL, Model: "model-a", Group: "b"},
+	}, RoundRobin, 0)
+
+	response, err := farm.GenerateWhere(context.Background(), LLMRequest{Prompt: "hello"}, Where{Group: "b"})
+	if err != nil {
+		t.Fatalf("GenerateWhere: %v", err)
+	}
+	if response.Response != "from group b" {
+		t.Errorf("expected selector to pin the request to group b, got %q", response.Response)
+	}
+}
+
+func TestFarmOllama_GenerateWhereNoMatchReturnsError(t *testing.T) {
+	server := newTagsServer(t, []string{"model-a"}, "hi")
+	defer server.Close()
+
+	farm := NewFarmOllama([]FarmEndpoint{{BaseURL: server.URL, Model: "model-a", Group: "a"}}, RoundRobin, 0)
+
+	_, err := farm.GenerateWhere(context.Background(), LLMRequest{Prompt: "hello"}, Where{Group: "nonexistent"})
+	if err == nil {
+		t.Fatalf("expected an error when no endpoint matches the selector")
+	}
+}
+
+func TestFarmOllama_StartStopRunsHealthChecks(t *testing.T) {
+	server := newTagsServer(t, []string{"model-a"}, "hi")
+	defer server.Close()
+
+	farm := NewFarmOllama([]FarmEndpoint{{BaseURL: server.URL, Model: "model-a"}}, RoundRobin, 0)
+	farm.Start(10 * time.Millisecond)
+	defer farm.Stop()
+
+	if !farm.endpoints[0].status().Healthy {
+		t.Errorf("expected Start's initial synchronous check to mark the endpoint healthy")
+	}
+}
@@ -0,0 +1,29 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ProvidersHealthResponse represents the response from GET /health/providers.
+type ProvidersHealthResponse struct {
+	Providers []CircuitStatus `json:"providers"`
+	Count     int             `json:"count"`
+	Success   bool            `json:"success"`
+}
+
+// HealthHandler handles GET /health/providers, reporting the circuit state,
+// consecutive failure count, and last-error timestamp for every LLM provider
+// wrapped in a CircuitBreaker.
+func HealthHandler(w http.ResponseWriter, r *http.Request) {
+	providers := ProviderHealthSnapshot()
+
+	response := ProvidersHealthResponse{
+		Providers: providers,
+		Count:     len(providers),
+		Success:   true,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -1,15 +1,99 @@
 package llm
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"math/rand"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/piercegov/llm-npc-backend/internal/logging"
 )
 
+// unixSocketScheme is the BaseURL prefix NewLMStudio recognizes to dial a
+// Unix domain socket instead of TCP, e.g. "unix:///tmp/lmstudio.sock", for
+// model runners reachable on the same host without exposing a TCP port.
+const unixSocketScheme = "unix://"
+
+// unixSocketHost is the placeholder host used in request URLs once a
+// Unix socket transport is wired up: the socket path already pins the
+// destination, so the request line reads "http://unix/v1/..." rather than
+// embedding the path twice.
+const unixSocketHost = "http://unix"
+
+// newUnixSocketTransport returns an http.Transport whose DialContext ignores
+// the network/address net/http derives from the request URL and always
+// dials socketPath over a Unix domain socket instead.
+func newUnixSocketTransport(socketPath string) *http.Transport {
+	return &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}
+}
+
+// defaultLMStudioTimeout is what NewLMStudio uses when given a zero or
+// negative timeout, matching the default applied to Ollama via
+// cfg.Config.LLMTimeout.
+const defaultLMStudioTimeout = 30 * time.Second
+
+// RetryPolicy configures LMStudio.Generate's own retry loop. This is separate
+// from the Retrier NewProvider wraps every provider with: that one retries a
+// whole Generate call as a black box, while RetryPolicy operates inside
+// Generate, where it can inspect HTTP status codes and Retry-After headers
+// before deciding whether and how long to wait before the next attempt.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Jitter scales each computed delay by a random factor in
+	// [1-Jitter, 1+Jitter]. Zero disables jitter.
+	Jitter float64
+}
+
+// defaultRetryPolicy is used when LMStudio.Retry is left zero-valued.
+var defaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+	Jitter:      0.2,
+}
+
+// backoff computes the delay before retry attempt's next try (1-indexed):
+// BaseDelay * 2^(attempt-1), capped at MaxDelay, then jittered.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = defaultRetryPolicy.BaseDelay
+	}
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryPolicy.MaxDelay
+	}
+
+	delay := float64(base) * math.Pow(2, float64(attempt-1))
+	if delay <= 0 || delay > float64(maxDelay) {
+		delay = float64(maxDelay)
+	}
+
+	if p.Jitter > 0 {
+		factor := 1 - p.Jitter + rand.Float64()*2*p.Jitter
+		delay *= factor
+	}
+
+	return time.Duration(delay)
+}
+
 // lmStudioMessage represents a message in the LM Studio chat format
 type lmStudioMessage struct {
 	Role    string `json:"role"`
@@ -78,22 +162,72 @@ type LMStudio struct {
 	BaseURL string
 	Model   string
 	APIKey  string
+	// Timeout bounds a non-streaming Generate call's entire HTTP round trip.
+	// It is deliberately not applied to GenerateStream's client, since
+	// http.Client.Timeout covers reading the response body too and would cut
+	// a long-lived stream short; streaming calls rely on ctx for that instead.
+	Timeout time.Duration
+	// Retry configures Generate's own retry loop. Zero-valued means
+	// defaultRetryPolicy, a sensible default for a locally-hosted model that
+	// may still be loading or mid-restart.
+	Retry RetryPolicy
+	// transport is set when BaseURL was given as a unix:// URL, routing every
+	// request through the Unix domain socket instead of TCP. Nil means the
+	// http.Client falls back to http.DefaultTransport.
+	transport http.RoundTripper
 }
 
-// NewLMStudio creates a new LM Studio provider instance
-func NewLMStudio(baseURL, model, apiKey string) *LMStudio {
+// LMStudioOption customizes an LMStudio returned by NewLMStudio.
+type LMStudioOption func(*LMStudio)
+
+// WithRetryPolicy overrides the default RetryPolicy Generate uses for 429s,
+// 5xxs, and network errors against this LM Studio instance.
+func WithRetryPolicy(policy RetryPolicy) LMStudioOption {
+	return func(l *LMStudio) {
+		l.Retry = policy
+	}
+}
+
+// NewLMStudio creates a new LM Studio provider instance. baseURL is normally
+// an http(s):// URL, but a unix:///path/to/socket URL dials that Unix domain
+// socket for every request instead, with the request line still reading
+// "http://unix/v1/chat/completions".
+func NewLMStudio(baseURL, model, apiKey string, timeout time.Duration, opts ...LMStudioOption) *LMStudio {
 	if apiKey == "" {
 		apiKey = "lm-studio" // Default API key for LM Studio
 	}
-	return &LMStudio{
+	if timeout <= 0 {
+		timeout = defaultLMStudioTimeout
+	}
+	l := &LMStudio{
 		BaseURL: baseURL,
 		Model:   model,
 		APIKey:  apiKey,
+		Timeout: timeout,
+		Retry:   defaultRetryPolicy,
 	}
+	if socketPath, ok := strings.CutPrefix(baseURL, unixSocketScheme); ok {
+		l.BaseURL = unixSocketHost
+		l.transport = newUnixSocketTransport(socketPath)
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// httpClient builds the *http.Client for a single request, applying timeout
+// and routing through l.transport (set when BaseURL is a unix:// socket
+// path) if configured. A zero timeout leaves the client unbounded, matching
+// the existing rationale on GenerateStream and TTS: ctx bounds those calls
+// instead, since http.Client.Timeout would cut a long response short.
+func (l *LMStudio) httpClient(timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: l.transport}
 }
 
-// Generate implements the LLMProvider interface
-func (l *LMStudio) Generate(request LLMRequest) (LLMResponse, error) {
+// buildLMStudioRequest translates an LLMRequest into the LM Studio chat
+// completion payload shared by Generate and GenerateStream.
+func (l *LMStudio) buildLMStudioRequest(request LLMRequest) lmStudioRequest {
 	// Build messages array
 	messages := []lmStudioMessage{}
 
@@ -157,6 +291,16 @@ func (l *LMStudio) Generate(request LLMRequest) (LLMResponse, error) {
 		lmReq.Tools = tools
 	}
 
+	return lmReq
+}
+
+// Generate implements the LLMProvider interface. On a 429, 5xx, or network
+// error it retries per l.Retry, honoring a Retry-After header when the
+// server sends one; a 400/401/404 short-circuits immediately since retrying
+// a malformed request or bad model name can never succeed.
+func (l *LMStudio) Generate(ctx context.Context, request LLMRequest) (LLMResponse, error) {
+	lmReq := l.buildLMStudioRequest(request)
+
 	// Marshal request
 	jsonBody, err := json.Marshal(lmReq)
 	if err != nil {
@@ -168,75 +312,201 @@ func (l *LMStudio) Generate(request LLMRequest) (LLMResponse, error) {
 	logging.Info("Sending request to LM Studio",
 		"base_url", l.BaseURL,
 		"model", l.Model,
+		"request_id", logging.RequestIDFromContext(ctx),
 		"system_prompt_length", len(request.SystemPrompt),
 		"user_prompt_length", len(request.Prompt),
-		"tools_count", len(tools),
+		"tools_count", len(lmReq.Tools),
 	)
 	logging.Debug("LM Studio request details",
 		"system_prompt", request.SystemPrompt,
 		"user_prompt", request.Prompt,
 	)
 
-	// Create HTTP request
-	httpReq, err := http.NewRequest("POST", l.BaseURL+"/v1/chat/completions", bytes.NewBuffer(jsonBody))
+	policy := l.Retry
+	if policy.MaxAttempts <= 0 {
+		policy = defaultRetryPolicy
+	}
+
+	start := time.Now()
+	var lastStatus int
+
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		statusCode, retryAfter, body, reqErr := l.doGenerateRequest(ctx, jsonBody)
+		metrics := &RetryMetrics{Attempts: attempt, LastStatusCode: statusCode, TotalLatency: time.Since(start)}
+
+		if reqErr != nil {
+			logging.Error("Failed to send request to LM Studio", "error", reqErr, "attempt", attempt)
+			if attempt == policy.MaxAttempts || !IsRetryable(reqErr) {
+				return LLMResponse{Retry: metrics}, reqErr
+			}
+			if waitErr := l.waitForRetry(ctx, policy.backoff(attempt)); waitErr != nil {
+				return LLMResponse{Retry: metrics}, classifyTransportError(ctx, "lmstudio", l.Model, waitErr)
+			}
+			continue
+		}
+
+		lastStatus = statusCode
+		if statusCode == http.StatusOK {
+			response, parseErr := l.parseGenerateResponse(body, statusCode)
+			if parseErr != nil {
+				return LLMResponse{Retry: metrics}, parseErr
+			}
+			response.Retry = metrics
+			return response, nil
+		}
+
+		logging.Error("LM Studio returned non-200 status",
+			"status_code", statusCode,
+			"body", string(body),
+			"attempt", attempt,
+			"request_id", logging.RequestIDFromContext(ctx),
+		)
+
+		statusErr := l.classifyGenerateStatus(statusCode, body, retryAfter)
+		if attempt == policy.MaxAttempts || !IsRetryable(statusErr) {
+			return LLMResponse{StatusCode: statusCode, Retry: metrics}, statusErr
+		}
+
+		delay := policy.backoff(attempt)
+		if statusErr.RetryAfter > delay {
+			delay = statusErr.RetryAfter
+		}
+		logging.Warn("Retrying LM Studio request after transient error",
+			"attempt", attempt,
+			"max_attempts", policy.MaxAttempts,
+			"delay", delay,
+			"error", statusErr,
+		)
+		if waitErr := l.waitForRetry(ctx, delay); waitErr != nil {
+			return LLMResponse{StatusCode: statusCode, Retry: metrics}, classifyTransportError(ctx, "lmstudio", l.Model, waitErr)
+		}
+	}
+
+	// Unreachable: every loop iteration returns on its final attempt.
+	return LLMResponse{StatusCode: lastStatus}, fmt.Errorf("lmstudio: exhausted retries without a result")
+}
+
+// doGenerateRequest performs a single HTTP attempt against
+// /v1/chat/completions, returning the status code, any Retry-After header
+// value, and the response body. A non-nil error means the request never got
+// a response (already classified via classifyTransportError).
+func (l *LMStudio) doGenerateRequest(ctx context.Context, jsonBody []byte) (statusCode int, retryAfter string, body []byte, err error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", l.BaseURL+"/v1/chat/completions", bytes.NewBuffer(jsonBody))
 	if err != nil {
 		logging.Error("Failed to create HTTP request", "error", err)
-		return LLMResponse{}, err
+		return 0, "", nil, err
 	}
-
-	// Set headers
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+l.APIKey)
+	if requestID := logging.RequestIDFromContext(ctx); requestID != "" {
+		httpReq.Header.Set("X-Request-ID", requestID)
+	}
 
-	// Send request
-	client := &http.Client{}
+	client := l.httpClient(l.Timeout)
 	resp, err := client.Do(httpReq)
 	if err != nil {
-		logging.Error("Failed to send request to LM Studio", "error", err)
-		return LLMResponse{}, err
+		return 0, "", nil, classifyTransportError(ctx, "lmstudio", l.Model, err)
 	}
 	defer resp.Body.Close()
 
-	// Read response
-	body, err := io.ReadAll(resp.Body)
+	body, err = io.ReadAll(resp.Body)
 	if err != nil {
 		logging.Error("Failed to read response body", "error", err)
-		return LLMResponse{}, err
+		return resp.StatusCode, "", nil, err
 	}
+	return resp.StatusCode, resp.Header.Get("Retry-After"), body, nil
+}
 
-	// Check for non-200 status
-	if resp.StatusCode != http.StatusOK {
-		logging.Error("LM Studio returned non-200 status",
-			"status_code", resp.StatusCode,
-			"body", string(body),
-		)
-		return LLMResponse{
-			StatusCode: resp.StatusCode,
-			Response:   fmt.Sprintf("LM Studio error: %s", string(body)),
-		}, nil
+// classifyGenerateStatus maps a non-200 /v1/chat/completions response to a
+// *ProviderError, mirroring Ollama's status-code switch, with a Retry-After
+// hint attached for 429s so the retry loop waits at least that long.
+func (l *LMStudio) classifyGenerateStatus(statusCode int, body []byte, retryAfterHeader string) *ProviderError {
+	var baseErr error
+	var message string
+	switch statusCode {
+	case http.StatusBadRequest:
+		baseErr = ErrBadRequest
+		message = fmt.Sprintf("invalid request: %s", string(body))
+	case http.StatusUnauthorized:
+		baseErr = ErrUnauthorized
+		message = "authentication failed"
+	case http.StatusNotFound:
+		baseErr = ErrModelNotFound
+		message = fmt.Sprintf("model '%s' not found", l.Model)
+	case http.StatusTooManyRequests:
+		baseErr = ErrRateLimited
+		message = "rate limited"
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		baseErr = ErrTimeout
+		message = "request timed out"
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
+		baseErr = ErrProviderUnavailable
+		message = "LM Studio service unavailable"
+	default:
+		baseErr = fmt.Errorf("unexpected status code: %d", statusCode)
+		message = string(body)
 	}
 
-	// Parse response
+	statusErr := NewProviderError("lmstudio", l.Model, baseErr, message)
+	if statusCode == http.StatusTooManyRequests {
+		statusErr.RetryAfter = parseRetryAfterHeader(retryAfterHeader)
+	}
+	return statusErr
+}
+
+// waitForRetry sleeps for delay, returning ctx.Err() early if ctx is
+// canceled or expires first.
+func (l *LMStudio) waitForRetry(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+// parseRetryAfterHeader parses a Retry-After header value, which per RFC
+// 9110 is either a delay in seconds or an HTTP-date. An unparsable or
+// already-past value yields zero, leaving the caller to fall back to its
+// own computed backoff.
+func parseRetryAfterHeader(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(when); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+// parseGenerateResponse unmarshals a successful /v1/chat/completions body
+// into an LLMResponse, extracting any tool calls the model made.
+func (l *LMStudio) parseGenerateResponse(body []byte, statusCode int) (LLMResponse, error) {
 	var lmResp lmStudioResponse
 	if err := json.Unmarshal(body, &lmResp); err != nil {
 		logging.Error("Failed to unmarshal LM Studio response", "error", err, "body", string(body))
 		return LLMResponse{}, err
 	}
 
-	// Extract response content and tool calls
 	if len(lmResp.Choices) == 0 {
-		return LLMResponse{
-			StatusCode: resp.StatusCode,
-			Response:   "",
-		}, nil
+		return LLMResponse{StatusCode: statusCode}, nil
 	}
 
 	choice := lmResp.Choices[0]
 
-	// Extract tool uses
 	var toolUses []ToolUse
 	for _, toolCall := range choice.Message.ToolCalls {
-		// Parse arguments JSON
 		var args map[string]interface{}
 		if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
 			logging.Error("Failed to parse tool call arguments",
@@ -253,9 +523,8 @@ func (l *LMStudio) Generate(request LLMRequest) (LLMResponse, error) {
 		})
 	}
 
-	// Log response details
 	logging.Info("Received response from LM Studio",
-		"status_code", resp.StatusCode,
+		"status_code", statusCode,
 		"content_length", len(choice.Message.Content),
 		"tool_calls", len(toolUses),
 		"finish_reason", choice.FinishReason,
@@ -263,8 +532,441 @@ func (l *LMStudio) Generate(request LLMRequest) (LLMResponse, error) {
 	)
 
 	return LLMResponse{
-		StatusCode: resp.StatusCode,
+		StatusCode: statusCode,
 		Response:   choice.Message.Content,
 		ToolUses:   toolUses,
+		Provider:   "lmstudio",
+		Usage: &LLMUsage{
+			PromptTokens:     lmResp.Usage.PromptTokens,
+			CompletionTokens: lmResp.Usage.CompletionTokens,
+			TotalTokens:      lmResp.Usage.TotalTokens,
+		},
+	}, nil
+}
+
+// lmStudioStreamChunk is a single SSE "data:" payload from a streaming chat
+// completion request.
+type lmStudioStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content   string                   `json:"content"`
+			ToolCalls []lmStudioStreamToolCall `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// lmStudioStreamToolCall is one tool_calls[i] entry in a streaming delta.
+// Arguments arrive fragmented across chunks and must be concatenated by
+// Index before the accumulated string is valid JSON.
+type lmStudioStreamToolCall struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// streamingToolCall accumulates one tool call's id, name and fragmented
+// arguments across a stream, keyed by its Index.
+type streamingToolCall struct {
+	id        string
+	name      string
+	arguments strings.Builder
+}
+
+// GenerateStream implements the LLMProvider interface by requesting
+// stream: true and consuming the text/event-stream response, delivering one
+// LLMChunk per SSE "data:" line until "data: [DONE]" or an error.
+func (l *LMStudio) GenerateStream(ctx context.Context, request LLMRequest) (<-chan LLMChunk, error) {
+	lmReq := l.buildLMStudioRequest(request)
+	lmReq.Stream = true
+
+	jsonBody, err := json.Marshal(lmReq)
+	if err != nil {
+		logging.Error("Failed to marshal LM Studio stream request", "error", err)
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", l.BaseURL+"/v1/chat/completions", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		logging.Error("Failed to create HTTP request", "error", err)
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+l.APIKey)
+	if requestID := logging.RequestIDFromContext(ctx); requestID != "" {
+		httpReq.Header.Set("X-Request-ID", requestID)
+	}
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	logging.Info("Streaming request to LM Studio",
+		"base_url", l.BaseURL,
+		"model", l.Model,
+		"tools_count", len(lmReq.Tools),
+	)
+
+	// No client-side Timeout here: ctx is what bounds a streaming call, since
+	// http.Client.Timeout covers the whole response body read and would cut
+	// a slow-but-healthy stream off mid-flight.
+	client := l.httpClient(0)
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		logging.Error("Failed to send streaming request to LM Studio", "error", err)
+		return nil, classifyTransportError(ctx, "lmstudio", l.Model, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		logging.Error("LM Studio returned non-200 status for streaming request",
+			"status_code", resp.StatusCode,
+			"body", string(body),
+		)
+		return nil, fmt.Errorf("LM Studio stream error (status %d): %s", resp.StatusCode, string(body))
+	}
+
+	chunks := make(chan LLMChunk)
+	go l.consumeStream(ctx, resp.Body, chunks)
+	return chunks, nil
+}
+
+// consumeStream reads an LM Studio SSE response body line by line, assembling
+// tool call arguments per index and emitting one LLMChunk per delta. It
+// closes body and chunks before returning, and stops early if ctx is
+// canceled so a dropped caller doesn't leak the goroutine or socket.
+func (l *LMStudio) consumeStream(ctx context.Context, body io.ReadCloser, chunks chan<- LLMChunk) {
+	defer close(chunks)
+	defer body.Close()
+
+	var content strings.Builder
+	toolCalls := make(map[int]*streamingToolCall)
+	var toolOrder []int
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		payload := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if payload == "" {
+			continue
+		}
+		if payload == "[DONE]" {
+			return
+		}
+
+		var streamChunk lmStudioStreamChunk
+		if err := json.Unmarshal([]byte(payload), &streamChunk); err != nil {
+			logging.Error("Failed to unmarshal LM Studio stream chunk", "error", err, "payload", payload)
+			chunks <- LLMChunk{Err: err}
+			return
+		}
+
+		if len(streamChunk.Choices) == 0 {
+			continue
+		}
+		choice := streamChunk.Choices[0]
+
+		var toolCallDelta *ToolCallDelta
+		for _, tc := range choice.Delta.ToolCalls {
+			call, ok := toolCalls[tc.Index]
+			if !ok {
+				call = &streamingToolCall{id: tc.ID}
+				toolCalls[tc.Index] = call
+				toolOrder = append(toolOrder, tc.Index)
+			}
+			if tc.Function.Name != "" {
+				call.name = tc.Function.Name
+			}
+			call.arguments.WriteString(tc.Function.Arguments)
+			toolCallDelta = &ToolCallDelta{
+				ID:           call.id,
+				Name:         tc.Function.Name,
+				ArgsFragment: tc.Function.Arguments,
+			}
+		}
+
+		if choice.Delta.Content != "" {
+			content.WriteString(choice.Delta.Content)
+		}
+
+		chunks <- LLMChunk{
+			Content:       content.String(),
+			Delta:         choice.Delta.Content,
+			ToolUses:      assembleStreamedToolUses(toolOrder, toolCalls),
+			ToolCallDelta: toolCallDelta,
+			FinishReason:  choice.FinishReason,
+			Usage:         lmStudioStreamUsage(streamChunk.Usage),
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			logging.Warn("LM Studio stream canceled", "error", ctxErr)
+			chunks <- LLMChunk{Err: ctxErr}
+			return
+		}
+		logging.Error("Error reading LM Studio stream", "error", err)
+		chunks <- LLMChunk{Err: err}
+	}
+}
+
+// assembleStreamedToolUses parses each tool call's arguments accumulated so
+// far, in first-seen order, skipping any whose arguments aren't yet valid
+// JSON (they'll appear once a later chunk completes them).
+func assembleStreamedToolUses(order []int, calls map[int]*streamingToolCall) []ToolUse {
+	var uses []ToolUse
+	for _, index := range order {
+		call := calls[index]
+		var args map[string]interface{}
+		if err := json.Unmarshal([]byte(call.arguments.String()), &args); err != nil {
+			continue
+		}
+		uses = append(uses, ToolUse{ToolName: call.name, ToolArgs: args})
+	}
+	return uses
+}
+
+// lmStudioStreamUsage converts the optional usage block on a stream chunk
+// (only the final chunk typically carries one) to an *LLMUsage.
+func lmStudioStreamUsage(usage *struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}) *LLMUsage {
+	if usage == nil {
+		return nil
+	}
+	return &LLMUsage{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+	}
+}
+
+// lmStudioEmbedRequest is the request payload for LM Studio's
+// OpenAI-compatible /v1/embeddings endpoint.
+type lmStudioEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// lmStudioEmbedResponse is the response from /v1/embeddings.
+type lmStudioEmbedResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+	Usage struct {
+		PromptTokens int `json:"prompt_tokens"`
+		TotalTokens  int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// Embed implements Embedder against LM Studio's /v1/embeddings endpoint.
+func (l *LMStudio) Embed(ctx context.Context, request EmbedRequest) (EmbedResponse, error) {
+	jsonBody, err := json.Marshal(lmStudioEmbedRequest{Model: l.Model, Input: request.Input})
+	if err != nil {
+		logging.Error("Failed to marshal LM Studio embed request", "error", err)
+		return EmbedResponse{}, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", l.BaseURL+"/v1/embeddings", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		logging.Error("Failed to create HTTP request", "error", err)
+		return EmbedResponse{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+l.APIKey)
+	if requestID := logging.RequestIDFromContext(ctx); requestID != "" {
+		httpReq.Header.Set("X-Request-ID", requestID)
+	}
+
+	client := l.httpClient(l.Timeout)
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		logging.Error("Failed to send embed request to LM Studio", "error", err)
+		return EmbedResponse{}, classifyTransportError(ctx, "lmstudio", l.Model, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logging.Error("Failed to read embed response body", "error", err)
+		return EmbedResponse{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		logging.Error("LM Studio returned non-200 status for embed request",
+			"status_code", resp.StatusCode,
+			"body", string(body),
+		)
+		return EmbedResponse{}, NewProviderError("lmstudio", l.Model, ErrProviderUnavailable, fmt.Sprintf("embed error (status %d): %s", resp.StatusCode, string(body)))
+	}
+
+	var lmResp lmStudioEmbedResponse
+	if err := json.Unmarshal(body, &lmResp); err != nil {
+		logging.Error("Failed to unmarshal LM Studio embed response", "error", err, "body", string(body))
+		return EmbedResponse{}, err
+	}
+
+	embeddings := make([][]float64, len(lmResp.Data))
+	for _, entry := range lmResp.Data {
+		if entry.Index < 0 || entry.Index >= len(embeddings) {
+			continue
+		}
+		embeddings[entry.Index] = entry.Embedding
+	}
+
+	return EmbedResponse{
+		Embeddings: embeddings,
+		Usage: &LLMUsage{
+			PromptTokens: lmResp.Usage.PromptTokens,
+			TotalTokens:  lmResp.Usage.TotalTokens,
+		},
 	}, nil
 }
+
+// lmStudioTranscriptionResponse is the response from /v1/audio/transcriptions.
+type lmStudioTranscriptionResponse struct {
+	Text string `json:"text"`
+}
+
+// Transcribe implements Transcriber against LM Studio's
+// /v1/audio/transcriptions endpoint, which expects a multipart/form-data
+// body rather than JSON.
+func (l *LMStudio) Transcribe(ctx context.Context, audio io.Reader, opts TranscribeOptions) (TranscribeResponse, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	filename := opts.Filename
+	if filename == "" {
+		filename = "audio.wav"
+	}
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return TranscribeResponse{}, err
+	}
+	if _, err := io.Copy(part, audio); err != nil {
+		return TranscribeResponse{}, err
+	}
+	if err := writer.WriteField("model", l.Model); err != nil {
+		return TranscribeResponse{}, err
+	}
+	if opts.Language != "" {
+		if err := writer.WriteField("language", opts.Language); err != nil {
+			return TranscribeResponse{}, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return TranscribeResponse{}, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", l.BaseURL+"/v1/audio/transcriptions", &body)
+	if err != nil {
+		logging.Error("Failed to create HTTP request", "error", err)
+		return TranscribeResponse{}, err
+	}
+	httpReq.Header.Set("Content-Type", writer.FormDataContentType())
+	httpReq.Header.Set("Authorization", "Bearer "+l.APIKey)
+	if requestID := logging.RequestIDFromContext(ctx); requestID != "" {
+		httpReq.Header.Set("X-Request-ID", requestID)
+	}
+
+	client := l.httpClient(l.Timeout)
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		logging.Error("Failed to send transcription request to LM Studio", "error", err)
+		return TranscribeResponse{}, classifyTransportError(ctx, "lmstudio", l.Model, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logging.Error("Failed to read transcription response body", "error", err)
+		return TranscribeResponse{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		logging.Error("LM Studio returned non-200 status for transcription request",
+			"status_code", resp.StatusCode,
+			"body", string(respBody),
+		)
+		return TranscribeResponse{}, NewProviderError("lmstudio", l.Model, ErrProviderUnavailable, fmt.Sprintf("transcription error (status %d): %s", resp.StatusCode, string(respBody)))
+	}
+
+	var lmResp lmStudioTranscriptionResponse
+	if err := json.Unmarshal(respBody, &lmResp); err != nil {
+		logging.Error("Failed to unmarshal LM Studio transcription response", "error", err, "body", string(respBody))
+		return TranscribeResponse{}, err
+	}
+
+	return TranscribeResponse{Text: lmResp.Text}, nil
+}
+
+// lmStudioTTSRequest is the request payload for /v1/audio/speech.
+type lmStudioTTSRequest struct {
+	Model          string `json:"model"`
+	Input          string `json:"input"`
+	Voice          string `json:"voice,omitempty"`
+	ResponseFormat string `json:"response_format,omitempty"`
+}
+
+// Synthesize implements Synthesizer against LM Studio's /v1/audio/speech
+// endpoint. The returned ReadCloser streams the raw audio bytes; callers must
+// close it.
+func (l *LMStudio) Synthesize(ctx context.Context, request TTSRequest) (io.ReadCloser, error) {
+	jsonBody, err := json.Marshal(lmStudioTTSRequest{
+		Model:          l.Model,
+		Input:          request.Input,
+		Voice:          request.Voice,
+		ResponseFormat: request.ResponseFormat,
+	})
+	if err != nil {
+		logging.Error("Failed to marshal LM Studio TTS request", "error", err)
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", l.BaseURL+"/v1/audio/speech", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		logging.Error("Failed to create HTTP request", "error", err)
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+l.APIKey)
+	if requestID := logging.RequestIDFromContext(ctx); requestID != "" {
+		httpReq.Header.Set("X-Request-ID", requestID)
+	}
+
+	// No client-side Timeout: synthesized audio can be large, and ctx is what
+	// bounds this call, matching GenerateStream's rationale.
+	client := l.httpClient(0)
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		logging.Error("Failed to send TTS request to LM Studio", "error", err)
+		return nil, classifyTransportError(ctx, "lmstudio", l.Model, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		logging.Error("LM Studio returned non-200 status for TTS request",
+			"status_code", resp.StatusCode,
+			"body", string(body),
+		)
+		return nil, NewProviderError("lmstudio", l.Model, ErrProviderUnavailable, fmt.Sprintf("TTS error (status %d): %s", resp.StatusCode, string(body)))
+	}
+
+	return resp.Body, nil
+}
@@ -1,15 +1,25 @@
 package llm
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/piercegov/llm-npc-backend/internal/logging"
 )
 
 func TestLMStudioGenerate(t *testing.T) {
+	const testRequestID = "req-test-12345"
+
 	// Create a test server to mock LM Studio API
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Verify the request
@@ -23,6 +33,10 @@ func TestLMStudioGenerate(t *testing.T) {
 			t.Errorf("Expected Authorization header with Bearer token, got %s", authHeader)
 		}
 
+		if gotRequestID := r.Header.Get("X-Request-ID"); gotRequestID != testRequestID {
+			t.Errorf("Expected X-Request-ID header %q, got %q", testRequestID, gotRequestID)
+		}
+
 		// Parse request body
 		var req lmStudioRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -75,7 +89,7 @@ func TestLMStudioGenerate(t *testing.T) {
 	defer server.Close()
 
 	// Create LM Studio provider with test server URL
-	provider := NewLMStudio(server.URL, "test-model", "test-api-key")
+	provider := NewLMStudio(server.URL, "test-model", "test-api-key", 0)
 
 	// Create test request
 	request := LLMRequest{
@@ -83,8 +97,10 @@ func TestLMStudioGenerate(t *testing.T) {
 		Prompt:       "Hello, world!",
 	}
 
-	// Generate response
-	response, err := provider.Generate(request)
+	// Generate response, with a request ID bound to the context the way
+	// RequestTracingMiddleware would for a real HTTP request.
+	ctx := logging.WithRequestID(context.Background(), testRequestID)
+	response, err := provider.Generate(ctx, request)
 	if err != nil {
 		t.Fatalf("Failed to generate response: %v", err)
 	}
@@ -154,7 +170,7 @@ func TestLMStudioGenerateWithTools(t *testing.T) {
 	defer server.Close()
 
 	// Create provider
-	provider := NewLMStudio(server.URL, "test-model", "test-api-key")
+	provider := NewLMStudio(server.URL, "test-model", "test-api-key", 0)
 
 	// Create request with tools
 	request := LLMRequest{
@@ -176,7 +192,7 @@ func TestLMStudioGenerateWithTools(t *testing.T) {
 	}
 
 	// Generate response
-	response, err := provider.Generate(request)
+	response, err := provider.Generate(context.Background(), request)
 	if err != nil {
 		t.Fatalf("Failed to generate response: %v", err)
 	}
@@ -196,31 +212,370 @@ func TestLMStudioGenerateWithTools(t *testing.T) {
 }
 
 func TestLMStudioGenerateError(t *testing.T) {
-	// Create a test server that returns an error
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		wantErr    error
+	}{
+		{name: "unauthorized", statusCode: http.StatusUnauthorized, body: "invalid api key", wantErr: ErrUnauthorized},
+		{name: "model not found", statusCode: http.StatusNotFound, body: "model not found", wantErr: ErrModelNotFound},
+		{name: "request timeout", statusCode: http.StatusRequestTimeout, body: "request timed out", wantErr: ErrTimeout},
+		{name: "rate limited", statusCode: http.StatusTooManyRequests, body: "rate limited", wantErr: ErrRateLimited},
+		{name: "internal server error", statusCode: http.StatusInternalServerError, body: "internal server error", wantErr: ErrProviderUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			// Fast retry policy with a single attempt so the test doesn't
+			// wait out backoff for the statuses IsRetryable considers
+			// transient (429, 5xx, timeouts) - retry behavior itself is
+			// covered by TestLMStudioGenerate_RetriesOnRateLimitThenSucceeds
+			// and TestLMStudioGenerate_ShortCircuitsOnBadRequest.
+			provider := NewLMStudio(server.URL, "test-model", "test-api-key", 0, WithRetryPolicy(RetryPolicy{
+				MaxAttempts: 1,
+				BaseDelay:   time.Millisecond,
+				MaxDelay:    5 * time.Millisecond,
+			}))
+
+			_, err := provider.Generate(context.Background(), LLMRequest{Prompt: "Test"})
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLMStudioGenerate_RetriesOnRateLimitThenSucceeds(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte("rate limited"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(lmStudioResponse{
+			Choices: []lmStudioChoice{{Message: struct {
+				Role      string             `json:"role"`
+				Content   string             `json:"content"`
+				ToolCalls []lmStudioToolCall `json:"tool_calls,omitempty"`
+			}{Role: "assistant", Content: "ok"}}},
+		})
+	}))
+	defer server.Close()
+
+	provider := NewLMStudio(server.URL, "test-model", "test-api-key", 0, WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+	}))
+
+	response, err := provider.Generate(context.Background(), LLMRequest{Prompt: "Test"})
+	if err != nil {
+		t.Fatalf("Generate() returned an unexpected error: %v", err)
+	}
+	if response.Response != "ok" {
+		t.Errorf("Response = %q, want %q", response.Response, "ok")
+	}
+	if response.Retry == nil || response.Retry.Attempts != 3 {
+		t.Errorf("Retry = %+v, want Attempts=3", response.Retry)
+	}
+	if attempts != 3 {
+		t.Errorf("server saw %d attempts, want 3", attempts)
+	}
+}
+
+func TestLMStudioGenerate_ShortCircuitsOnBadRequest(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("bad request"))
+	}))
+	defer server.Close()
+
+	provider := NewLMStudio(server.URL, "test-model", "test-api-key", 0, WithRetryPolicy(RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+	}))
+
+	_, err := provider.Generate(context.Background(), LLMRequest{Prompt: "Test"})
+	if !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("server saw %d attempts, want 1 (no retry on 400)", attempts)
+	}
+}
+
+func TestLMStudioGenerateStream_AssemblesDeltasAndToolCalls(t *testing.T) {
+	// Create a test server that streams an SSE response split across several
+	// chunks, including a tool call whose arguments arrive in two fragments.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req lmStudioRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to parse request body: %v", err)
+		}
+		if !req.Stream {
+			t.Errorf("Expected stream: true on streaming request")
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("test server does not support flushing")
+		}
+
+		lines := []string{
+			`{"choices":[{"delta":{"content":"Hel"}}]}`,
+			`{"choices":[{"delta":{"content":"lo"}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"test_tool","arguments":"{\"param1\""}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":":\"value1\"}"}}]},"finish_reason":"tool_calls"}]}`,
+			`{"choices":[{"delta":{},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":5,"completion_tokens":7,"total_tokens":12}}`,
+			"[DONE]",
+		}
+		for _, line := range lines {
+			fmt.Fprintf(w, "data: %s\n\n", line)
+			flusher.Flush()
+		}
+	}))
+	defer server.Close()
+
+	provider := NewLMStudio(server.URL, "test-model", "test-api-key", 0)
+	request := LLMRequest{Prompt: "Use the test tool."}
+
+	stream, err := provider.GenerateStream(context.Background(), request)
+	if err != nil {
+		t.Fatalf("GenerateStream returned error: %v", err)
+	}
+
+	var last LLMChunk
+	var sawToolUse bool
+	var toolCallDeltas []ToolCallDelta
+	for chunk := range stream {
+		if chunk.Err != nil {
+			t.Fatalf("unexpected chunk error: %v", chunk.Err)
+		}
+		if len(chunk.ToolUses) > 0 {
+			sawToolUse = true
+		}
+		if chunk.ToolCallDelta != nil {
+			toolCallDeltas = append(toolCallDeltas, *chunk.ToolCallDelta)
+		}
+		last = chunk
+	}
+
+	if last.Content != "Hello" {
+		t.Errorf("Expected accumulated content 'Hello', got %q", last.Content)
+	}
+	if !sawToolUse {
+		t.Error("Expected a chunk with an assembled tool use once arguments completed")
+	}
+	if last.Usage == nil || last.Usage.TotalTokens != 12 {
+		t.Errorf("Expected final usage with TotalTokens=12, got %+v", last.Usage)
+	}
+
+	if len(toolCallDeltas) != 2 {
+		t.Fatalf("Expected 2 tool call deltas (one per argument fragment), got %d: %+v", len(toolCallDeltas), toolCallDeltas)
+	}
+	if toolCallDeltas[0].ID != "call_1" || toolCallDeltas[0].Name != "test_tool" || toolCallDeltas[0].ArgsFragment != `{"param1"` {
+		t.Errorf("Unexpected first tool call delta: %+v", toolCallDeltas[0])
+	}
+	if toolCallDeltas[1].ID != "call_1" || toolCallDeltas[1].Name != "" || toolCallDeltas[1].ArgsFragment != `:"value1"}` {
+		t.Errorf("Unexpected second tool call delta: %+v", toolCallDeltas[1])
+	}
+}
+
+func TestLMStudioGenerateStream_NonOKStatus(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Internal server error"))
+		w.Write([]byte("boom"))
 	}))
 	defer server.Close()
 
-	// Create provider
-	provider := NewLMStudio(server.URL, "test-model", "test-api-key")
+	provider := NewLMStudio(server.URL, "test-model", "test-api-key", 0)
+	_, err := provider.GenerateStream(context.Background(), LLMRequest{Prompt: "Test"})
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+}
 
-	// Create request
-	request := LLMRequest{
-		Prompt: "Test",
+func TestLMStudioEmbed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("Expected path /v1/embeddings, got %s", r.URL.Path)
+		}
+
+		var req lmStudioEmbedRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("Failed to parse request body: %v", err)
+		}
+		if len(req.Input) != 2 {
+			t.Errorf("Expected 2 inputs, got %d", len(req.Input))
+		}
+
+		response := lmStudioEmbedResponse{
+			Data: []struct {
+				Index     int       `json:"index"`
+				Embedding []float64 `json:"embedding"`
+			}{
+				{Index: 1, Embedding: []float64{0.4, 0.5}},
+				{Index: 0, Embedding: []float64{0.1, 0.2, 0.3}},
+			},
+		}
+		response.Usage.PromptTokens = 4
+		response.Usage.TotalTokens = 4
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	provider := NewLMStudio(server.URL, "test-model", "test-api-key", 0)
+	resp, err := provider.Embed(context.Background(), EmbedRequest{Input: []string{"hello", "world"}})
+	if err != nil {
+		t.Fatalf("Embed() returned an unexpected error: %v", err)
 	}
 
-	// Generate response
-	_, err := provider.Generate(request)
+	if len(resp.Embeddings) != 2 {
+		t.Fatalf("Expected 2 embeddings, got %d", len(resp.Embeddings))
+	}
+	if len(resp.Embeddings[0]) != 3 || len(resp.Embeddings[1]) != 2 {
+		t.Errorf("Expected embeddings reordered by index, got %+v", resp.Embeddings)
+	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 4 {
+		t.Errorf("Expected usage with TotalTokens=4, got %+v", resp.Usage)
+	}
+}
 
-	// Should get an error
+func TestLMStudioTranscribe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" {
+			t.Errorf("Expected path /v1/audio/transcriptions, got %s", r.URL.Path)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatalf("Failed to parse multipart form: %v", err)
+		}
+		if r.FormValue("model") != "test-model" {
+			t.Errorf("Expected model 'test-model', got %s", r.FormValue("model"))
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("Expected a 'file' field, got error: %v", err)
+		}
+		defer file.Close()
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(lmStudioTranscriptionResponse{Text: "hello there"})
+	}))
+	defer server.Close()
+
+	provider := NewLMStudio(server.URL, "test-model", "test-api-key", 0)
+	resp, err := provider.Transcribe(context.Background(), strings.NewReader("fake audio bytes"), TranscribeOptions{Filename: "input.wav"})
+	if err != nil {
+		t.Fatalf("Transcribe() returned an unexpected error: %v", err)
+	}
+	if resp.Text != "hello there" {
+		t.Errorf("Expected transcribed text 'hello there', got %q", resp.Text)
+	}
+}
+
+func TestLMStudioSynthesize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/speech" {
+			t.Errorf("Expected path /v1/audio/speech, got %s", r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Write([]byte("fake-audio-bytes"))
+	}))
+	defer server.Close()
+
+	provider := NewLMStudio(server.URL, "test-model", "test-api-key", 0)
+	audio, err := provider.Synthesize(context.Background(), TTSRequest{Input: "Hello there", ResponseFormat: "mp3"})
+	if err != nil {
+		t.Fatalf("Synthesize() returned an unexpected error: %v", err)
+	}
+	defer audio.Close()
+
+	data, err := io.ReadAll(audio)
+	if err != nil {
+		t.Fatalf("Failed to read synthesized audio: %v", err)
+	}
+	if string(data) != "fake-audio-bytes" {
+		t.Errorf("Expected synthesized audio bytes, got %q", string(data))
+	}
+}
+
+func TestLMStudioSynthesize_NonOKStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("boom"))
+	}))
+	defer server.Close()
+
+	provider := NewLMStudio(server.URL, "test-model", "test-api-key", 0)
+	_, err := provider.Synthesize(context.Background(), TTSRequest{Input: "Test"})
 	if err == nil {
 		t.Fatal("Expected error, got nil")
 	}
+}
+
+func TestLMStudioGenerate_UnixSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "lmstudio.sock")
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("Expected path /v1/chat/completions, got %s", r.URL.Path)
+		}
+
+		response := lmStudioResponse{
+			Choices: []lmStudioChoice{
+				{
+					Message: struct {
+						Role      string             `json:"role"`
+						Content   string             `json:"content"`
+						ToolCalls []lmStudioToolCall `json:"tool_calls,omitempty"`
+					}{
+						Role:    "assistant",
+						Content: "Hello over the socket",
+					},
+					FinishReason: "stop",
+				},
+			},
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Failed to listen on Unix socket: %v", err)
+	}
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	provider := NewLMStudio("unix://"+socketPath, "test-model", "test-api-key", 0)
 
-	// Check that it's the right error type
-	if !errors.Is(err, ErrProviderUnavailable) {
-		t.Errorf("Expected ErrProviderUnavailable, got %v", err)
+	response, err := provider.Generate(context.Background(), LLMRequest{Prompt: "Hello, world!"})
+	if err != nil {
+		t.Fatalf("Generate() returned an unexpected error: %v", err)
+	}
+	if response.Response != "Hello over the socket" {
+		t.Errorf("Expected content %q, got %q", "Hello over the socket", response.Response)
 	}
 }
@@ -1,12 +1,17 @@
 package llm
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/piercegov/llm-npc-backend/internal/cfg"
 	"github.com/piercegov/llm-npc-backend/internal/logging"
@@ -54,14 +59,29 @@ type ollamaTool struct {
 
 type Ollama struct {
 	baseURL string
+
+	// toolModeCache remembers the ToolModeAuto probe result per model name,
+	// so repeated calls for the same model don't re-probe /api/show.
+	toolModeCache sync.Map
 }
 
 func NewOllama(baseURL string) *Ollama {
 	return &Ollama{baseURL: baseURL}
 }
 
-func (o *Ollama) Generate(request LLMRequest) (LLMResponse, error) {
-	// Transform Tool to Ollama-specific tool format
+// selectOllamaModel picks OllamaVisionModel instead of OllamaModel whenever
+// request carries Images, since chat and vision models are rarely the same.
+func selectOllamaModel(config cfg.Config, request LLMRequest) string {
+	if len(request.Images) > 0 && config.OllamaVisionModel != "" {
+		return config.OllamaVisionModel
+	}
+	return config.OllamaModel
+}
+
+// buildOllamaRequestMap converts an LLMRequest into Ollama's /api/chat
+// request shape, shared by Generate and GenerateStream so the two only
+// differ in "stream" and how they read the response body.
+func buildOllamaRequestMap(request LLMRequest, model string, stream bool) map[string]interface{} {
 	var formattedTools []ollamaTool
 	if len(request.Tools) > 0 {
 		formattedTools = make([]ollamaTool, len(request.Tools))
@@ -95,8 +115,6 @@ func (o *Ollama) Generate(request LLMRequest) (LLMResponse, error) {
 		}
 	}
 
-	ollamaModel := cfg.ReadConfig().OllamaModel
-
 	messages := []map[string]interface{}{}
 
 	// Add system message if provided
@@ -107,16 +125,25 @@ func (o *Ollama) Generate(request LLMRequest) (LLMResponse, error) {
 		})
 	}
 
-	// Add user message
-	messages = append(messages, map[string]interface{}{
+	// Add user message, attaching base64-encoded images for vision models
+	// when the request carries any (e.g. llava, llama3.2-vision, bakllava).
+	userMessage := map[string]interface{}{
 		"role":    "user",
 		"content": request.Prompt,
-	})
+	}
+	if len(request.Images) > 0 {
+		encodedImages := make([]string, len(request.Images))
+		for i, image := range request.Images {
+			encodedImages[i] = base64.StdEncoding.EncodeToString(image)
+		}
+		userMessage["images"] = encodedImages
+	}
+	messages = append(messages, userMessage)
 
 	requestMap := map[string]interface{}{
-		"model":    ollamaModel,
+		"model":    model,
 		"messages": messages,
-		"stream":   false,
+		"stream":   stream,
 	}
 
 	// Only add the "tools" field if there are formatted tools
@@ -124,6 +151,63 @@ func (o *Ollama) Generate(request LLMRequest) (LLMResponse, error) {
 		requestMap["tools"] = formattedTools
 	}
 
+	switch format := request.ResponseFormat.(type) {
+	case jsonResponseFormat:
+		requestMap["format"] = "json"
+	case FormatJSONSchema:
+		var schemaValue interface{}
+		if err := json.Unmarshal(format.Schema, &schemaValue); err == nil {
+			requestMap["format"] = schemaValue
+		}
+	}
+
+	return requestMap
+}
+
+// applyResponseFormat trims Ollama's message content (its JSON mode is known
+// to sometimes emit stray leading/trailing whitespace that breaks a naive
+// json.Unmarshal) and, when format is FormatJSONSchema, validates the
+// trimmed content against the schema. It returns the trimmed content, a
+// Structured payload set whenever the trimmed content is valid JSON and a
+// structured format was requested, and a non-nil error only when a schema
+// was supplied and the content doesn't satisfy it.
+func applyResponseFormat(model string, format ResponseFormat, content string) (string, json.RawMessage, error) {
+	content = strings.TrimSpace(content)
+
+	schemaFormat, hasSchema := format.(FormatJSONSchema)
+	_, isJSON := format.(jsonResponseFormat)
+	if !hasSchema && !isJSON {
+		return content, nil, nil
+	}
+
+	if !json.Valid([]byte(content)) {
+		if hasSchema {
+			return content, nil, NewProviderError("ollama", model, ErrBadRequest, "response is not valid JSON")
+		}
+		return content, nil, nil
+	}
+
+	if hasSchema {
+		if err := validateJSONSchema(schemaFormat.Schema, []byte(content)); err != nil {
+			return content, nil, NewProviderError("ollama", model, ErrBadRequest, fmt.Sprintf("response does not match schema: %v", err))
+		}
+	}
+
+	return content, json.RawMessage(content), nil
+}
+
+func (o *Ollama) Generate(ctx context.Context, request LLMRequest) (LLMResponse, error) {
+	ollamaModel := selectOllamaModel(cfg.ReadConfig(), request)
+	toolMode := o.resolveToolMode(ctx, ollamaModel, request.ToolMode)
+
+	effectiveRequest := request
+	if toolMode == ToolModePromptInjected && len(request.Tools) > 0 {
+		effectiveRequest.SystemPrompt = strings.TrimSpace(effectiveRequest.SystemPrompt + "\n\n" + buildToolInjectionPrompt(request.Tools))
+		effectiveRequest.Tools = nil
+	}
+
+	requestMap := buildOllamaRequestMap(effectiveRequest, ollamaModel, false)
+
 	jsonBody, err := json.Marshal(requestMap)
 	if err != nil {
 		logging.Error("Failed to marshal Ollama request body", "error", err, "requestMap_keys", func() []string {
@@ -139,22 +223,27 @@ func (o *Ollama) Generate(request LLMRequest) (LLMResponse, error) {
 	// Log request in a more readable format
 	logging.Info("Sending request to Ollama",
 		"model", ollamaModel,
-		"system_prompt_length", len(request.SystemPrompt),
-		"user_prompt_length", len(request.Prompt),
-		"tools_count", len(formattedTools),
+		"request_id", logging.RequestIDFromContext(ctx),
+		"system_prompt_length", len(effectiveRequest.SystemPrompt),
+		"user_prompt_length", len(effectiveRequest.Prompt),
+		"tools_count", len(effectiveRequest.Tools),
+		"tool_mode", toolMode,
 	)
 	logging.Debug("Ollama request details",
-		"system_prompt", request.SystemPrompt,
-		"user_prompt", request.Prompt,
+		"system_prompt", effectiveRequest.SystemPrompt,
+		"user_prompt", effectiveRequest.Prompt,
 	)
 
-	httpRequest, err := http.NewRequest("POST", o.baseURL+"/api/chat", bytes.NewBuffer(jsonBody))
+	httpRequest, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/chat", bytes.NewBuffer(jsonBody))
 	if err != nil {
 		logging.Error("Error creating request", "error", err)
 		return LLMResponse{}, err
 	}
 
 	httpRequest.Header.Set("Content-Type", "application/json")
+	if requestID := logging.RequestIDFromContext(ctx); requestID != "" {
+		httpRequest.Header.Set("X-Request-ID", requestID)
+	}
 
 	// Create client with configurable timeout
 	config := cfg.ReadConfig()
@@ -165,11 +254,7 @@ func (o *Ollama) Generate(request LLMRequest) (LLMResponse, error) {
 
 	if err != nil {
 		logging.Error("Failed to send request to Ollama", "error", err)
-		// Check if it's a timeout
-		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-			return LLMResponse{}, NewProviderError("ollama", ollamaModel, ErrTimeout, "request timed out")
-		}
-		return LLMResponse{}, NewProviderError("ollama", ollamaModel, ErrProviderUnavailable, "failed to connect to Ollama")
+		return LLMResponse{}, classifyTransportError(ctx, "ollama", ollamaModel, err)
 	}
 
 	defer response.Body.Close()
@@ -186,35 +271,7 @@ func (o *Ollama) Generate(request LLMRequest) (LLMResponse, error) {
 			"status_code", response.StatusCode,
 			"body", string(body),
 		)
-
-		// Map status codes to appropriate errors
-		var baseErr error
-		var message string
-		switch response.StatusCode {
-		case http.StatusBadRequest:
-			baseErr = ErrBadRequest
-			message = "invalid request parameters"
-		case http.StatusUnauthorized:
-			baseErr = ErrUnauthorized  
-			message = "authentication failed"
-		case http.StatusNotFound:
-			baseErr = ErrModelNotFound
-			message = fmt.Sprintf("model '%s' not found", ollamaModel)
-		case http.StatusTooManyRequests:
-			baseErr = ErrRateLimited
-			message = "rate limit exceeded"
-		case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
-			baseErr = ErrProviderUnavailable
-			message = "Ollama service unavailable"
-		case http.StatusGatewayTimeout:
-			baseErr = ErrTimeout
-			message = "gateway timeout"
-		default:
-			baseErr = fmt.Errorf("unexpected status code: %d", response.StatusCode)
-			message = string(body)
-		}
-
-		return LLMResponse{}, NewProviderError("ollama", ollamaModel, baseErr, message)
+		return LLMResponse{}, classifyOllamaStatus(ollamaModel, response.StatusCode, body)
 	}
 
 	// Parse the full Ollama response
@@ -233,9 +290,426 @@ func (o *Ollama) Generate(request LLMRequest) (LLMResponse, error) {
 		}
 	}
 
+	// Models run in ToolModePromptInjected never populate tool_calls; a
+	// requested tool instead shows up as a JSON object in the content.
+	if toolMode == ToolModePromptInjected && len(toolUses) == 0 {
+		if toolUse, ok := parseInjectedToolCall(parsedResp.Message.Content); ok {
+			toolUses = append(toolUses, toolUse)
+		}
+	}
+
+	content, structured, err := applyResponseFormat(ollamaModel, request.ResponseFormat, parsedResp.Message.Content)
+	if err != nil {
+		return LLMResponse{}, err
+	}
+
 	return LLMResponse{
 		StatusCode: response.StatusCode,
-		Response:   parsedResp.Message.Content, // Extract only the content
+		Response:   content,
 		ToolUses:   toolUses,
+		Structured: structured,
+		Provider:   "ollama",
+		Usage: &LLMUsage{
+			PromptTokens:     parsedResp.PromptEvalCount,
+			CompletionTokens: parsedResp.EvalCount,
+			TotalTokens:      parsedResp.PromptEvalCount + parsedResp.EvalCount,
+		},
 	}, nil
 }
+
+// ollamaEmbedRequest is the request payload for Ollama's batch /api/embed endpoint.
+type ollamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// ollamaEmbedResponse is the response from /api/embed: one embedding per
+// entry in ollamaEmbedRequest.Input, in the same order.
+type ollamaEmbedResponse struct {
+	Embeddings      [][]float64 `json:"embeddings"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+}
+
+// Embed implements Embedder against Ollama's batch /api/embed endpoint,
+// using the embedding model configured separately from the chat model since
+// they're rarely the same (e.g. "qwen3:1.7b" for chat, "nomic-embed-text"
+// for embeddings).
+func (o *Ollama) Embed(ctx context.Context, request EmbedRequest) (EmbedResponse, error) {
+	embedModel := cfg.ReadConfig().OllamaEmbedModel
+
+	jsonBody, err := json.Marshal(ollamaEmbedRequest{Model: embedModel, Input: request.Input})
+	if err != nil {
+		logging.Error("Failed to marshal Ollama embed request", "error", err)
+		return EmbedResponse{}, err
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/embed", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		logging.Error("Error creating embed request", "error", err)
+		return EmbedResponse{}, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	if requestID := logging.RequestIDFromContext(ctx); requestID != "" {
+		httpRequest.Header.Set("X-Request-ID", requestID)
+	}
+
+	config := cfg.ReadConfig()
+	client := &http.Client{Timeout: config.LLMTimeout}
+	response, err := client.Do(httpRequest)
+	if err != nil {
+		logging.Error("Failed to send embed request to Ollama", "error", err)
+		return EmbedResponse{}, classifyTransportError(ctx, "ollama", embedModel, err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		logging.Error("Failed to read embed response body", "error", err)
+		return EmbedResponse{}, NewProviderError("ollama", embedModel, err, "failed to read response")
+	}
+
+	if response.StatusCode != http.StatusOK {
+		logging.Error("Ollama returned non-200 status for embed request",
+			"status_code", response.StatusCode,
+			"body", string(body),
+		)
+		return EmbedResponse{}, classifyOllamaStatus(embedModel, response.StatusCode, body)
+	}
+
+	var parsed ollamaEmbedResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		logging.Error("Failed to unmarshal Ollama embed response", "error", err, "body", string(body))
+		return EmbedResponse{}, NewProviderError("ollama", embedModel, err, "invalid response format")
+	}
+
+	return EmbedResponse{
+		Embeddings: parsed.Embeddings,
+		Usage:      &LLMUsage{PromptTokens: parsed.PromptEvalCount, TotalTokens: parsed.PromptEvalCount},
+	}, nil
+}
+
+// classifyOllamaStatus maps a non-200 Ollama response to the shared provider
+// error taxonomy, used by both Generate and GenerateStream.
+func classifyOllamaStatus(model string, statusCode int, body []byte) error {
+	var baseErr error
+	var message string
+	switch statusCode {
+	case http.StatusBadRequest:
+		baseErr = ErrBadRequest
+		message = "invalid request parameters"
+	case http.StatusUnauthorized:
+		baseErr = ErrUnauthorized
+		message = "authentication failed"
+	case http.StatusNotFound:
+		baseErr = ErrModelNotFound
+		message = fmt.Sprintf("model '%s' not found", model)
+	case http.StatusTooManyRequests:
+		baseErr = ErrRateLimited
+		message = "rate limit exceeded"
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
+		baseErr = ErrProviderUnavailable
+		message = "Ollama service unavailable"
+	case http.StatusGatewayTimeout:
+		baseErr = ErrTimeout
+		message = "gateway timeout"
+	default:
+		baseErr = fmt.Errorf("unexpected status code: %d", statusCode)
+		message = string(body)
+	}
+
+	return NewProviderError("ollama", model, baseErr, message)
+}
+
+// resolveToolMode turns an LLMRequest's ToolMode into a concrete choice
+// between ToolModeNative and ToolModePromptInjected, probing the model for
+// ToolModeAuto and defaulting the zero value to ToolModeNative.
+func (o *Ollama) resolveToolMode(ctx context.Context, model string, mode ToolMode) ToolMode {
+	switch mode {
+	case ToolModePromptInjected:
+		return ToolModePromptInjected
+	case ToolModeAuto:
+		return o.probeToolMode(ctx, model)
+	default:
+		return ToolModeNative
+	}
+}
+
+// probeToolMode returns the cached ToolModeAuto decision for model, probing
+// /api/show via detectToolMode on the first call for a given model name.
+func (o *Ollama) probeToolMode(ctx context.Context, model string) ToolMode {
+	if cached, ok := o.toolModeCache.Load(model); ok {
+		return cached.(ToolMode)
+	}
+
+	mode := o.detectToolMode(ctx, model)
+	o.toolModeCache.Store(model, mode)
+	return mode
+}
+
+// ollamaShowResponse is the subset of POST /api/show this package cares
+// about: whether the model declares native tool-calling support.
+type ollamaShowResponse struct {
+	Capabilities []string `json:"capabilities"`
+}
+
+// detectToolMode asks Ollama's /api/show whether model declares a "tools"
+// capability. Any failure to reach or parse /api/show is treated as "assume
+// no native tool support" (ToolModePromptInjected), the safer default since
+// a model silently ignoring the native tools field is indistinguishable
+// from one that never replies with tool calls.
+func (o *Ollama) detectToolMode(ctx context.Context, model string) ToolMode {
+	jsonBody, err := json.Marshal(map[string]string{"model": model})
+	if err != nil {
+		return ToolModePromptInjected
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/show", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return ToolModePromptInjected
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	response, err := client.Do(httpRequest)
+	if err != nil {
+		logging.Warn("Failed to probe Ollama model capabilities, defaulting to prompt-injected tools", "model", model, "error", err)
+		return ToolModePromptInjected
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return ToolModePromptInjected
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return ToolModePromptInjected
+	}
+
+	var parsed ollamaShowResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return ToolModePromptInjected
+	}
+
+	for _, capability := range parsed.Capabilities {
+		if capability == "tools" {
+			return ToolModeNative
+		}
+	}
+	return ToolModePromptInjected
+}
+
+// buildToolInjectionPrompt renders tools as a JSON-schema description plus
+// an instruction to reply with a {"tool": "<name>", "tool_input": {...}}
+// JSON object (or plain text if no tool applies), for models that ignore
+// the native tools request field.
+func buildToolInjectionPrompt(tools []Tool) string {
+	schemas := make([]map[string]interface{}, len(tools))
+	for i, tool := range tools {
+		properties := make(map[string]interface{})
+		var required []string
+		for name, param := range tool.Parameters {
+			properties[name] = map[string]interface{}{
+				"type":        string(param.Type),
+				"description": param.Description,
+			}
+			if param.Required {
+				required = append(required, name)
+			}
+		}
+
+		schemas[i] = map[string]interface{}{
+			"name":        tool.Name,
+			"description": tool.Description,
+			"parameters": map[string]interface{}{
+				"type":       "object",
+				"properties": properties,
+				"required":   required,
+			},
+		}
+	}
+
+	schemaJSON, err := json.MarshalIndent(schemas, "", "  ")
+	if err != nil {
+		schemaJSON = []byte("[]")
+	}
+
+	return fmt.Sprintf(
+		"You have access to the following tools:\n%s\n\n"+
+			`If you need to use a tool, reply with ONLY a JSON object of the form {"tool": "<name>", "tool_input": {...}} and nothing else. `+
+			"If no tool is needed, reply with plain text.",
+		string(schemaJSON),
+	)
+}
+
+// ollamaPromptInjectedToolCall is the JSON shape buildToolInjectionPrompt
+// asks a tool-incapable model to reply with.
+type ollamaPromptInjectedToolCall struct {
+	Tool      string                 `json:"tool"`
+	ToolInput map[string]interface{} `json:"tool_input"`
+}
+
+// parseInjectedToolCall extracts a ToolUse from content if it's a
+// {"tool": ..., "tool_input": {...}} JSON object, the reply format
+// buildToolInjectionPrompt requests from prompt-injected models. A
+// plain-text reply (no tool needed) returns ok == false, not an error.
+func parseInjectedToolCall(content string) (ToolUse, bool) {
+	trimmed := strings.TrimSpace(content)
+	if !strings.HasPrefix(trimmed, "{") {
+		return ToolUse{}, false
+	}
+
+	var parsed ollamaPromptInjectedToolCall
+	if err := json.Unmarshal([]byte(trimmed), &parsed); err != nil || parsed.Tool == "" {
+		return ToolUse{}, false
+	}
+
+	return ToolUse{ToolName: parsed.Tool, ToolArgs: parsed.ToolInput}, true
+}
+
+// GenerateStream implements the LLMProvider interface by requesting
+// stream: true and consuming the newline-delimited JSON objects Ollama's
+// /api/chat emits, one LLMChunk per incremental message.content delta.
+// Ollama includes tool_calls only on the final object (done: true).
+func (o *Ollama) GenerateStream(ctx context.Context, request LLMRequest) (<-chan LLMChunk, error) {
+	ollamaModel := selectOllamaModel(cfg.ReadConfig(), request)
+	toolMode := o.resolveToolMode(ctx, ollamaModel, request.ToolMode)
+
+	effectiveRequest := request
+	if toolMode == ToolModePromptInjected && len(request.Tools) > 0 {
+		effectiveRequest.SystemPrompt = strings.TrimSpace(effectiveRequest.SystemPrompt + "\n\n" + buildToolInjectionPrompt(request.Tools))
+		effectiveRequest.Tools = nil
+	}
+
+	requestMap := buildOllamaRequestMap(effectiveRequest, ollamaModel, true)
+
+	jsonBody, err := json.Marshal(requestMap)
+	if err != nil {
+		logging.Error("Failed to marshal Ollama stream request body", "error", err)
+		return nil, err
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/api/chat", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		logging.Error("Error creating streaming request", "error", err)
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	if requestID := logging.RequestIDFromContext(ctx); requestID != "" {
+		httpRequest.Header.Set("X-Request-ID", requestID)
+	}
+
+	logging.Info("Streaming request to Ollama",
+		"model", ollamaModel,
+		"request_id", logging.RequestIDFromContext(ctx),
+		"tools_count", len(effectiveRequest.Tools),
+		"tool_mode", toolMode,
+	)
+
+	// No client-side Timeout here, matching LMStudio's GenerateStream: ctx is
+	// what bounds a streaming call, since http.Client.Timeout covers the
+	// whole response body read and would cut a slow-but-healthy stream off
+	// mid-flight.
+	client := &http.Client{}
+	response, err := client.Do(httpRequest)
+	if err != nil {
+		logging.Error("Failed to send streaming request to Ollama", "error", err)
+		return nil, classifyTransportError(ctx, "ollama", ollamaModel, err)
+	}
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+		logging.Error("Ollama returned non-200 status for streaming request",
+			"status_code", response.StatusCode,
+			"body", string(body),
+		)
+		return nil, classifyOllamaStatus(ollamaModel, response.StatusCode, body)
+	}
+
+	chunks := make(chan LLMChunk)
+	go consumeOllamaStream(ctx, response.Body, chunks, toolMode, ollamaModel, request.ResponseFormat)
+	return chunks, nil
+}
+
+// consumeOllamaStream reads Ollama's newline-delimited JSON stream, emitting
+// one LLMChunk per line until the object with done: true or an error. It
+// closes body and chunks before returning, and stops early if ctx is
+// canceled so a dropped caller doesn't leak the goroutine or socket. When
+// toolMode is ToolModePromptInjected, the final chunk's ToolUses is parsed
+// from the accumulated content instead of Ollama's native tool_calls field.
+// On the final chunk, format is applied the same way Generate applies it to
+// a non-streamed response (trimmed content, schema-validated Structured).
+func consumeOllamaStream(ctx context.Context, body io.ReadCloser, chunks chan<- LLMChunk, toolMode ToolMode, model string, format ResponseFormat) {
+	defer close(chunks)
+	defer body.Close()
+
+	var content bytes.Buffer
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var parsed ollamaResponse
+		if err := json.Unmarshal(line, &parsed); err != nil {
+			logging.Error("Failed to unmarshal Ollama stream line", "error", err, "line", string(line))
+			chunks <- LLMChunk{Err: err}
+			return
+		}
+
+		content.WriteString(parsed.Message.Content)
+
+		chunk := LLMChunk{
+			Content: content.String(),
+			Delta:   parsed.Message.Content,
+		}
+
+		if parsed.Done {
+			chunk.FinishReason = parsed.DoneReason
+			chunk.Usage = &LLMUsage{
+				PromptTokens:     parsed.PromptEvalCount,
+				CompletionTokens: parsed.EvalCount,
+				TotalTokens:      parsed.PromptEvalCount + parsed.EvalCount,
+			}
+			if len(parsed.Message.ToolCalls) > 0 {
+				toolUses := make([]ToolUse, len(parsed.Message.ToolCalls))
+				for i, call := range parsed.Message.ToolCalls {
+					toolUses[i] = ToolUse{ToolName: call.Function.Name, ToolArgs: call.Function.Arguments}
+				}
+				chunk.ToolUses = toolUses
+			} else if toolMode == ToolModePromptInjected {
+				if toolUse, ok := parseInjectedToolCall(chunk.Content); ok {
+					chunk.ToolUses = []ToolUse{toolUse}
+				}
+			}
+
+			trimmedContent, structured, err := applyResponseFormat(model, format, chunk.Content)
+			if err != nil {
+				chunks <- LLMChunk{Err: err}
+				return
+			}
+			chunk.Content = trimmedContent
+			chunk.Structured = structured
+		}
+
+		chunks <- chunk
+
+		if parsed.Done {
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			logging.Warn("Ollama stream canceled", "error", ctxErr)
+			chunks <- LLMChunk{Err: ctxErr}
+			return
+		}
+		logging.Error("Error reading Ollama stream", "error", err)
+		chunks <- LLMChunk{Err: err}
+	}
+}
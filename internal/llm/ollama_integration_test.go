@@ -0,0 +1,216 @@
+//go:build integration
+
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// integrationModel is the small, tool-capable model pulled into the
+// container for these tests. Override via OLLAMA_INTEGRATION_MODEL for a
+// faster or more capable model, e.g. when running against a machine with a
+// warm image cache.
+func integrationModel() string {
+	if model := os.Getenv("OLLAMA_INTEGRATION_MODEL"); model != "" {
+		return model
+	}
+	return "llama3.2:1b"
+}
+
+// startOllamaContainer spins up a real ollama/ollama container, waits for it
+// to accept requests, and pulls integrationModel() into it so tests don't
+// depend on a human-configured Ollama on localhost:11434. It returns the
+// container's mapped base URL and registers cleanup via t.Cleanup.
+func startOllamaContainer(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+
+	req := testcontainers.ContainerRequest{
+		Image:        "ollama/ollama:latest",
+		ExposedPorts: []string{"11434/tcp"},
+		WaitingFor:   wait.ForHTTP("/api/tags").WithPort("11434/tcp").WithStartupTimeout(60 * time.Second),
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		t.Fatalf("failed to start ollama container: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := container.Terminate(context.Background()); err != nil {
+			t.Logf("failed to terminate ollama container: %v", err)
+		}
+	})
+
+	mappedPort, err := container.MappedPort(ctx, "11434/tcp")
+	if err != nil {
+		t.Fatalf("failed to get mapped port: %v", err)
+	}
+	host, err := container.Host(ctx)
+	if err != nil {
+		t.Fatalf("failed to get container host: %v", err)
+	}
+	baseURL := fmt.Sprintf("http://%s:%s", host, mappedPort.Port())
+
+	pullOllamaModel(t, baseURL, integrationModel())
+	return baseURL
+}
+
+// pullOllamaModel blocks until model is pulled and ready in the container at
+// baseURL, via the same /api/pull endpoint a human would use.
+func pullOllamaModel(t *testing.T, baseURL, model string) {
+	t.Helper()
+
+	jsonBody, err := json.Marshal(map[string]interface{}{"model": model, "stream": false})
+	if err != nil {
+		t.Fatalf("failed to marshal pull request for model %q: %v", model, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/api/pull", bytes.NewReader(jsonBody))
+	if err != nil {
+		t.Fatalf("failed to build pull request for model %q: %v", model, err)
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+
+	response, err := http.DefaultClient.Do(httpRequest)
+	if err != nil {
+		t.Fatalf("failed to pull model %q: %v", model, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("pulling model %q returned status %d", model, response.StatusCode)
+	}
+}
+
+// setOllamaModelEnv points cfg.ReadConfig().OllamaModel at model for the
+// duration of t, since Generate resolves its chat model from global config
+// rather than LLMRequest.
+func setOllamaModelEnv(t *testing.T, model string) {
+	t.Helper()
+	os.Setenv("OLLAMA_MODEL", model)
+	t.Cleanup(func() { os.Unsetenv("OLLAMA_MODEL") })
+}
+
+// setOllamaVisionModelEnv points cfg.ReadConfig().OllamaVisionModel at model
+// for the duration of t, the vision-model counterpart of setOllamaModelEnv.
+func setOllamaVisionModelEnv(t *testing.T, model string) {
+	t.Helper()
+	os.Setenv("OLLAMA_VISION_MODEL", model)
+	t.Cleanup(func() { os.Unsetenv("OLLAMA_VISION_MODEL") })
+}
+
+// TestOllama_Generate_WithToolCall_Container is the hermetic replacement for
+// the old localhost:11434-dependent tool-call test: it runs against a
+// container we control, so it's reproducible in CI instead of silently
+// skipping when no local Ollama is reachable.
+func TestOllama_Generate_WithToolCall_Container(t *testing.T) {
+	baseURL := startOllamaContainer(t)
+	setOllamaModelEnv(t, integrationModel())
+	ollama := NewOllama(baseURL)
+
+	prompt := "Please use a tool to get the current weather in Paris in celsius."
+	llmResponse, err := ollama.Generate(context.Background(), LLMRequest{
+		Prompt: prompt,
+		Tools:  []Tool{makeWeatherTool()},
+	})
+	if err != nil {
+		t.Fatalf("Generate() returned an unexpected error: %v", err)
+	}
+
+	if len(llmResponse.ToolUses) == 0 {
+		t.Fatalf("expected at least one tool call in the response, got none. Response: %s", llmResponse.Response)
+	}
+	if llmResponse.ToolUses[0].ToolName != "get_current_weather" {
+		t.Errorf("expected tool name 'get_current_weather', got %q", llmResponse.ToolUses[0].ToolName)
+	}
+}
+
+// TestOllamaGenerateStream_Container exercises the streaming path end to end
+// against the same hermetic container.
+func TestOllamaGenerateStream_Container(t *testing.T) {
+	baseURL := startOllamaContainer(t)
+	setOllamaModelEnv(t, integrationModel())
+	ollama := NewOllama(baseURL)
+
+	chunks, err := ollama.GenerateStream(context.Background(), LLMRequest{
+		Prompt: "Say hello in one short sentence.",
+	})
+	if err != nil {
+		t.Fatalf("GenerateStream() returned an unexpected error: %v", err)
+	}
+
+	var assembled string
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			t.Fatalf("received chunk error: %v", chunk.Err)
+		}
+		assembled += chunk.Delta
+	}
+	if assembled == "" {
+		t.Errorf("expected a non-empty assembled streaming response")
+	}
+}
+
+// TestOllama_Embed_Container exercises Embed against the hermetic container.
+func TestOllama_Embed_Container(t *testing.T) {
+	baseURL := startOllamaContainer(t)
+	pullOllamaModel(t, baseURL, "nomic-embed-text")
+	ollama := NewOllama(baseURL)
+
+	response, err := ollama.Embed(context.Background(), EmbedRequest{Input: []string{"hello world"}})
+	if err != nil {
+		t.Fatalf("Embed() returned an unexpected error: %v", err)
+	}
+	if len(response.Embeddings) != 1 || len(response.Embeddings[0]) == 0 {
+		t.Errorf("expected one non-empty embedding vector, got %v", response.Embeddings)
+	}
+}
+
+// TestOllama_Generate_WithImage_Container exercises the vision-model path
+// introduced for multimodal input against the hermetic container.
+func TestOllama_Generate_WithImage_Container(t *testing.T) {
+	baseURL := startOllamaContainer(t)
+	const visionModel = "llava"
+	pullOllamaModel(t, baseURL, visionModel)
+	setOllamaVisionModelEnv(t, visionModel)
+	ollama := NewOllama(baseURL)
+
+	response, err := ollama.Generate(context.Background(), LLMRequest{
+		Prompt: "Describe what you see in one sentence.",
+		Images: [][]byte{onePixelPNG()},
+	})
+	if err != nil {
+		t.Fatalf("Generate() returned an unexpected error: %v", err)
+	}
+	if response.Response == "" {
+		t.Errorf("expected a non-empty description for the image")
+	}
+}
+
+// onePixelPNG returns the bytes of a minimal 1x1 transparent PNG, enough to
+// exercise the vision-model path without needing a real test fixture.
+func onePixelPNG() []byte {
+	return []byte{
+		0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d,
+		0x49, 0x48, 0x44, 0x52, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01,
+		0x08, 0x06, 0x00, 0x00, 0x00, 0x1f, 0x15, 0xc4, 0x89, 0x00, 0x00, 0x00,
+		0x0a, 0x49, 0x44, 0x41, 0x54, 0x78, 0x9c, 0x63, 0x00, 0x01, 0x00, 0x00,
+		0x05, 0x00, 0x01, 0x0d, 0x0a, 0x2d, 0xb4, 0x00, 0x00, 0x00, 0x00, 0x49,
+		0x45, 0x4e, 0x44, 0xae, 0x42, 0x60, 0x82,
+	}
+}
@@ -1,13 +1,20 @@
 package llm
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/piercegov/llm-npc-backend/internal/cfg"
+	"github.com/piercegov/llm-npc-backend/internal/logging"
 )
 
 func TestOllama_Generate_SuccessfulResponse(t *testing.T) {
@@ -24,6 +31,7 @@ func TestOllama_Generate_SuccessfulResponse(t *testing.T) {
 		"done_reason": "stop",
 	}
 	jsonOllamaResponse, _ := json.Marshal(ollamaResponse)
+	const testRequestID = "req-ollama-12345"
 
 	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		// Check if the request path is /api/chat
@@ -34,6 +42,9 @@ func TestOllama_Generate_SuccessfulResponse(t *testing.T) {
 		if req.Method != http.MethodPost {
 			t.Errorf("Expected POST request, got '%s'", req.Method)
 		}
+		if gotRequestID := req.Header.Get("X-Request-ID"); gotRequestID != testRequestID {
+			t.Errorf("Expected X-Request-ID header %q, got %q", testRequestID, gotRequestID)
+		}
 
 		// Send a 200 OK response with a proper Ollama JSON body
 		rw.Header().Set("Content-Type", "application/json")
@@ -46,7 +57,8 @@ func TestOllama_Generate_SuccessfulResponse(t *testing.T) {
 	ollama := NewOllama(server.URL)
 
 	prompt := "Hello, Ollama!"
-	response, err := ollama.Generate(LLMRequest{Prompt: prompt})
+	ctx := logging.WithRequestID(context.Background(), testRequestID)
+	response, err := ollama.Generate(ctx, LLMRequest{Prompt: prompt})
 
 	if err != nil {
 		t.Fatalf("Generate() returned an unexpected error: %v", err)
@@ -61,49 +73,10 @@ func TestOllama_Generate_SuccessfulResponse(t *testing.T) {
 	}
 }
 
-// TestOllama_Generate_WithToolCall verifies that the response contains at least one tool call.
-func TestOllama_Generate_WithToolCall(t *testing.T) {
-	// Skip this test if -short is passed, as it's an integration test.
-	if testing.Short() {
-		t.Skip("Skipping integration test in short mode.")
-	}
-
-	// Use the default Ollama URL for the integration test.
-	ollama := NewOllama("http://localhost:11434")
-
-	// Prompt designed to trigger a tool call
-	prompt := "Please use a tool to get the current weather in Paris in celsius."
-	llmResponse, err := ollama.Generate(LLMRequest{Prompt: prompt, Tools: []Tool{makeWeatherTool()}})
-
-	if err != nil {
-		if strings.Contains(err.Error(), "connection refused") {
-			t.Skipf("Skipping integration test: Ollama instance not reachable at port 11434 (connection refused). Error: %v", err)
-			return
-		}
-		t.Fatalf("Generate() returned an unexpected error: %v", err)
-	}
-
-	if llmResponse.StatusCode != http.StatusOK {
-		t.Errorf("Expected status code %d, got %d. Response: %s", http.StatusOK, llmResponse.StatusCode, llmResponse.Response)
-	}
-
-	// Verify that the response contains at least one tool call
-	if len(llmResponse.ToolUses) == 0 {
-		t.Errorf("Expected at least one tool call in the response, but got none. Response: %s", llmResponse.Response)
-	}
-	//Validate that it called the get_current_weather tool with the correct arguments
-	if llmResponse.ToolUses[0].ToolName != "get_current_weather" {
-		t.Errorf("Expected tool name 'get_current_weather', got '%s'", llmResponse.ToolUses[0].ToolName)
-	}
-	if llmResponse.ToolUses[0].ToolArgs["location"] != "Paris" {
-		t.Errorf("Expected location 'Paris', got '%s'", llmResponse.ToolUses[0].ToolArgs["location"])
-	}
-	if llmResponse.ToolUses[0].ToolArgs["format"] != "celsius" {
-		t.Errorf("Expected format 'celsius', got '%s'", llmResponse.ToolUses[0].ToolArgs["format"])
-	}
-
-	t.Logf("Integration test received response with tool call: %s", llmResponse.Response)
-}
+// TestOllama_Generate_WithToolCall has moved to ollama_integration_test.go
+// (build tag "integration"), where it runs against a hermetic
+// testcontainers-managed Ollama instance instead of a human-configured
+// localhost:11434.
 
 func makeWeatherTool() Tool {
 	return Tool{
@@ -135,7 +108,7 @@ func TestOllama_Generate_ModelNotFound(t *testing.T) {
 	defer server.Close()
 
 	ollama := NewOllama(server.URL)
-	_, err := ollama.Generate(LLMRequest{Prompt: "test"})
+	_, err := ollama.Generate(context.Background(), LLMRequest{Prompt: "test"})
 
 	if err == nil {
 		t.Fatal("Expected error but got none")
@@ -163,7 +136,7 @@ func TestOllama_Generate_BadRequest(t *testing.T) {
 	defer server.Close()
 
 	ollama := NewOllama(server.URL)
-	_, err := ollama.Generate(LLMRequest{Prompt: "test"})
+	_, err := ollama.Generate(context.Background(), LLMRequest{Prompt: "test"})
 
 	if err == nil {
 		t.Fatal("Expected error but got none")
@@ -184,7 +157,7 @@ func TestOllama_Generate_Unauthorized(t *testing.T) {
 	defer server.Close()
 
 	ollama := NewOllama(server.URL)
-	_, err := ollama.Generate(LLMRequest{Prompt: "test"})
+	_, err := ollama.Generate(context.Background(), LLMRequest{Prompt: "test"})
 
 	if err == nil {
 		t.Fatal("Expected error but got none")
@@ -205,7 +178,7 @@ func TestOllama_Generate_RateLimited(t *testing.T) {
 	defer server.Close()
 
 	ollama := NewOllama(server.URL)
-	_, err := ollama.Generate(LLMRequest{Prompt: "test"})
+	_, err := ollama.Generate(context.Background(), LLMRequest{Prompt: "test"})
 
 	if err == nil {
 		t.Fatal("Expected error but got none")
@@ -226,7 +199,7 @@ func TestOllama_Generate_ServiceUnavailable(t *testing.T) {
 	defer server.Close()
 
 	ollama := NewOllama(server.URL)
-	_, err := ollama.Generate(LLMRequest{Prompt: "test"})
+	_, err := ollama.Generate(context.Background(), LLMRequest{Prompt: "test"})
 
 	if err == nil {
 		t.Fatal("Expected error but got none")
@@ -259,7 +232,7 @@ func TestOllama_Generate_Timeout(t *testing.T) {
 func TestOllama_Generate_ConnectionRefused(t *testing.T) {
 	// Use a port that's likely not in use
 	ollama := NewOllama("http://localhost:54321")
-	_, err := ollama.Generate(LLMRequest{Prompt: "test"})
+	_, err := ollama.Generate(context.Background(), LLMRequest{Prompt: "test"})
 
 	if err == nil {
 		t.Fatal("Expected error but got none")
@@ -269,3 +242,394 @@ func TestOllama_Generate_ConnectionRefused(t *testing.T) {
 		t.Errorf("Expected ErrProviderUnavailable, got %v", err)
 	}
 }
+
+// TestOllamaGenerateStream_AssemblesDeltasAndToolCalls verifies that
+// GenerateStream decodes Ollama's newline-delimited JSON stream into
+// incremental chunks, accumulating content and surfacing tool calls (and
+// usage) only once the final done: true object arrives.
+func TestOllamaGenerateStream_AssemblesDeltasAndToolCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("Failed to parse request body: %v", err)
+		}
+		if stream, _ := payload["stream"].(bool); !stream {
+			t.Errorf("Expected stream: true on streaming request")
+		}
+
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("test server does not support flushing")
+		}
+
+		lines := []string{
+			`{"message":{"role":"assistant","content":"Hel"},"done":false}`,
+			`{"message":{"role":"assistant","content":"lo"},"done":false}`,
+			`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"get_current_weather","arguments":{"location":"Paris"}}}]},"done":true,"done_reason":"stop","prompt_eval_count":5,"eval_count":7}`,
+		}
+		for _, line := range lines {
+			fmt.Fprintf(w, "%s\n", line)
+			flusher.Flush()
+		}
+	}))
+	defer server.Close()
+
+	ollama := NewOllama(server.URL)
+	stream, err := ollama.GenerateStream(context.Background(), LLMRequest{Prompt: "Use the weather tool."})
+	if err != nil {
+		t.Fatalf("GenerateStream returned error: %v", err)
+	}
+
+	var last LLMChunk
+	for chunk := range stream {
+		if chunk.Err != nil {
+			t.Fatalf("unexpected chunk error: %v", chunk.Err)
+		}
+		last = chunk
+	}
+
+	if last.Content != "Hello" {
+		t.Errorf("Expected accumulated content 'Hello', got %q", last.Content)
+	}
+	if len(last.ToolUses) != 1 || last.ToolUses[0].ToolName != "get_current_weather" {
+		t.Errorf("Expected a get_current_weather tool use on the final chunk, got %+v", last.ToolUses)
+	}
+	if last.Usage == nil || last.Usage.TotalTokens != 12 {
+		t.Errorf("Expected final usage with TotalTokens=12, got %+v", last.Usage)
+	}
+	if last.FinishReason != "stop" {
+		t.Errorf("Expected FinishReason 'stop', got %q", last.FinishReason)
+	}
+}
+
+func TestOllamaGenerateStream_NonOKStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("boom"))
+	}))
+	defer server.Close()
+
+	ollama := NewOllama(server.URL)
+	_, err := ollama.GenerateStream(context.Background(), LLMRequest{Prompt: "test"})
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+}
+
+// TestOllama_Generate_ContextCanceled verifies that an already-canceled ctx
+// is surfaced distinctly from a network failure, and is not retryable.
+func TestOllama_Generate_ContextCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ollama := NewOllama(server.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := ollama.Generate(ctx, LLMRequest{Prompt: "test"})
+	if err == nil {
+		t.Fatal("Expected error for a canceled context, got nil")
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if IsRetryable(err) {
+		t.Error("Expected a canceled request not to be retryable")
+	}
+}
+
+func TestOllama_Generate_PromptInjectedToolModeOmitsNativeToolsField(t *testing.T) {
+	var capturedBody map[string]interface{}
+	var capturedSystemPrompt string
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		json.NewDecoder(req.Body).Decode(&capturedBody)
+		if messages, ok := capturedBody["messages"].([]interface{}); ok && len(messages) > 0 {
+			if first, ok := messages[0].(map[string]interface{}); ok {
+				capturedSystemPrompt, _ = first["content"].(string)
+			}
+		}
+
+		reply := `{"tool": "get_current_weather", "tool_input": {"location": "Paris"}}`
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"message": map[string]interface{}{"role": "assistant", "content": reply},
+			"done":    true,
+		})
+	}))
+	defer server.Close()
+
+	ollama := NewOllama(server.URL)
+	response, err := ollama.Generate(context.Background(), LLMRequest{
+		SystemPrompt: "You are a helpful NPC.",
+		Prompt:       "What's the weather in Paris?",
+		Tools:        []Tool{makeWeatherTool()},
+		ToolMode:     ToolModePromptInjected,
+	})
+	if err != nil {
+		t.Fatalf("Generate() returned an unexpected error: %v", err)
+	}
+
+	if _, hasTools := capturedBody["tools"]; hasTools {
+		t.Errorf("expected no native tools field in prompt-injected mode, got %v", capturedBody["tools"])
+	}
+	if !strings.Contains(capturedSystemPrompt, "get_current_weather") {
+		t.Errorf("expected the injected system prompt to describe the tool, got %q", capturedSystemPrompt)
+	}
+
+	if len(response.ToolUses) != 1 {
+		t.Fatalf("expected one parsed tool use, got %d", len(response.ToolUses))
+	}
+	if response.ToolUses[0].ToolName != "get_current_weather" {
+		t.Errorf("expected tool name 'get_current_weather', got %q", response.ToolUses[0].ToolName)
+	}
+	if response.ToolUses[0].ToolArgs["location"] != "Paris" {
+		t.Errorf("expected location 'Paris', got %v", response.ToolUses[0].ToolArgs["location"])
+	}
+}
+
+func TestOllama_Generate_PromptInjectedToolModePlainTextReply(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"message": map[string]interface{}{"role": "assistant", "content": "It's sunny today."},
+			"done":    true,
+		})
+	}))
+	defer server.Close()
+
+	ollama := NewOllama(server.URL)
+	response, err := ollama.Generate(context.Background(), LLMRequest{
+		Prompt:   "What's the weather?",
+		Tools:    []Tool{makeWeatherTool()},
+		ToolMode: ToolModePromptInjected,
+	})
+	if err != nil {
+		t.Fatalf("Generate() returned an unexpected error: %v", err)
+	}
+	if len(response.ToolUses) != 0 {
+		t.Errorf("expected no tool uses for a plain-text reply, got %d", len(response.ToolUses))
+	}
+	if response.Response != "It's sunny today." {
+		t.Errorf("expected the plain-text reply to pass through unchanged, got %q", response.Response)
+	}
+}
+
+func TestOllama_Generate_AutoToolModeProbesAndCachesPerModel(t *testing.T) {
+	var showRequests int
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/api/show":
+			showRequests++
+			json.NewEncoder(rw).Encode(map[string]interface{}{"capabilities": []string{"completion"}})
+		case "/api/chat":
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			if _, hasTools := body["tools"]; hasTools {
+				t.Errorf("expected Auto mode to have resolved to prompt-injected (no native tools field), got %v", body["tools"])
+			}
+			json.NewEncoder(rw).Encode(map[string]interface{}{
+				"message": map[string]interface{}{"role": "assistant", "content": "no tool needed"},
+				"done":    true,
+			})
+		}
+	}))
+	defer server.Close()
+
+	ollama := NewOllama(server.URL)
+	request := LLMRequest{Prompt: "hello", Tools: []Tool{makeWeatherTool()}, ToolMode: ToolModeAuto}
+
+	if _, err := ollama.Generate(context.Background(), request); err != nil {
+		t.Fatalf("first Generate(): %v", err)
+	}
+	if _, err := ollama.Generate(context.Background(), request); err != nil {
+		t.Fatalf("second Generate(): %v", err)
+	}
+
+	if showRequests != 1 {
+		t.Errorf("expected /api/show to be probed once and cached, got %d requests", showRequests)
+	}
+}
+
+func TestSelectOllamaModel_PicksVisionModelOnlyWhenImagesPresentAndConfigured(t *testing.T) {
+	config := cfg.Config{OllamaModel: "qwen3:1.7b", OllamaVisionModel: "llava"}
+
+	if got := selectOllamaModel(config, LLMRequest{Prompt: "hi"}); got != "qwen3:1.7b" {
+		t.Errorf("expected chat model for a text-only request, got %q", got)
+	}
+	if got := selectOllamaModel(config, LLMRequest{Prompt: "what is this?", Images: [][]byte{{1, 2, 3}}}); got != "llava" {
+		t.Errorf("expected vision model when Images is set, got %q", got)
+	}
+
+	config.OllamaVisionModel = ""
+	if got := selectOllamaModel(config, LLMRequest{Images: [][]byte{{1, 2, 3}}}); got != "qwen3:1.7b" {
+		t.Errorf("expected fallback to chat model when no vision model is configured, got %q", got)
+	}
+}
+
+func TestOllama_Generate_AttachesBase64EncodedImages(t *testing.T) {
+	os.Setenv("OLLAMA_VISION_MODEL", "test-vision-model")
+	defer os.Unsetenv("OLLAMA_VISION_MODEL")
+
+	var capturedBody map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		json.NewDecoder(req.Body).Decode(&capturedBody)
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"message": map[string]interface{}{"role": "assistant", "content": "I see a cat."},
+			"done":    true,
+		})
+	}))
+	defer server.Close()
+
+	ollama := NewOllama(server.URL)
+	response, err := ollama.Generate(context.Background(), LLMRequest{
+		Prompt: "What is in this image?",
+		Images: [][]byte{[]byte("fake-jpeg-bytes")},
+	})
+	if err != nil {
+		t.Fatalf("Generate() returned an unexpected error: %v", err)
+	}
+	if response.Response != "I see a cat." {
+		t.Errorf("expected the response to pass through unchanged, got %q", response.Response)
+	}
+
+	if got := capturedBody["model"]; got != "test-vision-model" {
+		t.Errorf("expected request to target the configured vision model, got %v", got)
+	}
+
+	messages, ok := capturedBody["messages"].([]interface{})
+	if !ok || len(messages) == 0 {
+		t.Fatalf("expected at least one message in the request body, got %v", capturedBody["messages"])
+	}
+	userMessage, ok := messages[len(messages)-1].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected the last message to be an object, got %v", messages[len(messages)-1])
+	}
+	images, ok := userMessage["images"].([]interface{})
+	if !ok || len(images) != 1 {
+		t.Fatalf("expected one base64-encoded image on the user message, got %v", userMessage["images"])
+	}
+	if images[0] != base64.StdEncoding.EncodeToString([]byte("fake-jpeg-bytes")) {
+		t.Errorf("expected the image to be base64-encoded, got %v", images[0])
+	}
+}
+
+func TestOllama_Generate_NoImagesOmitsImagesFieldAndUsesChatModel(t *testing.T) {
+	var capturedBody map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		json.NewDecoder(req.Body).Decode(&capturedBody)
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"message": map[string]interface{}{"role": "assistant", "content": "hi"},
+			"done":    true,
+		})
+	}))
+	defer server.Close()
+
+	ollama := NewOllama(server.URL)
+	if _, err := ollama.Generate(context.Background(), LLMRequest{Prompt: "hello"}); err != nil {
+		t.Fatalf("Generate() returned an unexpected error: %v", err)
+	}
+
+	messages, ok := capturedBody["messages"].([]interface{})
+	if !ok || len(messages) == 0 {
+		t.Fatalf("expected at least one message in the request body, got %v", capturedBody["messages"])
+	}
+	userMessage, ok := messages[len(messages)-1].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected the last message to be an object, got %v", messages[len(messages)-1])
+	}
+	if _, hasImages := userMessage["images"]; hasImages {
+		t.Errorf("expected no images field on a text-only request, got %v", userMessage["images"])
+	}
+}
+
+func TestOllama_Generate_FormatJSONSetsLooseJSONModeAndTrimsWhitespace(t *testing.T) {
+	var capturedBody map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		json.NewDecoder(req.Body).Decode(&capturedBody)
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"message": map[string]interface{}{"role": "assistant", "content": "\n  {\"action\":\"move\"}  \n"},
+			"done":    true,
+		})
+	}))
+	defer server.Close()
+
+	ollama := NewOllama(server.URL)
+	response, err := ollama.Generate(context.Background(), LLMRequest{
+		Prompt:         "Where should the NPC go?",
+		ResponseFormat: FormatJSON,
+	})
+	if err != nil {
+		t.Fatalf("Generate() returned an unexpected error: %v", err)
+	}
+
+	if capturedBody["format"] != "json" {
+		t.Errorf("expected request body format to be \"json\", got %v", capturedBody["format"])
+	}
+	if response.Response != `{"action":"move"}` {
+		t.Errorf("expected trimmed whitespace around the response, got %q", response.Response)
+	}
+	if string(response.Structured) != `{"action":"move"}` {
+		t.Errorf("expected Structured to hold the parsed JSON, got %q", response.Structured)
+	}
+}
+
+func TestOllama_Generate_FormatJSONSchemaSendsSchemaAndValidatesReply(t *testing.T) {
+	schema := json.RawMessage(`{"type":"object","required":["action","target"],"properties":{"action":{"type":"string"}}}`)
+	var capturedBody map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		json.NewDecoder(req.Body).Decode(&capturedBody)
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"message": map[string]interface{}{"role": "assistant", "content": `{"action":"move","target":"tavern"}`},
+			"done":    true,
+		})
+	}))
+	defer server.Close()
+
+	ollama := NewOllama(server.URL)
+	response, err := ollama.Generate(context.Background(), LLMRequest{
+		Prompt:         "Where should the NPC go?",
+		ResponseFormat: FormatJSONSchema{Schema: schema},
+	})
+	if err != nil {
+		t.Fatalf("Generate() returned an unexpected error: %v", err)
+	}
+
+	if _, hasFormat := capturedBody["format"]; !hasFormat {
+		t.Errorf("expected request body to carry the schema as \"format\", got %v", capturedBody)
+	}
+	if string(response.Structured) != `{"action":"move","target":"tavern"}` {
+		t.Errorf("expected Structured to hold the parsed JSON, got %q", response.Structured)
+	}
+}
+
+func TestOllama_Generate_FormatJSONSchemaMismatchReturnsBadRequest(t *testing.T) {
+	schema := json.RawMessage(`{"type":"object","required":["action","target"]}`)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"message": map[string]interface{}{"role": "assistant", "content": `{"action":"move"}`},
+			"done":    true,
+		})
+	}))
+	defer server.Close()
+
+	ollama := NewOllama(server.URL)
+	_, err := ollama.Generate(context.Background(), LLMRequest{
+		Prompt:         "Where should the NPC go?",
+		ResponseFormat: FormatJSONSchema{Schema: schema},
+	})
+	if err == nil {
+		t.Fatalf("expected an error when the reply is missing a required field")
+	}
+	if !errors.Is(err, ErrBadRequest) {
+		t.Errorf("expected the error to wrap ErrBadRequest, got %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+package llm
+
+import "time"
+
+// defaultOpenAITimeout is used when NewOpenAI's timeout is left zero,
+// matching defaultLMStudioTimeout's rationale.
+const defaultOpenAITimeout = 30 * time.Second
+
+// NewOpenAI returns a provider for OpenAI's hosted chat/completions API.
+// OpenAI's wire format is exactly what LM Studio already emulates (it's an
+// OpenAI-compatible server), so rather than duplicating LMStudio's request
+// building, retry loop, and response parsing, this just points an LMStudio
+// instance at OpenAI's API instead of a local server.
+func NewOpenAI(baseURL, model, apiKey string, timeout time.Duration, opts ...LMStudioOption) *LMStudio {
+	if timeout <= 0 {
+		timeout = defaultOpenAITimeout
+	}
+	return NewLMStudio(baseURL, model, apiKey, timeout, opts...)
+}
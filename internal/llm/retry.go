@@ -0,0 +1,170 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/piercegov/llm-npc-backend/internal/logging"
+)
+
+// Retrier wraps LLMProvider calls with full-jitter exponential backoff,
+// retrying only errors that IsRetryable reports as transient.
+type Retrier struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// TotalDeadline caps the wall-clock time spent across all attempts,
+	// including backoff sleeps. Zero means no deadline beyond ctx itself.
+	TotalDeadline time.Duration
+}
+
+// NewRetrier creates a Retrier from the given backoff and deadline knobs.
+func NewRetrier(maxAttempts int, baseDelay, maxDelay, totalDeadline time.Duration) *Retrier {
+	return &Retrier{
+		MaxAttempts:   maxAttempts,
+		BaseDelay:     baseDelay,
+		MaxDelay:      maxDelay,
+		TotalDeadline: totalDeadline,
+	}
+}
+
+// Do calls fn, retrying while IsRetryable(err) is true, up to MaxAttempts and
+// TotalDeadline, with full-jitter exponential backoff between attempts. It
+// returns early if ctx is canceled. When the last error is a *ProviderError
+// carrying a RetryAfter hint, it waits at least that long before the next
+// attempt.
+func (r *Retrier) Do(ctx context.Context, fn func() error) error {
+	if r.TotalDeadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.TotalDeadline)
+		defer cancel()
+	}
+
+	maxAttempts := r.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		lastErr = fn()
+		if lastErr == nil {
+			return nil
+		}
+		if !IsRetryable(lastErr) {
+			return lastErr
+		}
+		if attempt == maxAttempts-1 {
+			break
+		}
+
+		delay := r.backoff(attempt)
+		if retryAfter := retryAfterHint(lastErr); retryAfter > delay {
+			delay = retryAfter
+		}
+
+		logging.Warn("Retrying LLM call after transient error",
+			"attempt", attempt+1,
+			"max_attempts", maxAttempts,
+			"delay", delay,
+			"error", lastErr,
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	return lastErr
+}
+
+// DoStream mirrors Do for a call shape that begins streaming a response body.
+// Retries apply only while establishing the stream: once fn has returned a
+// body, bytes may already be flowing to the caller, so a retry there would
+// risk duplicating or corrupting output.
+func (r *Retrier) DoStream(ctx context.Context, fn func() (io.ReadCloser, error)) (io.ReadCloser, error) {
+	var body io.ReadCloser
+	err := r.Do(ctx, func() error {
+		var innerErr error
+		body, innerErr = fn()
+		return innerErr
+	})
+	return body, err
+}
+
+// backoff computes a full-jitter exponential delay for the given zero-indexed
+// attempt: delay = rand(0, min(MaxDelay, BaseDelay * 2^attempt)).
+func (r *Retrier) backoff(attempt int) time.Duration {
+	base := r.BaseDelay
+	if base <= 0 {
+		base = 100 * time.Millisecond
+	}
+	maxDelay := r.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = 30 * time.Second
+	}
+
+	capped := float64(base) * math.Pow(2, float64(attempt))
+	if capped <= 0 || capped > float64(maxDelay) {
+		capped = float64(maxDelay)
+	}
+
+	return time.Duration(rand.Int63n(int64(capped) + 1))
+}
+
+// retryAfterHint extracts a *ProviderError's RetryAfter, if any.
+func retryAfterHint(err error) time.Duration {
+	var provErr *ProviderError
+	if errors.As(err, &provErr) {
+		return provErr.RetryAfter
+	}
+	return 0
+}
+
+// retryingProvider wraps an LLMProvider so every Generate call is retried
+// per the wrapped Retrier's backoff policy. It is returned transparently by
+// NewProvider so callers don't need to know retries are happening.
+type retryingProvider struct {
+	inner   LLMProvider
+	retrier *Retrier
+}
+
+// WithRetry wraps provider so its Generate calls are retried per retrier.
+func WithRetry(provider LLMProvider, retrier *Retrier) LLMProvider {
+	return &retryingProvider{inner: provider, retrier: retrier}
+}
+
+func (p *retryingProvider) Generate(ctx context.Context, request LLMRequest) (LLMResponse, error) {
+	var response LLMResponse
+	err := p.retrier.Do(ctx, func() error {
+		var innerErr error
+		response, innerErr = p.inner.Generate(ctx, request)
+		return innerErr
+	})
+	return response, err
+}
+
+// GenerateStream implements the LLMProvider interface by forwarding the
+// wrapped provider's own stream. Retries only cover establishing the stream
+// (the inner GenerateStream call returning before yielding any chunk);
+// retrying a partially delivered stream would mean replaying chunks already
+// sent to the caller, so a mid-stream failure is surfaced as-is once
+// established.
+func (p *retryingProvider) GenerateStream(ctx context.Context, request LLMRequest) (<-chan LLMChunk, error) {
+	var stream <-chan LLMChunk
+	err := p.retrier.Do(ctx, func() error {
+		var innerErr error
+		stream, innerErr = p.inner.GenerateStream(ctx, request)
+		return innerErr
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
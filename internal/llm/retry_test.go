@@ -0,0 +1,128 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/piercegov/llm-npc-backend/internal/logging"
+)
+
+func init() {
+	logging.InitLogger("debug")
+}
+
+func TestRetrier_Do_SucceedsWithoutRetry(t *testing.T) {
+	r := NewRetrier(3, time.Millisecond, 10*time.Millisecond, 0)
+
+	calls := 0
+	err := r.Do(context.Background(), func() error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v, want nil", err)
+	}
+	if calls != 1 {
+		t.Errorf("Do() called fn %d times, want 1", calls)
+	}
+}
+
+func TestRetrier_Do_RetriesRetryableErrors(t *testing.T) {
+	r := NewRetrier(3, time.Millisecond, 10*time.Millisecond, 0)
+
+	calls := 0
+	err := r.Do(context.Background(), func() error {
+		calls++
+		if calls < 3 {
+			return ErrRateLimited
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v, want nil", err)
+	}
+	if calls != 3 {
+		t.Errorf("Do() called fn %d times, want 3", calls)
+	}
+}
+
+func TestRetrier_Do_GivesUpAfterMaxAttempts(t *testing.T) {
+	r := NewRetrier(2, time.Millisecond, 10*time.Millisecond, 0)
+
+	calls := 0
+	err := r.Do(context.Background(), func() error {
+		calls++
+		return ErrTimeout
+	})
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Do() error = %v, want ErrTimeout", err)
+	}
+	if calls != 2 {
+		t.Errorf("Do() called fn %d times, want 2 (MaxAttempts)", calls)
+	}
+}
+
+func TestRetrier_Do_DoesNotRetryNonRetryableErrors(t *testing.T) {
+	r := NewRetrier(3, time.Millisecond, 10*time.Millisecond, 0)
+
+	calls := 0
+	err := r.Do(context.Background(), func() error {
+		calls++
+		return ErrBadRequest
+	})
+	if !errors.Is(err, ErrBadRequest) {
+		t.Fatalf("Do() error = %v, want ErrBadRequest", err)
+	}
+	if calls != 1 {
+		t.Errorf("Do() called fn %d times, want 1 (no retry)", calls)
+	}
+}
+
+func TestRetrier_Do_HonorsContextCancellation(t *testing.T) {
+	r := NewRetrier(5, 50*time.Millisecond, 100*time.Millisecond, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := r.Do(ctx, func() error {
+		calls++
+		if calls == 1 {
+			cancel()
+		}
+		return ErrTimeout
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Do() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestRetrier_Do_WaitsAtLeastRetryAfterHint(t *testing.T) {
+	r := NewRetrier(2, time.Millisecond, time.Millisecond, 0)
+
+	start := time.Now()
+	calls := 0
+	_ = r.Do(context.Background(), func() error {
+		calls++
+		if calls == 1 {
+			rateLimited := NewProviderError("test", "model", ErrRateLimited, "rate limited")
+			rateLimited.RetryAfter = 30 * time.Millisecond
+			return rateLimited
+		}
+		return nil
+	})
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("Do() returned after %v, expected to wait for the RetryAfter hint (>= 30ms)", elapsed)
+	}
+}
+
+func TestRetrier_backoff_StaysWithinBounds(t *testing.T) {
+	r := NewRetrier(10, 10*time.Millisecond, 200*time.Millisecond, 0)
+
+	for attempt := 0; attempt < 10; attempt++ {
+		delay := r.backoff(attempt)
+		if delay < 0 || delay > r.MaxDelay {
+			t.Errorf("backoff(%d) = %v, want within [0, %v]", attempt, delay, r.MaxDelay)
+		}
+	}
+}
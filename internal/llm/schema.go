@@ -0,0 +1,109 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// jsonSchema is the subset of JSON Schema that validateJSONSchema enforces:
+// enough to catch a model replying with the wrong shape, not a full
+// implementation of the spec (no $ref, no oneOf/anyOf, no numeric ranges).
+type jsonSchema struct {
+	Type       string                `json:"type"`
+	Required   []string              `json:"required"`
+	Properties map[string]jsonSchema `json:"properties"`
+	Items      *jsonSchema           `json:"items"`
+	Enum       []interface{}         `json:"enum"`
+}
+
+// validateJSONSchema checks that content (a JSON document) satisfies schema
+// (a JSON Schema document), returning a descriptive error on the first
+// mismatch found.
+func validateJSONSchema(schema, content []byte) error {
+	var parsedSchema jsonSchema
+	if err := json.Unmarshal(schema, &parsedSchema); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(content, &value); err != nil {
+		return fmt.Errorf("content is not valid JSON: %w", err)
+	}
+
+	return validateAgainstSchema("", parsedSchema, value)
+}
+
+func validateAgainstSchema(path string, schema jsonSchema, value interface{}) error {
+	if len(schema.Enum) > 0 && !enumContains(schema.Enum, value) {
+		return fmt.Errorf("%s: value is not one of the allowed enum values", fieldLabel(path))
+	}
+
+	switch schema.Type {
+	case "", "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			if schema.Type == "" {
+				return nil
+			}
+			return fmt.Errorf("%s: expected an object", fieldLabel(path))
+		}
+		for _, required := range schema.Required {
+			if _, present := obj[required]; !present {
+				return fmt.Errorf("%s: missing required field %q", fieldLabel(path), required)
+			}
+		}
+		for name, propSchema := range schema.Properties {
+			propValue, present := obj[name]
+			if !present {
+				continue
+			}
+			if err := validateAgainstSchema(path+"."+name, propSchema, propValue); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an array", fieldLabel(path))
+		}
+		if schema.Items == nil {
+			return nil
+		}
+		for i, item := range items {
+			if err := validateAgainstSchema(fmt.Sprintf("%s[%d]", path, i), *schema.Items, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s: expected a string", fieldLabel(path))
+		}
+	case "number", "integer":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: expected a number", fieldLabel(path))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected a boolean", fieldLabel(path))
+		}
+	}
+	return nil
+}
+
+func enumContains(enum []interface{}, value interface{}) bool {
+	for _, candidate := range enum {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldLabel(path string) string {
+	if path == "" {
+		return "root"
+	}
+	return path
+}
@@ -0,0 +1,48 @@
+package llm
+
+import "testing"
+
+func TestValidateJSONSchema_RequiredFieldMissing(t *testing.T) {
+	schema := []byte(`{"type":"object","required":["action","target"]}`)
+	content := []byte(`{"action":"move"}`)
+
+	if err := validateJSONSchema(schema, content); err == nil {
+		t.Fatalf("expected an error for a missing required field")
+	}
+}
+
+func TestValidateJSONSchema_AllRequiredFieldsPresentPasses(t *testing.T) {
+	schema := []byte(`{"type":"object","required":["action","target"]}`)
+	content := []byte(`{"action":"move","target":"tavern"}`)
+
+	if err := validateJSONSchema(schema, content); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidateJSONSchema_PropertyTypeMismatch(t *testing.T) {
+	schema := []byte(`{"type":"object","properties":{"action":{"type":"string"}}}`)
+	content := []byte(`{"action":42}`)
+
+	if err := validateJSONSchema(schema, content); err == nil {
+		t.Fatalf("expected an error for a property with the wrong type")
+	}
+}
+
+func TestValidateJSONSchema_EnumMismatch(t *testing.T) {
+	schema := []byte(`{"type":"string","enum":["move","speak","wait"]}`)
+	content := []byte(`"dance"`)
+
+	if err := validateJSONSchema(schema, content); err == nil {
+		t.Fatalf("expected an error for a value outside the enum")
+	}
+}
+
+func TestValidateJSONSchema_InvalidContentJSON(t *testing.T) {
+	schema := []byte(`{"type":"object"}`)
+	content := []byte(`not json`)
+
+	if err := validateJSONSchema(schema, content); err == nil {
+		t.Fatalf("expected an error for content that isn't valid JSON")
+	}
+}
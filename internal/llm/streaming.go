@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// LLMChunk is one incremental update from LLMProvider.GenerateStream.
+type LLMChunk struct {
+	// Content is the full response text accumulated so far, including Delta.
+	Content string
+	// Delta is just the text added by this chunk.
+	Delta string
+	// ToolUses holds the tool calls assembled so far; entries only appear
+	// once their arguments have accumulated into valid JSON, so earlier
+	// chunks may omit a tool call that a later one includes.
+	ToolUses []ToolUse
+	// ToolCallDelta carries this chunk's incremental contribution to one
+	// in-progress tool call, for providers that stream function-call
+	// arguments as fragments (e.g. OpenAI-compatible APIs) rather than only
+	// emitting ToolUses once arguments are complete JSON. Nil for chunks
+	// that carry no tool-call progress, and always nil for providers that
+	// don't support incremental tool-call streaming.
+	ToolCallDelta *ToolCallDelta
+	// FinishReason is set on the final chunk produced for a response.
+	FinishReason string
+	// Usage is set on the final chunk, if the provider reported it; nil otherwise.
+	Usage *LLMUsage
+	// Structured mirrors LLMResponse.Structured, set on the final chunk when
+	// ResponseFormat was FormatJSON or FormatJSONSchema and Content parsed
+	// as valid JSON.
+	Structured json.RawMessage
+	// Err is set if the stream failed; the channel is closed immediately after.
+	Err error
+}
+
+// ToolCallDelta is one incremental update to a single in-progress tool call
+// within a streamed response. A caller reconstructs the full call by
+// buffering fragments per ID: Name arrives once, on the chunk where it
+// first becomes known, and ArgsFragment accumulates across chunks into the
+// call's full JSON arguments.
+type ToolCallDelta struct {
+	// ID identifies which in-progress tool call this delta belongs to,
+	// since a response can request several tool calls concurrently.
+	ID string
+	// Name is the tool's name, set only on the chunk where it first becomes
+	// known; empty on every subsequent delta for the same ID.
+	Name string
+	// ArgsFragment is the raw JSON text this chunk appends to ID's
+	// arguments.
+	ArgsFragment string
+}
+
+// LLMUsage reports token accounting for a completed generation.
+type LLMUsage struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// generateStreamFallback adapts a provider's non-streaming Generate into the
+// GenerateStream contract by running it synchronously and emitting the whole
+// response as a single final chunk. Providers that don't natively support
+// streaming, or that just wrap another provider, use this so every
+// LLMProvider satisfies the interface uniformly.
+func generateStreamFallback(ctx context.Context, generate func(context.Context, LLMRequest) (LLMResponse, error), request LLMRequest) (<-chan LLMChunk, error) {
+	response, err := generate(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan LLMChunk, 1)
+	chunks <- LLMChunk{
+		Content:      response.Response,
+		Delta:        response.Response,
+		ToolUses:     response.ToolUses,
+		FinishReason: "stop",
+		Usage:        response.Usage,
+		Structured:   response.Structured,
+	}
+	close(chunks)
+	return chunks, nil
+}
@@ -1,6 +1,7 @@
 package logging
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"strings"
@@ -8,6 +9,24 @@ import (
 
 var Logger *slog.Logger
 
+// loggerContextKey is the context key under which a request-scoped
+// *slog.Logger is stored by WithContext.
+type loggerContextKey struct{}
+
+// WithContext returns a copy of ctx carrying logger, retrievable via FromContext.
+func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// FromContext returns the *slog.Logger bound to ctx by WithContext, or the
+// global Logger if none was bound.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok && logger != nil {
+		return logger
+	}
+	return Logger
+}
+
 // InitLogger initializes the global logger with the specified level and format
 func InitLogger(logLevel string) {
 	level := parseLogLevel(logLevel)
@@ -0,0 +1,25 @@
+package logging
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFromContext_ReturnsGlobalLoggerWhenUnset(t *testing.T) {
+	InitLogger("info")
+
+	if got := FromContext(context.Background()); got != Logger {
+		t.Fatalf("FromContext(context.Background()) = %p, want global Logger %p", got, Logger)
+	}
+}
+
+func TestWithContext_FromContext_RoundTrip(t *testing.T) {
+	InitLogger("info")
+
+	bound := Logger.With("request_id", "abc-123")
+	ctx := WithContext(context.Background(), bound)
+
+	if got := FromContext(ctx); got != bound {
+		t.Fatalf("FromContext(ctx) = %p, want bound logger %p", got, bound)
+	}
+}
@@ -0,0 +1,90 @@
+package logging
+
+import "strings"
+
+// Query is a small filter grammar for Records, used by the log viewer's
+// search bar. A query is whitespace-separated terms, implicitly ANDed
+// together (the literal word "AND" may be used between terms but adds no
+// extra meaning). Each term is one of:
+//
+//	level>=warn       - only records at or above the given level
+//	npc_id=guard_01   - exact match against Fields["npc_id"]
+//	tool:read         - substring match against Fields["tool"]
+//	guard_01          - free text, matched against the message and raw line
+type Query struct {
+	terms []queryTerm
+}
+
+type queryKind int
+
+const (
+	queryLevelAtLeast queryKind = iota
+	queryFieldEquals
+	queryFieldContains
+	queryFreeText
+)
+
+type queryTerm struct {
+	kind  queryKind
+	key   string
+	value string
+}
+
+// ParseQuery compiles a raw search-bar string into a Query. An empty or
+// whitespace-only string parses to a Query that matches every Record.
+func ParseQuery(raw string) Query {
+	var q Query
+	for _, token := range strings.Fields(raw) {
+		if strings.EqualFold(token, "AND") {
+			continue
+		}
+		q.terms = append(q.terms, parseTerm(token))
+	}
+	return q
+}
+
+func parseTerm(token string) queryTerm {
+	if key, value, ok := strings.Cut(token, ">="); ok {
+		return queryTerm{kind: queryLevelAtLeast, key: strings.ToLower(key), value: strings.ToUpper(value)}
+	}
+	if key, value, ok := strings.Cut(token, ":"); ok {
+		return queryTerm{kind: queryFieldContains, key: key, value: value}
+	}
+	if key, value, ok := strings.Cut(token, "="); ok {
+		return queryTerm{kind: queryFieldEquals, key: key, value: value}
+	}
+	return queryTerm{kind: queryFreeText, value: token}
+}
+
+// Matches reports whether rec satisfies every term in q.
+func (q Query) Matches(rec Record) bool {
+	for _, term := range q.terms {
+		if !term.matches(rec) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t queryTerm) matches(rec Record) bool {
+	switch t.kind {
+	case queryLevelAtLeast:
+		if t.key != "level" {
+			return false
+		}
+		rank := Level(t.value).Rank()
+		return rank >= 0 && rec.Level.Rank() >= rank
+	case queryFieldEquals:
+		value, ok := rec.Fields[t.key]
+		return ok && value == t.value
+	case queryFieldContains:
+		value, ok := rec.Fields[t.key]
+		return ok && strings.Contains(strings.ToLower(value), strings.ToLower(t.value))
+	case queryFreeText:
+		needle := strings.ToLower(t.value)
+		return strings.Contains(strings.ToLower(rec.Message), needle) ||
+			strings.Contains(strings.ToLower(rec.Raw), needle)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,54 @@
+package logging
+
+import "testing"
+
+func TestQuery_LevelAtLeast(t *testing.T) {
+	q := ParseQuery("level>=warn")
+
+	if q.Matches(Record{Level: LevelInfo}) {
+		t.Error("Matches() = true for INFO record, want false")
+	}
+	if !q.Matches(Record{Level: LevelError}) {
+		t.Error("Matches() = false for ERROR record, want true")
+	}
+}
+
+func TestQuery_FieldEquals(t *testing.T) {
+	q := ParseQuery("npc_id=guard_01")
+
+	if !q.Matches(Record{Fields: map[string]string{"npc_id": "guard_01"}}) {
+		t.Error("Matches() = false for exact field match, want true")
+	}
+	if q.Matches(Record{Fields: map[string]string{"npc_id": "guard_02"}}) {
+		t.Error("Matches() = true for a different npc_id, want false")
+	}
+}
+
+func TestQuery_FieldContains(t *testing.T) {
+	q := ParseQuery("tool:scratch")
+
+	if !q.Matches(Record{Fields: map[string]string{"tool": "read_scratchpad"}}) {
+		t.Error("Matches() = false for substring field match, want true")
+	}
+}
+
+func TestQuery_FreeTextAndFieldCombineWithAnd(t *testing.T) {
+	q := ParseQuery("npc_id=guard_01 AND timeout")
+
+	match := Record{Message: "tool call timeout", Fields: map[string]string{"npc_id": "guard_01"}}
+	if !q.Matches(match) {
+		t.Error("Matches() = false, want true when both terms are satisfied")
+	}
+
+	missingField := Record{Message: "tool call timeout", Fields: map[string]string{"npc_id": "guard_02"}}
+	if q.Matches(missingField) {
+		t.Error("Matches() = true, want false when the field term fails")
+	}
+}
+
+func TestQuery_Empty_MatchesEverything(t *testing.T) {
+	q := ParseQuery("   ")
+	if !q.Matches(Record{}) {
+		t.Error("Matches() = false for an empty query, want true")
+	}
+}
@@ -0,0 +1,181 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Level is a parsed log level, matching the string slog's handlers emit
+// ("DEBUG", "INFO", "WARN", "ERROR").
+type Level string
+
+const (
+	LevelDebug Level = "DEBUG"
+	LevelInfo  Level = "INFO"
+	LevelWarn  Level = "WARN"
+	LevelError Level = "ERROR"
+)
+
+// Rank orders levels for ">=" comparisons, e.g. filtering on "level>=warn".
+// Unrecognized levels rank below LevelDebug so they never match such a filter.
+func (l Level) Rank() int {
+	switch Level(strings.ToUpper(string(l))) {
+	case LevelDebug:
+		return 0
+	case LevelInfo:
+		return 1
+	case LevelWarn:
+		return 2
+	case LevelError:
+		return 3
+	default:
+		return -1
+	}
+}
+
+// Record is a structured log line emitted by internal/logging, either via
+// slog's text handler (dev) or JSON handler (production).
+type Record struct {
+	Time    time.Time
+	Level   Level
+	Message string
+	Fields  map[string]string
+	Raw     string
+}
+
+// ParseRecord parses a single line of backend output into a Record. It
+// understands both of internal/logging's handler formats:
+//
+//	time=2026-07-29T10:00:00.000-07:00 level=INFO msg="Request completed" method=GET
+//	{"time":"2026-07-29T10:00:00.000-07:00","level":"INFO","msg":"Request completed","method":"GET"}
+//
+// ParseRecord reports false when line doesn't look like a structured record
+// (e.g. output from a dependency that doesn't go through slog); callers
+// should fall back to displaying Raw as an opaque line in that case.
+func ParseRecord(line string) (Record, bool) {
+	raw := line
+	trimmed := strings.TrimSpace(line)
+
+	var fields map[string]string
+	var ok bool
+	if strings.HasPrefix(trimmed, "{") {
+		fields, ok = parseJSONFields(trimmed)
+	} else {
+		fields, ok = parseTextFields(trimmed)
+	}
+	if !ok {
+		return Record{Raw: raw}, false
+	}
+
+	level, hasLevel := fields["level"]
+	if !hasLevel {
+		return Record{Raw: raw}, false
+	}
+	delete(fields, "level")
+
+	message := fields["msg"]
+	delete(fields, "msg")
+
+	var t time.Time
+	if ts, hasTime := fields["time"]; hasTime {
+		delete(fields, "time")
+		if parsed, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			t = parsed
+		}
+	}
+
+	return Record{
+		Time:    t,
+		Level:   Level(strings.ToUpper(level)),
+		Message: message,
+		Fields:  fields,
+		Raw:     raw,
+	}, true
+}
+
+// parseJSONFields flattens a JSON log line into a string-valued field map.
+func parseJSONFields(line string) (map[string]string, bool) {
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(line), &raw); err != nil {
+		return nil, false
+	}
+
+	fields := make(map[string]string, len(raw))
+	for key, value := range raw {
+		if s, isString := value.(string); isString {
+			fields[key] = s
+		} else {
+			fields[key] = fmt.Sprintf("%v", value)
+		}
+	}
+	return fields, true
+}
+
+// parseTextFields splits a slog text-handler line into key=value fields,
+// honoring double-quoted values that may contain spaces.
+func parseTextFields(line string) (map[string]string, bool) {
+	fields := make(map[string]string)
+
+	i := 0
+	for i < len(line) {
+		for i < len(line) && line[i] == ' ' {
+			i++
+		}
+		if i >= len(line) {
+			break
+		}
+
+		eq := strings.IndexByte(line[i:], '=')
+		if eq < 0 {
+			return nil, false
+		}
+		key := line[i : i+eq]
+		i += eq + 1
+
+		var value string
+		if i < len(line) && line[i] == '"' {
+			end, unquoted, err := readQuoted(line[i:])
+			if err != nil {
+				return nil, false
+			}
+			value = unquoted
+			i += end
+		} else {
+			start := i
+			for i < len(line) && line[i] != ' ' {
+				i++
+			}
+			value = line[start:i]
+		}
+
+		fields[key] = value
+	}
+
+	if len(fields) == 0 {
+		return nil, false
+	}
+	return fields, true
+}
+
+// readQuoted decodes a leading double-quoted Go string literal from s,
+// returning the number of bytes it consumed and its unquoted value.
+func readQuoted(s string) (consumed int, value string, err error) {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == '"' {
+			quoted := s[:i+1]
+			value, err = strconv.Unquote(quoted)
+			if err != nil {
+				return 0, "", err
+			}
+			return i + 1, value, nil
+		}
+	}
+	return 0, "", fmt.Errorf("unterminated quoted value in %q", s)
+}
@@ -0,0 +1,58 @@
+package logging
+
+import "testing"
+
+func TestParseRecord_TextHandler(t *testing.T) {
+	line := `time=2026-07-29T10:00:00.000-07:00 level=INFO msg="Request completed" method=GET npc_id=guard_01`
+
+	rec, ok := ParseRecord(line)
+	if !ok {
+		t.Fatalf("ParseRecord(%q) ok = false, want true", line)
+	}
+	if rec.Level != LevelInfo {
+		t.Errorf("Level = %q, want %q", rec.Level, LevelInfo)
+	}
+	if rec.Message != "Request completed" {
+		t.Errorf("Message = %q, want %q", rec.Message, "Request completed")
+	}
+	if rec.Fields["method"] != "GET" || rec.Fields["npc_id"] != "guard_01" {
+		t.Errorf("Fields = %+v, want method=GET npc_id=guard_01", rec.Fields)
+	}
+	if rec.Time.IsZero() {
+		t.Error("Time is zero, want parsed timestamp")
+	}
+}
+
+func TestParseRecord_JSONHandler(t *testing.T) {
+	line := `{"time":"2026-07-29T10:00:00Z","level":"WARN","msg":"rate limited","tool":"read_scratchpad"}`
+
+	rec, ok := ParseRecord(line)
+	if !ok {
+		t.Fatalf("ParseRecord(%q) ok = false, want true", line)
+	}
+	if rec.Level != LevelWarn {
+		t.Errorf("Level = %q, want %q", rec.Level, LevelWarn)
+	}
+	if rec.Fields["tool"] != "read_scratchpad" {
+		t.Errorf("Fields[tool] = %q, want %q", rec.Fields["tool"], "read_scratchpad")
+	}
+}
+
+func TestParseRecord_UnstructuredFallsBack(t *testing.T) {
+	rec, ok := ParseRecord("panic: runtime error: index out of range")
+	if ok {
+		t.Fatal("ParseRecord() ok = true, want false for unstructured output")
+	}
+	if rec.Raw != "panic: runtime error: index out of range" {
+		t.Errorf("Raw = %q, want the original line preserved", rec.Raw)
+	}
+}
+
+func TestLevel_Rank(t *testing.T) {
+	if !(LevelDebug.Rank() < LevelInfo.Rank() && LevelInfo.Rank() < LevelWarn.Rank() && LevelWarn.Rank() < LevelError.Rank()) {
+		t.Errorf("levels are not strictly ordered debug<info<warn<error")
+	}
+	if Level("bogus").Rank() >= 0 {
+		t.Error("Rank() of an unrecognized level should be negative")
+	}
+}
@@ -0,0 +1,27 @@
+package logging
+
+import "context"
+
+// requestIDContextKey is the context key under which a request's ID is
+// stored by WithRequestID, mirroring loggerContextKey above. It lives here
+// rather than in internal/api so lower-level packages (e.g. internal/llm)
+// can propagate/read a request ID without importing the HTTP-handler layer.
+type requestIDContextKey struct{}
+
+// WithRequestID returns a copy of ctx carrying requestID, retrievable via
+// RequestIDFromContext.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID bound to ctx by WithRequestID,
+// or "" if none was bound.
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if requestID, ok := ctx.Value(requestIDContextKey{}).(string); ok {
+		return requestID
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package logging
+
+import "sync"
+
+// RingBuffer is a fixed-capacity, thread-safe buffer of Records. Pushing
+// past capacity discards the oldest record, so long-running backend
+// processes can stream indefinitely without unbounded memory growth.
+type RingBuffer struct {
+	mu       sync.Mutex
+	capacity int
+	buf      []Record
+	start    int
+}
+
+// NewRingBuffer creates a RingBuffer holding at most capacity records.
+// A non-positive capacity is treated as 1.
+func NewRingBuffer(capacity int) *RingBuffer {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &RingBuffer{capacity: capacity}
+}
+
+// Push appends rec, evicting the oldest record first if the buffer is full.
+func (r *RingBuffer) Push(rec Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.buf) < r.capacity {
+		r.buf = append(r.buf, rec)
+		return
+	}
+	r.buf[r.start] = rec
+	r.start = (r.start + 1) % r.capacity
+}
+
+// Snapshot returns the buffered records in insertion order, oldest first.
+func (r *RingBuffer) Snapshot() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]Record, len(r.buf))
+	for i := range r.buf {
+		out[i] = r.buf[(r.start+i)%r.capacity]
+	}
+	return out
+}
+
+// Len reports how many records are currently buffered.
+func (r *RingBuffer) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.buf)
+}
+
+// Clear discards every buffered record.
+func (r *RingBuffer) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buf = nil
+	r.start = 0
+}
@@ -0,0 +1,42 @@
+package logging
+
+import "testing"
+
+func TestRingBuffer_PushWithinCapacity(t *testing.T) {
+	r := NewRingBuffer(3)
+	r.Push(Record{Message: "a"})
+	r.Push(Record{Message: "b"})
+
+	got := r.Snapshot()
+	if len(got) != 2 || got[0].Message != "a" || got[1].Message != "b" {
+		t.Errorf("Snapshot() = %+v, want [a b]", got)
+	}
+}
+
+func TestRingBuffer_EvictsOldestPastCapacity(t *testing.T) {
+	r := NewRingBuffer(2)
+	r.Push(Record{Message: "a"})
+	r.Push(Record{Message: "b"})
+	r.Push(Record{Message: "c"})
+
+	got := r.Snapshot()
+	if len(got) != 2 || got[0].Message != "b" || got[1].Message != "c" {
+		t.Errorf("Snapshot() = %+v, want [b c]", got)
+	}
+	if r.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", r.Len())
+	}
+}
+
+func TestRingBuffer_Clear(t *testing.T) {
+	r := NewRingBuffer(2)
+	r.Push(Record{Message: "a"})
+	r.Clear()
+
+	if r.Len() != 0 {
+		t.Errorf("Len() after Clear() = %d, want 0", r.Len())
+	}
+	if got := r.Snapshot(); len(got) != 0 {
+		t.Errorf("Snapshot() after Clear() = %+v, want empty", got)
+	}
+}
@@ -0,0 +1,132 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Record is a single remembered piece of NPC dialogue or event text, along
+// with the embedding vector used to retrieve it later.
+type Record struct {
+	ID        string
+	NPCID     string
+	Text      string
+	Embedding []float64
+	Timestamp time.Time
+	Metadata  map[string]interface{}
+}
+
+// Store is the durability/indexing layer behind Retriever. It mirrors the
+// tools.ScratchpadStore/npc.Store pluggable-backend idiom: a scheme-selected
+// constructor picks the implementation, so callers never branch on backend.
+type Store interface {
+	Add(ctx context.Context, record Record) error
+	TopK(ctx context.Context, npcID string, queryEmbedding []float64, k int) ([]Record, error)
+}
+
+// NewStore builds a Store from a backend URL, selected by scheme:
+//
+//	memory://   an in-process cosine-similarity index, the default; does
+//	            not survive a restart
+//
+// sqlite:// and chromem:// are reserved for future durable backends but not
+// yet implemented. An empty rawURL defaults to "memory://".
+func NewStore(rawURL string) (Store, error) {
+	if rawURL == "" {
+		rawURL = "memory://"
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid memory store URL %q: %w", rawURL, err)
+	}
+
+	switch parsed.Scheme {
+	case "", "memory":
+		return NewInMemoryStore(), nil
+	case "sqlite", "chromem":
+		return nil, fmt.Errorf("memory store scheme %q is reserved but not yet implemented", parsed.Scheme)
+	default:
+		return nil, fmt.Errorf("unsupported memory store scheme: %s", parsed.Scheme)
+	}
+}
+
+// InMemoryStore ranks records by cosine similarity with a linear scan. Fine
+// for a single NPC's lifetime of memories; a durable backend (sqlite,
+// chromem-go) would trade this simplicity for an actual ANN index.
+type InMemoryStore struct {
+	mu      sync.RWMutex
+	records map[string][]Record // npcID -> records, oldest first
+}
+
+// NewInMemoryStore creates a Store that holds every remembered Record in
+// process memory only.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{records: make(map[string][]Record)}
+}
+
+func (s *InMemoryStore) Add(ctx context.Context, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.NPCID] = append(s.records[record.NPCID], record)
+	return nil
+}
+
+func (s *InMemoryStore) TopK(ctx context.Context, npcID string, queryEmbedding []float64, k int) ([]Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if k <= 0 {
+		return nil, nil
+	}
+
+	candidates := s.records[npcID]
+	scored := make([]scoredRecord, 0, len(candidates))
+	for _, record := range candidates {
+		scored = append(scored, scoredRecord{record: record, score: cosineSimilarity(queryEmbedding, record.Embedding)})
+	}
+
+	// Simple selection sort for the top k: candidate sets are small (a
+	// single NPC's memory, not a corpus), so an O(n*k) scan beats pulling in
+	// a full sort for what's usually a handful of picks.
+	var top []Record
+	for i := 0; i < k && len(scored) > 0; i++ {
+		bestIdx := 0
+		for j, candidate := range scored {
+			if candidate.score > scored[bestIdx].score {
+				bestIdx = j
+			}
+		}
+		top = append(top, scored[bestIdx].record)
+		scored = append(scored[:bestIdx], scored[bestIdx+1:]...)
+	}
+	return top, nil
+}
+
+type scoredRecord struct {
+	record Record
+	score  float64
+}
+
+// cosineSimilarity returns the cosine similarity of a and b, or 0 if either
+// is empty/zero-length (no meaningful direction to compare).
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) == 0 || len(b) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"context"
+	"testing"
+)
+
+func TestInMemoryStore_TopKRanksByCosineSimilarity(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+
+	records := []Record{
+		{NPCID: "npc-1", Text: "exact match", Embedding: []float64{1, 0, 0}},
+		{NPCID: "npc-1", Text: "orthogonal", Embedding: []float64{0, 1, 0}},
+		{NPCID: "npc-1", Text: "close match", Embedding: []float64{0.9, 0.1, 0}},
+		{NPCID: "npc-2", Text: "other npc", Embedding: []float64{1, 0, 0}},
+	}
+	for _, record := range records {
+		if err := store.Add(ctx, record); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	top, err := store.TopK(ctx, "npc-1", []float64{1, 0, 0}, 2)
+	if err != nil {
+		t.Fatalf("TopK: %v", err)
+	}
+	if len(top) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(top))
+	}
+	if top[0].Text != "exact match" {
+		t.Errorf("expected best match first, got %q", top[0].Text)
+	}
+	if top[1].Text != "close match" {
+		t.Errorf("expected second-best match second, got %q", top[1].Text)
+	}
+}
+
+func TestInMemoryStore_TopKScopesToNPC(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+
+	store.Add(ctx, Record{NPCID: "npc-1", Text: "mine", Embedding: []float64{1, 0}})
+	store.Add(ctx, Record{NPCID: "npc-2", Text: "not mine", Embedding: []float64{1, 0}})
+
+	top, err := store.TopK(ctx, "npc-1", []float64{1, 0}, 5)
+	if err != nil {
+		t.Fatalf("TopK: %v", err)
+	}
+	if len(top) != 1 || top[0].Text != "mine" {
+		t.Fatalf("expected only npc-1's record, got %+v", top)
+	}
+}
+
+func TestInMemoryStore_TopKZeroReturnsNothing(t *testing.T) {
+	store := NewInMemoryStore()
+	ctx := context.Background()
+	store.Add(ctx, Record{NPCID: "npc-1", Text: "mine", Embedding: []float64{1, 0}})
+
+	top, err := store.TopK(ctx, "npc-1", []float64{1, 0}, 0)
+	if err != nil {
+		t.Fatalf("TopK: %v", err)
+	}
+	if len(top) != 0 {
+		t.Fatalf("expected no results for k=0, got %d", len(top))
+	}
+}
+
+func TestNewStore_SelectsBackendByScheme(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawURL   string
+		wantErr  bool
+		wantType bool
+	}{
+		{name: "empty defaults to in-memory", rawURL: "", wantType: true},
+		{name: "memory scheme", rawURL: "memory://", wantType: true},
+		{name: "reserved sqlite scheme", rawURL: "sqlite://local.db", wantErr: true},
+		{name: "reserved chromem scheme", rawURL: "chromem://local", wantErr: true},
+		{name: "unsupported scheme", rawURL: "redis://localhost", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewStore(tt.rawURL)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error for %q, got none", tt.rawURL)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewStore(%q): %v", tt.rawURL, err)
+			}
+			if tt.wantType {
+				if _, ok := store.(*InMemoryStore); !ok {
+					t.Fatalf("expected *InMemoryStore, got %T", store)
+				}
+			}
+		})
+	}
+}
+
+func TestCosineSimilarity_MismatchedOrEmptyVectorsReturnZero(t *testing.T) {
+	if got := cosineSimilarity(nil, []float64{1, 2}); got != 0 {
+		t.Errorf("expected 0 for nil vector, got %f", got)
+	}
+	if got := cosineSimilarity([]float64{1, 2}, []float64{1, 2, 3}); got != 0 {
+		t.Errorf("expected 0 for mismatched lengths, got %f", got)
+	}
+	if got := cosineSimilarity([]float64{0, 0}, []float64{1, 1}); got != 0 {
+		t.Errorf("expected 0 for zero vector, got %f", got)
+	}
+}
@@ -0,0 +1,85 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/piercegov/llm-npc-backend/internal/llm"
+)
+
+// DefaultTopK is how many memories Retriever.Inject retrieves when a caller
+// doesn't override it.
+const DefaultTopK = 3
+
+// Retriever gives NPCs retrieval-augmented recall: Remember embeds and
+// stores a piece of dialogue/event text, and Inject retrieves the k most
+// similar memories for a query and formats them for injection into
+// LLMRequest.SystemPrompt, so an NPC can "remember" prior interactions
+// without replaying its whole history into the context window.
+type Retriever struct {
+	store    Store
+	embedder llm.Embedder
+}
+
+// NewRetriever creates a Retriever backed by store and embedder.
+func NewRetriever(store Store, embedder llm.Embedder) *Retriever {
+	return &Retriever{store: store, embedder: embedder}
+}
+
+// Remember embeds text and stores it as a Record for npcID.
+func (r *Retriever) Remember(ctx context.Context, npcID, text string, metadata map[string]interface{}) error {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+
+	resp, err := r.embedder.Embed(ctx, llm.EmbedRequest{Input: []string{text}})
+	if err != nil {
+		return fmt.Errorf("embed memory: %w", err)
+	}
+	if len(resp.Embeddings) == 0 {
+		return fmt.Errorf("embed memory: provider returned no embeddings")
+	}
+
+	return r.store.Add(ctx, Record{
+		NPCID:     npcID,
+		Text:      text,
+		Embedding: resp.Embeddings[0],
+		Metadata:  metadata,
+	})
+}
+
+// Inject embeds query and retrieves npcID's k most similar memories,
+// formatted as a block ready to append to LLMRequest.SystemPrompt. Returns
+// an empty string (not an error) if no memories are found.
+func (r *Retriever) Inject(ctx context.Context, npcID, query string, k int) (string, error) {
+	if k <= 0 {
+		k = DefaultTopK
+	}
+	if strings.TrimSpace(query) == "" {
+		return "", nil
+	}
+
+	resp, err := r.embedder.Embed(ctx, llm.EmbedRequest{Input: []string{query}})
+	if err != nil {
+		return "", fmt.Errorf("embed query: %w", err)
+	}
+	if len(resp.Embeddings) == 0 {
+		return "", fmt.Errorf("embed query: provider returned no embeddings")
+	}
+
+	memories, err := r.store.TopK(ctx, npcID, resp.Embeddings[0], k)
+	if err != nil {
+		return "", fmt.Errorf("retrieve memories: %w", err)
+	}
+	if len(memories) == 0 {
+		return "", nil
+	}
+
+	var block strings.Builder
+	block.WriteString("Relevant memories from past interactions:\n")
+	for _, memory := range memories {
+		fmt.Fprintf(&block, "- %s\n", memory.Text)
+	}
+	return block.String(), nil
+}
@@ -0,0 +1,65 @@
+// Package metrics defines the Prometheus collectors shared by the NPC tick
+// loop, tool execution, and LLM adapters. They register once against
+// prometheus.DefaultRegisterer (see internal/reactions for the same
+// pattern applied to per-rule counters) and are exposed together by the
+// /metrics handler in cmd/backend/main.go.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// TickDuration is a full NPC tick's wall-clock time, from ActForTick's
+	// entry to its return, including every continue_thinking recursion.
+	// Deliberately not labeled by NPC name/ID: that value comes straight from
+	// the client-supplied, effectively-arbitrary Name on POST /npc/register,
+	// so labeling by it would let a caller mint an unbounded number of
+	// permanent Prometheus time series just by registering NPCs with distinct
+	// names.
+	TickDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "npc_tick_duration_seconds",
+		Help: "Wall-clock time for a full NPC tick, including every continue_thinking recursion.",
+	}, []string{"provider"})
+
+	// ThinkingDepth is the terminal recursion depth a tick reached (0 means
+	// the first round never called continue_thinking), the signal to watch
+	// for runaway recursion as maxThinkingDepth is approached. Unlabeled for
+	// the same cardinality reason as TickDuration.
+	ThinkingDepth = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "npc_thinking_depth",
+		Help:    "Terminal continue_thinking recursion depth a tick reached before returning.",
+		Buckets: []float64{0, 1, 2, 3, 4},
+	})
+
+	// ToolExecutions counts every tool call the tick loop runs through a
+	// ToolRegistry, labeled by tool name and whether it succeeded.
+	ToolExecutions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "npc_tool_executions_total",
+		Help: "Tool invocations from the tick loop, labeled by tool name and outcome.",
+	}, []string{"tool_name", "success"})
+
+	// ToolDuration is the wall-clock time a single ToolRegistry.ExecuteTool
+	// call took, labeled by tool name so an expensive tool stands out.
+	ToolDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "npc_tool_duration_seconds",
+		Help: "Wall-clock time spent executing a single tool call.",
+	}, []string{"tool_name"})
+
+	// LLMTokens accounts for tokens reported by an LLMProvider's Usage,
+	// labeled by provider and direction ("prompt" or "completion").
+	LLMTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "npc_llm_tokens_total",
+		Help: "Tokens accounted for by the LLM adapter, labeled by provider and direction (prompt|completion).",
+	}, []string{"provider", "direction"})
+
+	// KGPromptBytes is the serialized size of the knowledge-graph block
+	// injected into a tick's prompt, the signal to watch for prompt bloat
+	// as a game's knowledge graph grows.
+	KGPromptBytes = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "npc_kg_prompt_bytes",
+		Help:    "Serialized size, in bytes, of the knowledge-graph block injected into a tick's prompt.",
+		Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+	})
+)
@@ -0,0 +1,216 @@
+// Package flowtest lets developers describe multi-tick NPC scenarios in a
+// spreadsheet-like format and assert on the LLM's behavior as it drives
+// npc.NPC.ActForTick, analogous to a dialog flow testing harness.
+package flowtest
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/piercegov/llm-npc-backend/internal/npc"
+	"github.com/piercegov/llm-npc-backend/internal/tools"
+)
+
+// Scenario describes a single multi-tick conversation to drive against an NPC.
+type Scenario struct {
+	Name            string
+	NPCName         string
+	BackgroundStory string
+	Steps           []Step
+}
+
+// Step is one row of a scenario: an input event batch plus the assertions to
+// run against the resulting NPCTickResult.
+type Step struct {
+	// UserInput is folded into a single NPCTickEvent when EventType/EventDescription
+	// aren't provided directly, so simple scenarios can skip the event plumbing.
+	UserInput string
+
+	Events []npc.NPCTickEvent
+
+	// ExpectedOutput is matched against the concatenated LLMResponse. If it looks
+	// like a /.../ delimited pattern it's treated as a regex, otherwise a substring match.
+	ExpectedOutput string
+
+	// ExpectedToolCall, if set, must appear somewhere in the tick's tool uses.
+	ExpectedToolCall *ToolCallMatcher
+
+	// ExpectedContext is not matched against anything: NPCState has no
+	// fields, so a step with a non-empty ExpectedContext always fails with
+	// an "expected_context assertions are not yet supported" reason.
+	ExpectedContext map[string]interface{}
+
+	// AlternateIntents lists additional tool names that are an acceptable
+	// substitute for ExpectedToolCall.Name when deciding a soft pass.
+	AlternateIntents []string
+}
+
+// ToolCallMatcher asserts that a tool was called with a name and a (partial) set of args.
+type ToolCallMatcher struct {
+	Name string
+	Args map[string]interface{}
+}
+
+// StepResult captures the outcome of a single scenario step.
+type StepResult struct {
+	StepIndex      int
+	Passed         bool
+	RecallAtK      bool // soft pass: expected tool appeared within the top-K inference rounds
+	FailureReasons []string
+}
+
+// ScenarioResult captures the outcome of running an entire scenario.
+type ScenarioResult struct {
+	Scenario string
+	Steps    []StepResult
+	Passed   bool
+}
+
+// Report aggregates results across every scenario in a run.
+type Report struct {
+	Results []ScenarioResult
+}
+
+// Passed returns true only if every scenario in the report passed.
+func (r Report) Passed() bool {
+	for _, res := range r.Results {
+		if !res.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Runner drives scenarios against a tool registry, threading NPCState between steps.
+type Runner struct {
+	ToolRegistry *tools.ToolRegistry
+	RecallK      int // number of inference rounds considered for the Recall@K soft pass
+}
+
+// NewRunner creates a Runner with the given tool registry and a default Recall@K of 1.
+func NewRunner(toolRegistry *tools.ToolRegistry) *Runner {
+	return &Runner{ToolRegistry: toolRegistry, RecallK: 1}
+}
+
+// Run drives a single scenario, tick by tick, and returns its result.
+func (rn *Runner) Run(ctx context.Context, scenario Scenario) ScenarioResult {
+	result := ScenarioResult{Scenario: scenario.Name, Passed: true}
+
+	actor := npc.NPC{Name: scenario.NPCName, BackgroundStory: scenario.BackgroundStory}
+	state := npc.NPCState{}
+
+	for i, step := range scenario.Steps {
+		events := step.Events
+		if len(events) == 0 && step.UserInput != "" {
+			events = []npc.NPCTickEvent{{EventType: "user_input", EventDescription: step.UserInput}}
+		}
+
+		tickResult := actor.ActForTick(ctx, npc.NPCTickInput{
+			NPCState:     state,
+			Events:       events,
+			ToolRegistry: rn.ToolRegistry,
+		})
+
+		stepResult := rn.assertStep(i, step, tickResult)
+		result.Steps = append(result.Steps, stepResult)
+		if !stepResult.Passed {
+			result.Passed = false
+		}
+	}
+
+	return result
+}
+
+// RunAll drives every scenario and aggregates the results into a Report.
+func (rn *Runner) RunAll(ctx context.Context, scenarios []Scenario) Report {
+	var report Report
+	for _, scenario := range scenarios {
+		report.Results = append(report.Results, rn.Run(ctx, scenario))
+	}
+	return report
+}
+
+func (rn *Runner) assertStep(index int, step Step, tickResult npc.NPCTickResult) StepResult {
+	result := StepResult{StepIndex: index, Passed: true}
+
+	if !tickResult.Success {
+		result.Passed = false
+		result.FailureReasons = append(result.FailureReasons, fmt.Sprintf("tick failed: %s", tickResult.ErrorMessage))
+		return result
+	}
+
+	if step.ExpectedOutput != "" && !matchOutput(step.ExpectedOutput, tickResult.LLMResponse) {
+		result.Passed = false
+		result.FailureReasons = append(result.FailureReasons, fmt.Sprintf("expected output %q not found in %q", step.ExpectedOutput, tickResult.LLMResponse))
+	}
+
+	if step.ExpectedToolCall != nil {
+		hardPass, withinK := matchToolCall(*step.ExpectedToolCall, step.AlternateIntents, tickResult.Rounds, rn.RecallK)
+		result.RecallAtK = withinK
+		if !hardPass {
+			result.Passed = false
+			result.FailureReasons = append(result.FailureReasons, fmt.Sprintf("expected tool call %q not found", step.ExpectedToolCall.Name))
+		}
+	}
+
+	if len(step.ExpectedContext) > 0 {
+		// NPCState currently carries no fields; this is a placeholder for
+		// when per-game state (health, inventory, faction, ...) is added.
+		result.FailureReasons = append(result.FailureReasons, "expected_context assertions are not yet supported: NPCState has no fields")
+		result.Passed = false
+	}
+
+	return result
+}
+
+// matchOutput treats a /.../ wrapped pattern as a regex, otherwise does a substring match.
+func matchOutput(expected, actual string) bool {
+	if strings.HasPrefix(expected, "/") && strings.HasSuffix(expected, "/") && len(expected) > 1 {
+		pattern := expected[1 : len(expected)-1]
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false
+		}
+		return re.MatchString(actual)
+	}
+	return strings.Contains(actual, expected)
+}
+
+// matchToolCall reports whether the expected tool call was seen at all (hardPass)
+// and whether it was seen within the first k inference rounds (withinK, the
+// Recall@K soft pass).
+func matchToolCall(matcher ToolCallMatcher, alternates []string, rounds []npc.InferenceRound, k int) (hardPass bool, withinK bool) {
+	acceptable := map[string]bool{matcher.Name: true}
+	for _, alt := range alternates {
+		acceptable[alt] = true
+	}
+
+	for i, round := range rounds {
+		for _, used := range round.ToolsUsed {
+			if !acceptable[used.ToolName] {
+				continue
+			}
+			if used.ToolName == matcher.Name && !argsMatch(matcher.Args, used.Args) {
+				continue
+			}
+			hardPass = true
+			if i < k {
+				withinK = true
+			}
+		}
+	}
+	return hardPass, withinK
+}
+
+// argsMatch reports whether every key in expected is present in actual with an equal value.
+func argsMatch(expected, actual map[string]interface{}) bool {
+	for k, v := range expected {
+		av, ok := actual[k]
+		if !ok || fmt.Sprintf("%v", av) != fmt.Sprintf("%v", v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package flowtest
+
+import (
+	"testing"
+
+	"github.com/piercegov/llm-npc-backend/internal/npc"
+)
+
+func TestMatchOutput(t *testing.T) {
+	if !matchOutput("hello", "oh hello there") {
+		t.Error("expected substring match to pass")
+	}
+	if matchOutput("goodbye", "oh hello there") {
+		t.Error("expected substring match to fail")
+	}
+	if !matchOutput("/^oh/", "oh hello there") {
+		t.Error("expected regex match to pass")
+	}
+}
+
+func TestMatchToolCall(t *testing.T) {
+	rounds := []npc.InferenceRound{
+		{ToolsUsed: []npc.ToolResult{{ToolName: "speak", Args: map[string]interface{}{"text": "hi"}}}},
+		{ToolsUsed: []npc.ToolResult{{ToolName: "wave"}}},
+	}
+
+	hardPass, withinK := matchToolCall(ToolCallMatcher{Name: "speak"}, nil, rounds, 1)
+	if !hardPass || !withinK {
+		t.Errorf("expected hard pass and recall@1, got hardPass=%v withinK=%v", hardPass, withinK)
+	}
+
+	hardPass, withinK = matchToolCall(ToolCallMatcher{Name: "wave"}, nil, rounds, 1)
+	if !hardPass || withinK {
+		t.Errorf("expected hard pass without recall@1, got hardPass=%v withinK=%v", hardPass, withinK)
+	}
+
+	hardPass, _ = matchToolCall(ToolCallMatcher{Name: "dance"}, []string{"wave"}, rounds, 1)
+	if !hardPass {
+		t.Error("expected alternate_intents to allow a hard pass")
+	}
+}
+
+func TestArgsMatch(t *testing.T) {
+	actual := map[string]interface{}{"location": "Paris", "format": "celsius"}
+	if !argsMatch(map[string]interface{}{"location": "Paris"}, actual) {
+		t.Error("expected subset match to pass")
+	}
+	if argsMatch(map[string]interface{}{"location": "London"}, actual) {
+		t.Error("expected mismatched value to fail")
+	}
+}
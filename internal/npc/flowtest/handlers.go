@@ -0,0 +1,51 @@
+package flowtest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/piercegov/llm-npc-backend/internal/api"
+	"github.com/piercegov/llm-npc-backend/internal/tools"
+)
+
+// Handlers contains the HTTP handlers for triggering flowtest runs against a live server.
+type Handlers struct {
+	toolRegistry *tools.ToolRegistry
+}
+
+// NewHandlers creates a new instance of flowtest handlers.
+func NewHandlers(toolRegistry *tools.ToolRegistry) *Handlers {
+	return &Handlers{toolRegistry: toolRegistry}
+}
+
+// RunRequest represents the request to run a flowtest scenario against a live server session.
+type RunRequest struct {
+	Scenario Scenario `json:"scenario" binding:"required"`
+	RecallK  int      `json:"recall_k"`
+}
+
+// RunHandler handles POST /npc/flowtest/run
+func (h *Handlers) RunHandler(w http.ResponseWriter, r *http.Request) {
+	var req RunRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteErrorResponse(w, http.StatusBadRequest, "Invalid JSON", api.ErrCodeInvalidJSON, nil, r.Context())
+		return
+	}
+
+	if req.Scenario.NPCName == "" {
+		api.WriteErrorResponse(w, http.StatusBadRequest, "scenario.NPCName is required", api.ErrCodeValidation, nil, r.Context())
+		return
+	}
+
+	runner := NewRunner(h.toolRegistry)
+	if req.RecallK > 0 {
+		runner.RecallK = req.RecallK
+	}
+
+	result := runner.Run(r.Context(), req.Scenario)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
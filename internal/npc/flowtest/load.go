@@ -0,0 +1,181 @@
+package flowtest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// scenarioFile mirrors Scenario/Step but with JSON-friendly field names for the on-disk format.
+type scenarioFile struct {
+	Name            string     `json:"name"`
+	NPCName         string     `json:"npc_name"`
+	BackgroundStory string     `json:"background_story"`
+	Steps           []stepFile `json:"steps"`
+}
+
+type stepFile struct {
+	UserInput        string                 `json:"user_input"`
+	ExpectedOutput   string                 `json:"expected_output"`
+	ExpectedToolCall *toolCallFile          `json:"expected_tool_call"`
+	ExpectedContext  map[string]interface{} `json:"expected_context"`
+	AlternateIntents []string               `json:"alternate_intents"`
+}
+
+type toolCallFile struct {
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args"`
+}
+
+// LoadScenariosFromDir loads every .json and .csv scenario file in dir.
+func LoadScenariosFromDir(dir string) ([]Scenario, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading scenario dir: %w", err)
+	}
+
+	var scenarios []Scenario
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".json":
+			scenario, err := loadScenarioJSON(path)
+			if err != nil {
+				return nil, fmt.Errorf("loading %s: %w", path, err)
+			}
+			scenarios = append(scenarios, scenario)
+		case ".csv":
+			scenario, err := loadScenarioCSV(path)
+			if err != nil {
+				return nil, fmt.Errorf("loading %s: %w", path, err)
+			}
+			scenarios = append(scenarios, scenario)
+		}
+	}
+
+	return scenarios, nil
+}
+
+func loadScenarioJSON(path string) (Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Scenario{}, err
+	}
+
+	var file scenarioFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return Scenario{}, err
+	}
+
+	scenario := Scenario{
+		Name:            file.Name,
+		NPCName:         file.NPCName,
+		BackgroundStory: file.BackgroundStory,
+	}
+	for _, s := range file.Steps {
+		scenario.Steps = append(scenario.Steps, stepFromFile(s))
+	}
+	return scenario, nil
+}
+
+// loadScenarioCSV loads a scenario where the NPC name/background is fixed per-file
+// (via an optional leading "#" comment header: "# npc_name=...,background_story=...").
+// The next row is a column header naming user_input, expected_output,
+// expected_tool_call, expected_tool_args (a JSON object) and alternate_intents
+// ("|"-separated); each row after it is one step.
+func loadScenarioCSV(path string) (Scenario, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Scenario{}, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return Scenario{}, err
+	}
+	if len(records) == 0 {
+		return Scenario{}, fmt.Errorf("no rows in %s", path)
+	}
+
+	scenario := Scenario{Name: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}
+
+	if first := records[0]; len(first) > 0 && strings.HasPrefix(strings.TrimSpace(first[0]), "#") {
+		for _, field := range first {
+			key, value, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(field), "#"), "=")
+			switch strings.TrimSpace(key) {
+			case "npc_name":
+				scenario.NPCName = strings.TrimSpace(value)
+			case "background_story":
+				scenario.BackgroundStory = strings.TrimSpace(value)
+			}
+		}
+		records = records[1:]
+		if len(records) == 0 {
+			return Scenario{}, fmt.Errorf("no rows in %s", path)
+		}
+	}
+
+	header := records[0]
+	colIndex := make(map[string]int, len(header))
+	for i, col := range header {
+		colIndex[strings.TrimSpace(strings.ToLower(col))] = i
+	}
+
+	get := func(row []string, name string) string {
+		idx, ok := colIndex[name]
+		if !ok || idx >= len(row) {
+			return ""
+		}
+		return row[idx]
+	}
+
+	for _, row := range records[1:] {
+		if len(row) == 0 {
+			continue
+		}
+
+		step := stepFile{
+			UserInput:      get(row, "user_input"),
+			ExpectedOutput: get(row, "expected_output"),
+		}
+		if toolName := get(row, "expected_tool_call"); toolName != "" {
+			step.ExpectedToolCall = &toolCallFile{Name: toolName}
+			if rawArgs := get(row, "expected_tool_args"); rawArgs != "" {
+				if err := json.Unmarshal([]byte(rawArgs), &step.ExpectedToolCall.Args); err != nil {
+					return Scenario{}, fmt.Errorf("parsing expected_tool_args in %s: %w", path, err)
+				}
+			}
+		}
+		if alts := get(row, "alternate_intents"); alts != "" {
+			step.AlternateIntents = strings.Split(alts, "|")
+		}
+
+		scenario.Steps = append(scenario.Steps, stepFromFile(step))
+	}
+
+	return scenario, nil
+}
+
+func stepFromFile(s stepFile) Step {
+	step := Step{
+		UserInput:        s.UserInput,
+		ExpectedOutput:   s.ExpectedOutput,
+		ExpectedContext:  s.ExpectedContext,
+		AlternateIntents: s.AlternateIntents,
+	}
+	if s.ExpectedToolCall != nil {
+		step.ExpectedToolCall = &ToolCallMatcher{Name: s.ExpectedToolCall.Name, Args: s.ExpectedToolCall.Args}
+	}
+	return step
+}
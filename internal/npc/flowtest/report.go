@@ -0,0 +1,96 @@
+package flowtest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// junitTestSuites mirrors the standard JUnit XML schema.
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name    string        `xml:"name,attr"`
+	Failure *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnitXML renders a Report as JUnit XML, one testsuite per scenario and
+// one testcase per step, so flowtest results can feed CI test reporting.
+func WriteJUnitXML(w io.Writer, report Report) error {
+	suites := junitTestSuites{}
+
+	for _, result := range report.Results {
+		suite := junitTestSuite{Name: result.Scenario}
+
+		for _, step := range result.Steps {
+			suite.Tests++
+			testCase := junitTestCase{Name: fmt.Sprintf("step_%d", step.StepIndex)}
+			if !step.Passed {
+				suite.Failures++
+				testCase.Failure = &junitFailure{
+					Message: "assertion failed",
+					Text:    strings.Join(step.FailureReasons, "; "),
+				}
+			}
+			suite.Cases = append(suite.Cases, testCase)
+		}
+
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
+		return err
+	}
+	return encoder.Encode(suites)
+}
+
+// WriteSummary renders a short, human-readable pass/fail summary per scenario and step.
+func WriteSummary(w io.Writer, report Report) error {
+	for _, result := range report.Results {
+		status := "PASS"
+		if !result.Passed {
+			status = "FAIL"
+		}
+		if _, err := fmt.Fprintf(w, "[%s] %s\n", status, result.Scenario); err != nil {
+			return err
+		}
+
+		for _, step := range result.Steps {
+			stepStatus := "pass"
+			switch {
+			case !step.Passed:
+				stepStatus = "fail"
+			case step.RecallAtK:
+				stepStatus = "pass (recall@k)"
+			}
+			if _, err := fmt.Fprintf(w, "  step %d: %s\n", step.StepIndex, stepStatus); err != nil {
+				return err
+			}
+			for _, reason := range step.FailureReasons {
+				if _, err := fmt.Fprintf(w, "    - %s\n", reason); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -1,28 +1,92 @@
 package npc
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
-	"strings"
+	"sync"
+	"time"
 
+	"github.com/piercegov/llm-npc-backend/internal/agents"
 	"github.com/piercegov/llm-npc-backend/internal/api"
+	"github.com/piercegov/llm-npc-backend/internal/llm"
 	"github.com/piercegov/llm-npc-backend/internal/logging"
+	"github.com/piercegov/llm-npc-backend/internal/memory"
+	"github.com/piercegov/llm-npc-backend/internal/reactions"
 	"github.com/piercegov/llm-npc-backend/internal/tools"
+	"github.com/piercegov/llm-npc-backend/internal/tools/rules"
+	"github.com/piercegov/llm-npc-backend/internal/webhook"
 )
 
+// llmErrorStatus maps a typed LLM error to an HTTP status and API error
+// code via errors.Is, replacing substring-matching on the error message
+// (which was fragile and locale-dependent). A nil or unrecognized err falls
+// back to a generic 500.
+func llmErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, llm.ErrProviderUnavailable):
+		return http.StatusServiceUnavailable, api.ErrCodeLLMProviderUnavailable
+	case errors.Is(err, llm.ErrTimeout):
+		return http.StatusGatewayTimeout, api.ErrCodeLLMTimeout
+	case errors.Is(err, llm.ErrRateLimited):
+		return http.StatusTooManyRequests, api.ErrCodeLLMRateLimited
+	case errors.Is(err, llm.ErrBadRequest):
+		return http.StatusBadRequest, api.ErrCodeLLMBadRequest
+	case errors.Is(err, llm.ErrUnauthorized):
+		return http.StatusUnauthorized, api.ErrCodeLLMUnauthorized
+	case errors.Is(err, llm.ErrModelNotFound):
+		return http.StatusNotFound, api.ErrCodeLLMModelNotFound
+	default:
+		return http.StatusInternalServerError, api.ErrCodeInternalServer
+	}
+}
+
 // NPCHandlers contains all NPC-related HTTP handlers
 type NPCHandlers struct {
 	storage        *NPCStorage
 	toolRegistry   *tools.ToolRegistry
 	sessionManager *tools.SessionManager
+	prompts        *PromptRegistry
+	webhooks       *webhook.Dispatcher
+	rules          *rules.RuleSession
+	memory         *memory.Retriever
+	provider       llm.LLMProvider
+	agents         *agents.Registry
+	reactions      *reactions.Engine
 }
 
-// NewNPCHandlers creates a new instance of NPC handlers
-func NewNPCHandlers(storage *NPCStorage, toolRegistry *tools.ToolRegistry, sessionManager *tools.SessionManager) *NPCHandlers {
+// NewNPCHandlers creates a new instance of NPC handlers. webhooks,
+// ruleSession, memoryRetriever, provider, agentRegistry and reactionEngine
+// may all be nil, in which case no lifecycle/tick events are fired, no
+// reactive tools.rules rules are evaluated, no retrieval-augmented memory
+// is injected, the server's configured default LLM provider (LLM_PROVIDER)
+// is used, no NPC's AgentName is resolved, and no pre-LLM reactions.Engine
+// rules run, respectively.
+func NewNPCHandlers(storage *NPCStorage, toolRegistry *tools.ToolRegistry, sessionManager *tools.SessionManager, prompts *PromptRegistry, webhooks *webhook.Dispatcher, ruleSession *rules.RuleSession, memoryRetriever *memory.Retriever, provider llm.LLMProvider, agentRegistry *agents.Registry, reactionEngine *reactions.Engine) *NPCHandlers {
 	return &NPCHandlers{
 		storage:        storage,
 		toolRegistry:   toolRegistry,
 		sessionManager: sessionManager,
+		prompts:        prompts,
+		webhooks:       webhooks,
+		rules:          ruleSession,
+		memory:         memoryRetriever,
+		provider:       provider,
+		agents:         agentRegistry,
+		reactions:      reactionEngine,
+	}
+}
+
+// fireWebhook fans event out to every subscribed webhook, if a Dispatcher
+// was configured, logging rather than failing the request on error.
+func (h *NPCHandlers) fireWebhook(ctx context.Context, event webhook.Event) {
+	if h.webhooks == nil {
+		return
+	}
+	if err := h.webhooks.Fire(ctx, event); err != nil {
+		logging.Warn("Failed to fire webhook event", "npc_id", event.NPCID, "event_type", event.Type, "error", err)
 	}
 }
 
@@ -46,7 +110,7 @@ func (h *NPCHandlers) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Register the NPC
-	npcID, err := h.storage.Register(req.Name, req.BackgroundStory)
+	npcID, err := h.storage.Register(req.Name, req.BackgroundStory, req.AgentName)
 	if err != nil {
 		api.LogRequestError(r.Context(), "Failed to register NPC", err)
 		api.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to register NPC", api.ErrCodeInternalServer, nil, r.Context())
@@ -55,6 +119,13 @@ func (h *NPCHandlers) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 
 	logging.Info("NPC registered successfully", "npc_id", npcID, "name", req.Name)
 
+	h.fireWebhook(r.Context(), webhook.Event{
+		NPCID:     npcID,
+		Type:      webhook.EventNPCRegistered,
+		RequestID: api.GetRequestID(r.Context()),
+		Payload:   map[string]string{"npc_id": npcID, "name": req.Name},
+	})
+
 	response := NPCRegisterResponse{
 		NPCID:   npcID,
 		Success: true,
@@ -88,6 +159,12 @@ func (h *NPCHandlers) ActHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A "prompt_variant" query parameter lets a game designer A/B a specific
+	// template against this NPC without changing its registered PromptTemplate.
+	if variant := r.URL.Query().Get("prompt_variant"); variant != "" {
+		req.NPCTickInput.PromptOptions.Template = variant
+	}
+
 	// Set the tool registry in the input
 	// If session ID is provided, combine global and session tools
 	if req.SessionID != "" && h.sessionManager != nil {
@@ -104,40 +181,29 @@ func (h *NPCHandlers) ActHandler(w http.ResponseWriter, r *http.Request) {
 	} else {
 		req.NPCTickInput.ToolRegistry = h.toolRegistry
 	}
+	req.NPCTickInput.RuleSession = h.rules
+	req.NPCTickInput.MemoryRetriever = h.memory
+	req.NPCTickInput.Provider = h.provider
+	req.NPCTickInput.AgentRegistry = h.agents
+	req.NPCTickInput.Reactions = h.reactions
 
 	// Execute the tick
-	result := npc.ActForTick(req.NPCTickInput)
+	result := npc.ActForTick(r.Context(), req.NPCTickInput)
 
 	// Check if the result indicates a failure
 	if !result.Success && result.ErrorMessage != "" {
-		// Determine appropriate HTTP status code based on error message
-		statusCode := http.StatusInternalServerError
-		errorCode := api.ErrCodeInternalServer
-		
-		if strings.Contains(result.ErrorMessage, "unavailable") {
-			statusCode = http.StatusServiceUnavailable
-			errorCode = api.ErrCodeLLMProviderUnavailable
-		} else if strings.Contains(result.ErrorMessage, "timed out") {
-			statusCode = http.StatusGatewayTimeout
-			errorCode = api.ErrCodeLLMTimeout
-		} else if strings.Contains(result.ErrorMessage, "rate limit") {
-			statusCode = http.StatusTooManyRequests
-			errorCode = api.ErrCodeLLMRateLimited
-		} else if strings.Contains(result.ErrorMessage, "Invalid request") {
-			statusCode = http.StatusBadRequest
-			errorCode = api.ErrCodeLLMBadRequest
-		} else if strings.Contains(result.ErrorMessage, "authentication failed") {
-			statusCode = http.StatusUnauthorized
-			errorCode = api.ErrCodeLLMUnauthorized
-		} else if strings.Contains(result.ErrorMessage, "model is not found") {
-			statusCode = http.StatusNotFound
-			errorCode = api.ErrCodeLLMModelNotFound
-		}
-		
+		statusCode, errorCode := llmErrorStatus(result.Err)
 		api.WriteErrorResponse(w, statusCode, result.ErrorMessage, errorCode, nil, r.Context())
 		return
 	}
 
+	h.fireWebhook(r.Context(), webhook.Event{
+		NPCID:     req.NPCID,
+		Type:      webhook.EventNPCTick,
+		RequestID: api.GetRequestID(r.Context()),
+		Payload:   result,
+	})
+
 	response := NPCActResponse{
 		NPCID:         req.NPCID,
 		NPCTickResult: result,
@@ -148,6 +214,155 @@ func (h *NPCHandlers) ActHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// sseHeartbeatInterval is how often ActStreamHandler writes a keep-alive
+// comment while waiting between token deltas, so reverse proxies with idle
+// timeouts don't close the connection mid-generation.
+const sseHeartbeatInterval = 15 * time.Second
+
+// sseWriter serializes writes to an SSE response. ActStreamHandler writes
+// deltas from one goroutine and heartbeats from another, and http.ResponseWriter
+// isn't safe for concurrent use, so every write goes through this mutex.
+type sseWriter struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// writeEvent JSON-encodes data and writes it as one SSE "event"/"data" frame.
+func (s *sseWriter) writeEvent(event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// writeComment writes an SSE comment line, which clients ignore but which
+// keeps the connection alive.
+func (s *sseWriter) writeComment(comment string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprintf(s.w, ": %s\n\n", comment)
+	s.flusher.Flush()
+}
+
+// sendHeartbeats writes a keep-alive comment every sseHeartbeatInterval
+// until ctx is canceled.
+func sendHeartbeats(ctx context.Context, sse *sseWriter) {
+	ticker := time.NewTicker(sseHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sse.writeComment("keep-alive")
+		}
+	}
+}
+
+// ActStreamHandler handles POST /npc/act/stream. It mirrors ActHandler's
+// request validation and tool registry resolution, but drives the tick
+// through NPC.ActForTickStream and relays each TickEvent to the client as a
+// Server-Sent Event - one SSE "event:" per TickEvent.Type - as soon as it
+// arrives, across however many continue_thinking rounds the tick takes,
+// instead of waiting for the full completion.
+func (h *NPCHandlers) ActStreamHandler(w http.ResponseWriter, r *http.Request) {
+	var req NPCActRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteErrorResponse(w, http.StatusBadRequest, "Invalid JSON", api.ErrCodeInvalidJSON, nil, r.Context())
+		return
+	}
+
+	if req.NPCID == "" {
+		api.WriteErrorResponse(w, http.StatusBadRequest, "NPC ID is required", api.ErrCodeValidation, nil, r.Context())
+		return
+	}
+
+	npc, err := h.storage.Get(req.NPCID)
+	if err != nil {
+		api.WriteErrorResponse(w, http.StatusNotFound, "NPC not found", api.ErrCodeNotFound, nil, r.Context())
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		api.WriteErrorResponse(w, http.StatusInternalServerError, "Streaming not supported by this connection", api.ErrCodeInternalServer, nil, r.Context())
+		return
+	}
+
+	if variant := r.URL.Query().Get("prompt_variant"); variant != "" {
+		req.NPCTickInput.PromptOptions.Template = variant
+	}
+
+	if req.SessionID != "" && h.sessionManager != nil {
+		sessionTools, err := h.sessionManager.GetSessionTools(req.SessionID)
+		if err != nil {
+			logging.Warn("Failed to get session tools", "session_id", req.SessionID, "error", err)
+			req.NPCTickInput.ToolRegistry = h.toolRegistry
+		} else {
+			combinedRegistry := tools.NewCombinedToolRegistry(h.toolRegistry, sessionTools)
+			req.NPCTickInput.ToolRegistry = combinedRegistry
+		}
+	} else {
+		req.NPCTickInput.ToolRegistry = h.toolRegistry
+	}
+	req.NPCTickInput.RuleSession = h.rules
+	req.NPCTickInput.MemoryRetriever = h.memory
+	req.NPCTickInput.Provider = h.provider
+	req.NPCTickInput.AgentRegistry = h.agents
+	req.NPCTickInput.Reactions = h.reactions
+
+	tickEvents, err := npc.ActForTickStream(r.Context(), req.NPCTickInput)
+	if err != nil {
+		api.LogRequestError(r.Context(), "Failed to start LLM stream", err)
+		api.WriteErrorResponse(w, http.StatusServiceUnavailable, "Error starting stream", api.ErrCodeLLMProviderUnavailable, nil, r.Context())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	sse := &sseWriter{w: w, flusher: flusher}
+
+	heartbeatCtx, cancelHeartbeat := context.WithCancel(r.Context())
+	defer cancelHeartbeat()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sendHeartbeats(heartbeatCtx, sse)
+	}()
+
+	for event := range tickEvents {
+		if event.Err != nil {
+			logging.Warn("NPC tick stream ended with an error", "npc_id", req.NPCID, "error", event.Err)
+		}
+		if err := sse.writeEvent(event.Type, event); err != nil {
+			logging.Warn("Failed to write SSE event, client likely disconnected", "npc_id", req.NPCID, "event_type", event.Type, "error", err)
+			break
+		}
+		if event.Type == TickEventDone {
+			break
+		}
+	}
+
+	cancelHeartbeat()
+	wg.Wait()
+}
+
 // ListHandler handles GET /npc/list
 func (h *NPCHandlers) ListHandler(w http.ResponseWriter, r *http.Request) {
 	npcs := h.storage.List()
@@ -173,9 +388,7 @@ func (h *NPCHandlers) ListHandler(w http.ResponseWriter, r *http.Request) {
 
 // GetHandler handles GET /npc/{id}
 func (h *NPCHandlers) GetHandler(w http.ResponseWriter, r *http.Request) {
-	// Extract NPC ID from URL path
-	path := strings.TrimPrefix(r.URL.Path, "/npc/")
-	npcID := strings.Split(path, "/")[0]
+	npcID := api.PathParam(r, "id")
 
 	if npcID == "" {
 		api.WriteErrorResponse(w, http.StatusBadRequest, "NPC ID is required", api.ErrCodeValidation, nil, r.Context())
@@ -202,11 +415,56 @@ func (h *NPCHandlers) GetHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// PromptsListResponse represents the response from listing prompt templates.
+type PromptsListResponse struct {
+	Templates []TemplateMeta `json:"templates"`
+	Count     int            `json:"count"`
+	Success   bool           `json:"success"`
+}
+
+// PromptsListHandler handles GET /npc/prompts
+func (h *NPCHandlers) PromptsListHandler(w http.ResponseWriter, r *http.Request) {
+	templates := h.prompts.List()
+
+	response := PromptsListResponse{
+		Templates: templates,
+		Count:     len(templates),
+		Success:   true,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// PromptsReloadResponse represents the response from forcing a prompt registry reload.
+type PromptsReloadResponse struct {
+	TemplatesLoaded int  `json:"templates_loaded"`
+	Success         bool `json:"success"`
+}
+
+// PromptsReloadHandler handles POST /npc/prompts/reload
+func (h *NPCHandlers) PromptsReloadHandler(w http.ResponseWriter, r *http.Request) {
+	if err := h.prompts.Reload(); err != nil {
+		api.LogRequestError(r.Context(), "Failed to reload prompt templates", err)
+		api.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to reload prompt templates", api.ErrCodeInternalServer, nil, r.Context())
+		return
+	}
+
+	templates := h.prompts.List()
+	logging.Info("Prompt templates reloaded", "templates_count", len(templates))
+
+	response := PromptsReloadResponse{
+		TemplatesLoaded: len(templates),
+		Success:         true,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 // DeleteHandler handles DELETE /npc/{id}
 func (h *NPCHandlers) DeleteHandler(w http.ResponseWriter, r *http.Request) {
-	// Extract NPC ID from URL path
-	path := strings.TrimPrefix(r.URL.Path, "/npc/")
-	npcID := strings.Split(path, "/")[0]
+	npcID := api.PathParam(r, "id")
 
 	if npcID == "" {
 		api.WriteErrorResponse(w, http.StatusBadRequest, "NPC ID is required", api.ErrCodeValidation, nil, r.Context())
@@ -222,6 +480,13 @@ func (h *NPCHandlers) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 
 	logging.Info("NPC deleted successfully", "npc_id", npcID)
 
+	h.fireWebhook(r.Context(), webhook.Event{
+		NPCID:     npcID,
+		Type:      webhook.EventNPCDeleted,
+		RequestID: api.GetRequestID(r.Context()),
+		Payload:   map[string]string{"npc_id": npcID},
+	})
+
 	response := NPCDeleteResponse{
 		NPCID:   npcID,
 		Success: true,
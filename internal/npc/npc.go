@@ -3,12 +3,22 @@ package npc
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/piercegov/llm-npc-backend/internal/agents"
+	"github.com/piercegov/llm-npc-backend/internal/api"
+	"github.com/piercegov/llm-npc-backend/internal/cfg"
 	"github.com/piercegov/llm-npc-backend/internal/kg"
 	"github.com/piercegov/llm-npc-backend/internal/llm"
 	"github.com/piercegov/llm-npc-backend/internal/logging"
+	"github.com/piercegov/llm-npc-backend/internal/memory"
+	"github.com/piercegov/llm-npc-backend/internal/metrics"
+	"github.com/piercegov/llm-npc-backend/internal/reactions"
 	"github.com/piercegov/llm-npc-backend/internal/tools"
+	"github.com/piercegov/llm-npc-backend/internal/tools/rules"
 )
 
 // Request/Response types for API endpoints
@@ -17,6 +27,9 @@ import (
 type NPCRegisterRequest struct {
 	Name            string `json:"name" binding:"required"`
 	BackgroundStory string `json:"background_story" binding:"required"`
+	// AgentName optionally names an agents.Agent this NPC should use; see
+	// NPC.AgentName.
+	AgentName string `json:"agent_name,omitempty"`
 }
 
 // NPCRegisterResponse represents the response from registering an NPC
@@ -71,6 +84,15 @@ type NPCTickResult struct {
 	LLMResponse  string // Concatenated responses from all rounds
 	Success      bool
 	ErrorMessage string
+	// Err is the underlying error that produced ErrorMessage, if any. It
+	// wraps one of the llm package's sentinel errors (ErrProviderUnavailable,
+	// ErrTimeout, etc.) so callers can classify failures with errors.Is
+	// instead of pattern-matching ErrorMessage's text.
+	Err error
+	// RequestID is the originating HTTP request's ID, so a client can
+	// cross-reference this result with the server's (and the LLM
+	// provider's) logs for the same request.
+	RequestID string
 }
 
 type InferenceRound struct {
@@ -79,6 +101,11 @@ type InferenceRound struct {
 	ToolsUsed    []ToolResult
 	Success      bool
 	ErrorMessage string
+	// Provider is the LLM provider that produced this round's response (see
+	// llm.LLMResponse.Provider), empty for a round that never reached the
+	// LLM (e.g. a reaction short-circuit) and for every round of
+	// ActForTickStream, since an llm.LLMChunk carries no provider name.
+	Provider string
 }
 
 type ToolResult struct {
@@ -100,12 +127,59 @@ type NPCTickInput struct {
 	KnowledgeGraph      kg.KnowledgeGraph
 	NPCState            NPCState
 	KnowledgeGraphDepth int
-	Events              []NPCTickEvent
-	ToolRegistry        *tools.ToolRegistry // Optional: if nil, no tools available
+	// KnowledgeGraphNodeBudget caps how many outgoing edges a single node
+	// contributes to ParseKnowledgeGraph's BFS per hop, so a high-degree
+	// hub node can't dominate the pruned subgraph on its own. 0 (the
+	// zero value) means unbounded.
+	KnowledgeGraphNodeBudget int
+	Events                   []NPCTickEvent
+	ToolRegistry             *tools.ToolRegistry // Optional: if nil, no tools available
+	PromptOptions            PromptOptions       // Optional: selects a non-default prompt template, e.g. for A/B testing
+	// RuleSession, if set, is asserted a tool_result Fact for every tool the
+	// LLM invokes this tick, so reactive rules can fire follow-up tool calls
+	// without waiting for the LLM to ask for them. Their results are folded
+	// into the same InferenceRound as the LLM-driven tool calls.
+	RuleSession *rules.RuleSession
+	// MemoryRetriever, if set, retrieves this NPC's most relevant past
+	// memories and injects them into the system prompt before calling the
+	// LLM, then remembers this tick's surroundings/events afterward so
+	// future ticks can recall them without replaying full history.
+	MemoryRetriever *memory.Retriever
+	// Provider, if set, overrides the server's configured default LLM
+	// provider for this tick. Unset, CallLLM/CallLLMStream fall back to the
+	// provider built from LLM_PROVIDER at startup.
+	Provider llm.LLMProvider
+	// AgentRegistry, if set, resolves the acting NPC's AgentName into an
+	// agents.Agent. Unset (or an NPC with no AgentName), ticks behave
+	// exactly as if agents didn't exist.
+	AgentRegistry *agents.Registry
+	// Reactions, if set, is evaluated against Events/Surroundings/NPCState/
+	// KnowledgeGraph before the LLM is called, per tick and per
+	// continue_thinking round. A matching rule can add prompt hints, inject
+	// knowledge-graph nodes, force/forbid tools, or short-circuit the round
+	// with a canned response instead of calling the LLM at all. Unset,
+	// ticks behave exactly as if reactions didn't exist.
+	Reactions *reactions.Engine
 }
 type NPC struct {
 	Name            string
 	BackgroundStory string
+	// PromptTemplate, if set, pins this NPC to a specific prompt template
+	// ("archetype.locale" or "archetype.locale.vN"), overriding the default
+	// archetype/locale resolution unless NPCTickInput.PromptOptions.Template
+	// is also set, which wins.
+	PromptTemplate string
+	// KnowledgeGraph accumulates facts this NPC has learned, persisted
+	// alongside the NPC via Store (NPCStorage.UpdateKnowledgeGraph) so it
+	// survives a restart instead of living only in the per-tick
+	// NPCTickInput a caller happens to supply.
+	KnowledgeGraph kg.KnowledgeGraph
+	// AgentName, if set, names an agents.Agent (resolved at tick time via
+	// NPCTickInput.AgentRegistry) whose prompt template, allowed tool
+	// subset, default KnowledgeGraphDepth, and always-included context this
+	// NPC uses, falling back to whatever the per-tick request already set
+	// wherever the agent leaves a field at its zero value.
+	AgentName string
 }
 
 type NPCState struct {
@@ -118,33 +192,101 @@ type Surrounding struct {
 	Description string
 }
 
-func (n *NPC) ActForTick(input NPCTickInput) NPCTickResult {
-	return n.actForTickWithDepth(input, 0)
+func (n *NPC) ActForTick(ctx context.Context, input NPCTickInput) NPCTickResult {
+	start := time.Now()
+	result := n.actForTickWithDepth(ctx, input, 0)
+	result.RequestID = api.GetRequestID(ctx)
+
+	provider := "unknown"
+	if len(result.Rounds) > 0 {
+		if result.Rounds[0].Provider != "" {
+			provider = result.Rounds[0].Provider
+		}
+		metrics.ThinkingDepth.Observe(float64(len(result.Rounds) - 1))
+	}
+	metrics.TickDuration.WithLabelValues(provider).Observe(time.Since(start).Seconds())
+
+	return result
 }
 
 const maxThinkingDepth = 3
 
-func (n *NPC) actForTickWithDepth(input NPCTickInput, depth int) NPCTickResult {
+// buildTickRequest resolves promptOpts and input against n.AgentName (see
+// resolveAgent), evaluates input.Reactions (see reactToTick), renders
+// surroundings/knowledge-graph/events and the system prompt (injecting
+// retrieved memories when input.MemoryRetriever is set), and assembles the
+// resulting llm.LLMRequest. It returns the possibly-updated input
+// (ToolRegistry and KnowledgeGraphDepth may have been resolved from an
+// agent or a reaction) alongside it, since both actForTickWithDepth and
+// ActForTickStream need the resolved input for tool execution and
+// recursion. If a reaction short-circuits the round, shortCircuit is
+// non-nil and the llm.LLMRequest return is zero-valued: callers must return
+// it (or an equivalent TickEvent) without calling the LLM. Shared by both
+// the streaming and non-streaming tick paths so they can't drift apart.
+func (n *NPC) buildTickRequest(ctx context.Context, input NPCTickInput, depth int) (llm.LLMRequest, NPCTickInput, *NPCTickResult, error) {
+	promptOpts := input.PromptOptions
+	if promptOpts.Template == "" && n.PromptTemplate != "" {
+		promptOpts.Template = n.PromptTemplate
+	}
+	var alwaysInclude []string
+	fullToolRegistry := input.ToolRegistry
+	promptOpts, alwaysInclude, input.ToolRegistry, input.KnowledgeGraphDepth = n.resolveAgent(input, promptOpts)
+
+	var reactionHints []string
+	if input.Reactions != nil {
+		reactCtx := n.reactToTick(input)
+		if reactCtx.ShortCircuit != "" {
+			logging.Info("NPC tick short-circuited by a reaction rule", "npc_name", n.Name, "depth", depth)
+			round := InferenceRound{RoundNumber: depth + 1, LLMResponse: reactCtx.ShortCircuit, Success: true}
+			return llm.LLMRequest{}, input, &NPCTickResult{
+				Rounds:      []InferenceRound{round},
+				LLMResponse: reactCtx.ShortCircuit,
+				Success:     true,
+			}, nil
+		}
+
+		reactionHints = reactCtx.PromptHints
+		if len(reactCtx.InjectNodes) > 0 {
+			nodes := append([]kg.Node{}, input.KnowledgeGraph.Nodes...)
+			input.KnowledgeGraph.Nodes = append(nodes, reactCtx.InjectNodes...)
+		}
+		if input.ToolRegistry != nil && (len(reactCtx.ForceTools) > 0 || len(reactCtx.ForbidTools) > 0) {
+			input.ToolRegistry = applyToolReactions(fullToolRegistry, input.ToolRegistry, reactCtx)
+		}
+	}
+
 	surroundingsString, err := ParseSurroundings(input)
 	if err != nil {
-		logging.Error("Error parsing surroundings: %v", err)
-		return NPCTickResult{Success: false, ErrorMessage: fmt.Sprintf("Error parsing surroundings: %v", err)}
+		return llm.LLMRequest{}, input, nil, fmt.Errorf("error parsing surroundings: %w", err)
 	}
-	knowledgeGraphString, err := ParseKnowledgeGraph(input)
+	knowledgeGraphString, err := ParseKnowledgeGraph(n.Name, input)
 	if err != nil {
-		logging.Error("Error parsing knowledge graph: %v", err)
-		return NPCTickResult{Success: false, ErrorMessage: fmt.Sprintf("Error parsing knowledge graph: %v", err)}
+		return llm.LLMRequest{}, input, nil, fmt.Errorf("error parsing knowledge graph: %w", err)
 	}
+	metrics.KGPromptBytes.Observe(float64(len(knowledgeGraphString)))
 	eventsString, err := ParseEvents(input)
 	if err != nil {
-		logging.Error("Error parsing events: %v", err)
-		return NPCTickResult{Success: false, ErrorMessage: fmt.Sprintf("Error parsing events: %v", err)}
+		return llm.LLMRequest{}, input, nil, fmt.Errorf("error parsing events: %w", err)
+	}
+
+	systemPrompt := globalPromptRegistry().Build(n.Name, n.BackgroundStory, promptOpts)
+	for _, line := range alwaysInclude {
+		systemPrompt += "\n" + line
+	}
+	for _, hint := range reactionHints {
+		systemPrompt += "\n" + hint
 	}
 
-	systemPrompt := BuildNPCSystemPrompt(n.Name, n.BackgroundStory)
+	if input.MemoryRetriever != nil {
+		memoryBlock, err := input.MemoryRetriever.Inject(ctx, n.Name, surroundingsString+"\n"+eventsString, memory.DefaultTopK)
+		if err != nil {
+			logging.Error("Error retrieving memories: %v", err)
+		} else if memoryBlock != "" {
+			systemPrompt += "\n" + memoryBlock
+		}
+	}
 
-	// Log NPC action details
-	logging.Info("NPC ActForTick",
+	logging.Info("NPC tick",
 		"npc_name", n.Name,
 		"depth", depth,
 		"surroundings_count", len(input.Surroundings),
@@ -157,104 +299,158 @@ func (n *NPC) actForTickWithDepth(input NPCTickInput, depth int) NPCTickResult {
 		SystemPrompt: systemPrompt,
 		Prompt:       surroundingsString + "\n" + knowledgeGraphString + "\n" + eventsString,
 	}
-
-	// Add tools if available
 	if input.ToolRegistry != nil {
 		llmRequest.Tools = input.ToolRegistry.GetTools()
 	}
 
-	llmResponse, err := CallLLM(llmRequest)
-	if err != nil {
-		logging.Error("Error calling LLM: %v", err)
-		return NPCTickResult{Success: false, ErrorMessage: fmt.Sprintf("Error calling LLM: %v", err)}
+	return llmRequest, input, nil, nil
+}
+
+// reactToTick evaluates input.Reactions once per input.Events (or once with
+// a zero Event, if there are none, so event-agnostic rules relying only on
+// NPCState/Surroundings/KnowledgeGraph still fire), merging every event's
+// reactions.Context into one. It stops at the first event whose evaluation
+// sets ShortCircuit, since nothing after that point should still run.
+func (n *NPC) reactToTick(input NPCTickInput) reactions.Context {
+	events := input.Events
+	if len(events) == 0 {
+		events = []NPCTickEvent{{}}
 	}
 
-	var toolResults []ToolResult
+	merged := reactions.Context{}
+	for _, event := range events {
+		reactInput := reactions.Input{
+			NPCName:        n.Name,
+			Event:          reactions.Event{EventType: event.EventType, EventDescription: event.EventDescription},
+			NPCState:       input.NPCState,
+			KnowledgeGraph: input.KnowledgeGraph,
+		}
+		for _, s := range input.Surroundings {
+			reactInput.Surroundings = append(reactInput.Surroundings, reactions.Surrounding{Name: s.Name, Description: s.Description})
+		}
 
-	// Process any tool uses
-	if len(llmResponse.ToolUses) > 0 && input.ToolRegistry != nil {
-		ctx := context.Background()
-		for _, toolUse := range llmResponse.ToolUses {
-			logging.Info("NPC using tool",
-				"npc_name", n.Name,
-				"tool_name", toolUse.ToolName,
-				"args", toolUse.ToolArgs,
-			)
+		result := input.Reactions.Evaluate(reactInput)
+		merged.PromptHints = append(merged.PromptHints, result.PromptHints...)
+		merged.InjectNodes = append(merged.InjectNodes, result.InjectNodes...)
+		merged.ForceTools = append(merged.ForceTools, result.ForceTools...)
+		merged.ForbidTools = append(merged.ForbidTools, result.ForbidTools...)
+		if result.ShortCircuit != "" {
+			merged.ShortCircuit = result.ShortCircuit
+			return merged
+		}
+	}
 
-			result, err := input.ToolRegistry.ExecuteTool(ctx, n.Name, toolUse)
+	return merged
+}
 
-			toolResult := ToolResult{
-				ToolName: toolUse.ToolName,
-				Args:     toolUse.ToolArgs,
-				Success:  result.Success,
-				Response: result.Message,
-			}
+// applyToolReactions folds a reaction's ForceTools/ForbidTools into
+// current's effective tool set, sourcing forced tools from fullRegistry
+// (the registry before any agent AllowedTools subset was applied) so a
+// rule can reinstate a tool an agent would otherwise exclude; forbidden
+// tools are simply removed from whatever's currently allowed. A nil
+// fullRegistry falls back to current, so a forced tool that was never
+// registered anywhere is still a no-op rather than a panic.
+func applyToolReactions(fullRegistry, current *tools.ToolRegistry, reactCtx reactions.Context) *tools.ToolRegistry {
+	names := make(map[string]struct{})
+	for _, tool := range current.GetTools() {
+		names[tool.Name] = struct{}{}
+	}
+	for _, name := range reactCtx.ForceTools {
+		names[name] = struct{}{}
+	}
+	for _, name := range reactCtx.ForbidTools {
+		delete(names, name)
+	}
 
-			if err != nil {
-				logging.Error("Error executing tool: %v", err)
-				toolResult.Success = false
-				toolResult.Error = err.Error()
-			} else {
-				logging.Info("Tool execution completed",
-					"tool_name", toolUse.ToolName,
-					"success", result.Success,
-					"message", result.Message,
-				)
-			}
+	list := make([]string, 0, len(names))
+	for name := range names {
+		list = append(list, name)
+	}
 
-			toolResults = append(toolResults, toolResult)
+	base := fullRegistry
+	if base == nil {
+		base = current
+	}
+	return base.Subset(list)
+}
+
+// continuationInput builds the NPCTickInput for the next continue_thinking
+// round: toolResults become NPCTickEvents (so the next round's prompt
+// describes what just happened) while everything else about the scene
+// (surroundings, knowledge graph, tool registry, prompt options, etc.)
+// carries over unchanged. Shared by actForTickWithDepth's recursion and
+// ActForTickStream's round loop.
+func (n *NPC) continuationInput(input NPCTickInput, toolResults []ToolResult) NPCTickInput {
+	var newEvents []NPCTickEvent
+	for _, toolResult := range toolResults {
+		eventType := "tool_execution"
+		if !toolResult.Success {
+			eventType = "tool_error"
 		}
+
+		description := fmt.Sprintf("Tool '%s' executed", toolResult.ToolName)
+		if toolResult.Response != "" {
+			description += fmt.Sprintf(" - Response: %s", toolResult.Response)
+		}
+		if toolResult.Error != "" {
+			description += fmt.Sprintf(" - Error: %s", toolResult.Error)
+		}
+
+		newEvents = append(newEvents, NPCTickEvent{
+			EventType:        eventType,
+			EventDescription: description,
+		})
 	}
 
-	// Check if continue_thinking was used and we haven't exceeded depth limit
-	var usedContinueThinking bool
-	for _, toolUse := range llmResponse.ToolUses {
-		if toolUse.ToolName == "continue_thinking" {
-			usedContinueThinking = true
-			break
+	return NPCTickInput{
+		Surroundings:             input.Surroundings,
+		KnowledgeGraph:           input.KnowledgeGraph,
+		NPCState:                 input.NPCState,
+		KnowledgeGraphDepth:      input.KnowledgeGraphDepth,
+		KnowledgeGraphNodeBudget: input.KnowledgeGraphNodeBudget,
+		Events:                   newEvents,
+		ToolRegistry:             input.ToolRegistry,
+		PromptOptions:            input.PromptOptions,
+		MemoryRetriever:          input.MemoryRetriever,
+		Provider:                 input.Provider,
+		AgentRegistry:            input.AgentRegistry,
+		Reactions:                input.Reactions,
+	}
+}
+
+func (n *NPC) actForTickWithDepth(ctx context.Context, input NPCTickInput, depth int) NPCTickResult {
+	llmRequest, input, shortCircuit, err := n.buildTickRequest(ctx, input, depth)
+	if err != nil {
+		logging.Error("Error building tick request: %v", err)
+		return NPCTickResult{Success: false, ErrorMessage: err.Error()}
+	}
+	if shortCircuit != nil {
+		return *shortCircuit
+	}
+
+	llmResponse, err := CallLLM(ctx, input.Provider, llmRequest)
+	if err != nil {
+		logging.Error("Error calling LLM: %v", err)
+		return NPCTickResult{Success: false, ErrorMessage: fmt.Sprintf("Error calling LLM: %v", err), Err: err}
+	}
+
+	if input.MemoryRetriever != nil && llmResponse.Response != "" {
+		if err := input.MemoryRetriever.Remember(ctx, n.Name, llmResponse.Response, nil); err != nil {
+			logging.Error("Error remembering tick response: %v", err)
 		}
 	}
 
+	toolResults, usedContinueThinking := n.executeToolUses(ctx, input, llmResponse.ToolUses)
+
 	if usedContinueThinking && depth < maxThinkingDepth && input.ToolRegistry != nil {
 		logging.Info("NPC continuing thinking",
 			"npc_name", n.Name,
 			"current_depth", depth,
 		)
 
-		// Convert tool results to events for the next thinking round
-		var newEvents []NPCTickEvent
-		for _, toolResult := range toolResults {
-			eventType := "tool_execution"
-			if !toolResult.Success {
-				eventType = "tool_error"
-			}
-
-			description := fmt.Sprintf("Tool '%s' executed", toolResult.ToolName)
-			if toolResult.Response != "" {
-				description += fmt.Sprintf(" - Response: %s", toolResult.Response)
-			}
-			if toolResult.Error != "" {
-				description += fmt.Sprintf(" - Error: %s", toolResult.Error)
-			}
-
-			newEvents = append(newEvents, NPCTickEvent{
-				EventType:        eventType,
-				EventDescription: description,
-			})
-		}
-
-		// Create new input with tool results as events
-		continueInput := NPCTickInput{
-			Surroundings:        input.Surroundings,
-			KnowledgeGraph:      input.KnowledgeGraph,
-			NPCState:            input.NPCState,
-			KnowledgeGraphDepth: input.KnowledgeGraphDepth,
-			Events:              newEvents,
-			ToolRegistry:        input.ToolRegistry,
-		}
-
 		// Recursively call for continued thinking
-		continueResult := n.actForTickWithDepth(continueInput, depth+1)
+		continueInput := n.continuationInput(input, toolResults)
+		continueResult := n.actForTickWithDepth(ctx, continueInput, depth+1)
 
 		// Create current round
 		currentRound := InferenceRound{
@@ -262,6 +458,7 @@ func (n *NPC) actForTickWithDepth(input NPCTickInput, depth int) NPCTickResult {
 			LLMResponse: llmResponse.Response,
 			ToolsUsed:   toolResults,
 			Success:     true,
+			Provider:    llmResponse.Provider,
 		}
 
 		// Combine rounds from current and recursive calls
@@ -280,6 +477,7 @@ func (n *NPC) actForTickWithDepth(input NPCTickInput, depth int) NPCTickResult {
 			LLMResponse:  concatenatedResponse.String(),
 			Success:      continueResult.Success,
 			ErrorMessage: continueResult.ErrorMessage,
+			Err:          continueResult.Err,
 		}
 	}
 
@@ -289,6 +487,7 @@ func (n *NPC) actForTickWithDepth(input NPCTickInput, depth int) NPCTickResult {
 		LLMResponse: llmResponse.Response,
 		ToolsUsed:   toolResults,
 		Success:     true,
+		Provider:    llmResponse.Provider,
 	}
 
 	response := llmResponse.Response
@@ -303,10 +502,191 @@ func (n *NPC) actForTickWithDepth(input NPCTickInput, depth int) NPCTickResult {
 	}
 }
 
-func CallLLM(input llm.LLMRequest) (llm.LLMResponse, error) {
-	// TODO: This should be configurable to support multiple LLM providers
-	ollama := llm.NewOllama("11434")
-	return ollama.Generate(input)
+// executeToolUses runs every tool call in toolUses through
+// input.ToolRegistry, asserting a RuleSession fact for each when
+// input.RuleSession is set, and reports whether continue_thinking was among
+// them. A nil input.ToolRegistry is a no-op: toolUses must have come from an
+// LLM response that was never offered any tools.
+func (n *NPC) executeToolUses(ctx context.Context, input NPCTickInput, toolUses []llm.ToolUse) ([]ToolResult, bool) {
+	if input.ToolRegistry == nil {
+		return nil, false
+	}
+
+	var toolResults []ToolResult
+	var usedContinueThinking bool
+
+	for _, toolUse := range toolUses {
+		if toolUse.ToolName == "continue_thinking" {
+			usedContinueThinking = true
+		}
+
+		logging.Info("NPC using tool",
+			"npc_name", n.Name,
+			"tool_name", toolUse.ToolName,
+			"args", toolUse.ToolArgs,
+		)
+
+		toolStart := time.Now()
+		result, err := input.ToolRegistry.ExecuteTool(ctx, n.Name, toolUse)
+		metrics.ToolDuration.WithLabelValues(toolUse.ToolName).Observe(time.Since(toolStart).Seconds())
+
+		toolResult := ToolResult{
+			ToolName: toolUse.ToolName,
+			Args:     toolUse.ToolArgs,
+			Success:  result.Success,
+			Response: result.Message,
+		}
+
+		if err != nil {
+			logging.Error("Error executing tool: %v", err)
+			toolResult.Success = false
+			toolResult.Error = err.Error()
+		} else {
+			logging.Info("Tool execution completed",
+				"tool_name", toolUse.ToolName,
+				"success", result.Success,
+				"message", result.Message,
+			)
+		}
+		metrics.ToolExecutions.WithLabelValues(toolUse.ToolName, strconv.FormatBool(toolResult.Success)).Inc()
+
+		toolResults = append(toolResults, toolResult)
+
+		if input.RuleSession != nil {
+			toolResults = append(toolResults, n.assertToolResultFact(ctx, input.RuleSession, toolUse, result)...)
+		}
+	}
+
+	return toolResults, usedContinueThinking
+}
+
+// assertToolResultFact tells session about the tool the LLM just invoked,
+// and converts any rules it fires into ToolResult entries alongside the
+// LLM-driven one, so a reactive follow-up action shows up in the same
+// InferenceRound as the call that triggered it.
+func (n *NPC) assertToolResultFact(ctx context.Context, session *rules.RuleSession, toolUse llm.ToolUse, result tools.ToolResult) []ToolResult {
+	fired, err := session.Assert(ctx, rules.Fact{
+		Kind:  rules.KindToolResult,
+		NPCID: n.Name,
+		Key:   toolUse.ToolName,
+		Data: map[string]interface{}{
+			"success": result.Success,
+			"message": result.Message,
+		},
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		logging.Error("Error evaluating rules after tool execution", "error", err, "tool_name", toolUse.ToolName)
+		return nil
+	}
+
+	results := make([]ToolResult, 0, len(fired))
+	for _, f := range fired {
+		results = append(results, ToolResult{
+			ToolName: f.Rule.Action,
+			Args:     f.Rule.Args,
+			Success:  f.Result.Success,
+			Response: f.Result.Message,
+		})
+	}
+	return results
+}
+
+// resolveAgent applies n.AgentName's agents.Agent (via input.AgentRegistry)
+// on top of promptOpts/input, filling in whatever the per-tick request left
+// at its zero value: promptOpts.Template, the knowledge graph depth, and
+// the tool registry (restricted to the agent's AllowedTools, when set). It
+// returns the resolved promptOpts, the agent's AlwaysInclude context lines,
+// the effective tool registry, and the effective knowledge graph depth. An
+// unset AgentName, an unset AgentRegistry, or an unknown agent name are all
+// no-ops: the inputs are returned unchanged.
+func (n *NPC) resolveAgent(input NPCTickInput, promptOpts PromptOptions) (PromptOptions, []string, *tools.ToolRegistry, int) {
+	toolRegistry := input.ToolRegistry
+	depth := input.KnowledgeGraphDepth
+
+	if n.AgentName == "" || input.AgentRegistry == nil {
+		return promptOpts, nil, toolRegistry, depth
+	}
+	agent, ok := input.AgentRegistry.Get(n.AgentName)
+	if !ok {
+		logging.Warn("NPC references unknown agent", "npc_name", n.Name, "agent_name", n.AgentName)
+		return promptOpts, nil, toolRegistry, depth
+	}
+
+	if promptOpts.Template == "" {
+		promptOpts.Template = agent.PromptTemplate
+	}
+	if depth == 0 {
+		depth = agent.KnowledgeGraphDepth
+	}
+	if toolRegistry != nil && agent.AllowedTools != nil {
+		toolRegistry = toolRegistry.Subset(agent.AllowedTools)
+	}
+
+	return promptOpts, agent.AlwaysInclude, toolRegistry, depth
+}
+
+var (
+	defaultProviderOnce sync.Once
+	defaultProvider     llm.LLMProvider
+	defaultProviderErr  error
+)
+
+// defaultLLMProvider lazily builds the server's configured LLM provider
+// (LLM_PROVIDER, with retry/circuit-breaker/fallback wrapping) on first use
+// and reuses it afterward, so circuit-breaker and fallback state persist
+// across ticks instead of resetting on every call.
+func defaultLLMProvider() (llm.LLMProvider, error) {
+	defaultProviderOnce.Do(func() {
+		defaultProvider, defaultProviderErr = llm.NewProvider(cfg.ReadConfig())
+	})
+	return defaultProvider, defaultProviderErr
+}
+
+// CallLLM calls provider's Generate, falling back to the server's
+// configured default provider (see defaultLLMProvider) when provider is nil.
+func CallLLM(ctx context.Context, provider llm.LLMProvider, input llm.LLMRequest) (llm.LLMResponse, error) {
+	if provider == nil {
+		var err error
+		provider, err = defaultLLMProvider()
+		if err != nil {
+			return llm.LLMResponse{}, fmt.Errorf("no LLM provider available: %w", err)
+		}
+	}
+	response, err := provider.Generate(ctx, input)
+	if err == nil {
+		recordLLMTokens(response)
+	}
+	return response, err
+}
+
+// recordLLMTokens feeds response.Usage into metrics.LLMTokens, labeled by
+// the provider that produced it; a no-op if the provider didn't report
+// usage (e.g. ExternalProvider, or a mock in tests).
+func recordLLMTokens(response llm.LLMResponse) {
+	if response.Usage == nil {
+		return
+	}
+	provider := response.Provider
+	if provider == "" {
+		provider = "unknown"
+	}
+	metrics.LLMTokens.WithLabelValues(provider, "prompt").Add(float64(response.Usage.PromptTokens))
+	metrics.LLMTokens.WithLabelValues(provider, "completion").Add(float64(response.Usage.CompletionTokens))
+}
+
+// CallLLMStream is CallLLM's incremental counterpart, used by
+// ActForTickStream to deliver token and tool-call deltas as they arrive
+// instead of waiting for the full response.
+func CallLLMStream(ctx context.Context, provider llm.LLMProvider, input llm.LLMRequest) (<-chan llm.LLMChunk, error) {
+	if provider == nil {
+		var err error
+		provider, err = defaultLLMProvider()
+		if err != nil {
+			return nil, fmt.Errorf("no LLM provider available: %w", err)
+		}
+	}
+	return provider.GenerateStream(ctx, input)
 }
 
 func ParseSurroundings(input NPCTickInput) (string, error) {
@@ -318,20 +698,28 @@ func ParseSurroundings(input NPCTickInput) (string, error) {
 	return surroundingsString, nil
 }
 
-func ParseKnowledgeGraph(input NPCTickInput) (string, error) {
+// ParseKnowledgeGraph renders the portion of input.KnowledgeGraph within
+// input.KnowledgeGraphDepth hops of this tick's anchors: npcName itself,
+// plus any node whose Data["name"] matches a current Surrounding or appears
+// in an Event's description. A depth of 0 omits the knowledge graph
+// entirely, matching the prior (un-pruned) behavior's opt-out.
+func ParseKnowledgeGraph(npcName string, input NPCTickInput) (string, error) {
 	depth := input.KnowledgeGraphDepth
 	if depth == 0 {
 		return "<knowledge_graph></knowledge_graph>", nil
 	}
 
+	graph := kg.NewGraph(input.KnowledgeGraph)
+	subgraph := graph.BoundedSubgraph(knowledgeGraphAnchors(npcName, input), depth, input.KnowledgeGraphNodeBudget)
+
 	kgString := "<knowledge_graph>\n"
 	kgString += fmt.Sprintf("\t<nodes>\n")
-	for _, node := range input.KnowledgeGraph.Nodes {
+	for _, node := range subgraph.Graph.Nodes {
 		kgString += fmt.Sprintf("\t\t<node>\n\t\t\t<node_id>%s</node_id>\n\t\t\t<node_data>%s</node_data>\n\t\t</node>\n", node.ID, node.Data)
 	}
 	kgString += "\t</nodes>\n"
 	kgString += fmt.Sprintf("\t<edges>\n")
-	for _, edge := range input.KnowledgeGraph.Edges {
+	for _, edge := range subgraph.Graph.Edges {
 		kgString += fmt.Sprintf("\t\t<edge>\n\t\t\t<edge_source>%s</edge_source>\n\t\t\t<edge_target>%s</edge_target>\n\t\t\t<edge_data>%s</edge_data>\n\t\t</edge>\n", edge.Source, edge.Target, edge.Data)
 	}
 	kgString += fmt.Sprintf("\t</edges>\n")
@@ -340,6 +728,40 @@ func ParseKnowledgeGraph(input NPCTickInput) (string, error) {
 	return kgString, nil
 }
 
+// knowledgeGraphAnchors resolves ParseKnowledgeGraph's BFS starting points:
+// the NPC by name, every current Surrounding by name, and any node whose
+// name is referenced in an Event's description (e.g. "the Stranger handed
+// over a letter" should anchor on a "Stranger" node even without a
+// structured link).
+func knowledgeGraphAnchors(npcName string, input NPCTickInput) kg.AnchorResolver {
+	names := make([]string, 0, len(input.Surroundings)+1)
+	names = append(names, npcName)
+	for _, surrounding := range input.Surroundings {
+		names = append(names, surrounding.Name)
+	}
+
+	eventReferences := func(g *kg.Graph) []string {
+		var resolved []string
+		for _, node := range g.Query(func(n kg.Node) bool {
+			name, _ := n.Data["name"].(string)
+			if name == "" {
+				return false
+			}
+			for _, event := range input.Events {
+				if strings.Contains(event.EventDescription, name) {
+					return true
+				}
+			}
+			return false
+		}) {
+			resolved = append(resolved, node.ID)
+		}
+		return resolved
+	}
+
+	return kg.CombineAnchors(kg.ByName(names...), eventReferences)
+}
+
 func ParseEvents(input NPCTickInput) (string, error) {
 	if len(input.Events) == 0 {
 		return "<events_since_last_tick></events_since_last_tick>", nil
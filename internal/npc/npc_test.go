@@ -1,17 +1,50 @@
 package npc
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/piercegov/llm-npc-backend/internal/kg"
+	"github.com/piercegov/llm-npc-backend/internal/llm"
 	"github.com/piercegov/llm-npc-backend/internal/logging"
 )
 
+// stubProvider is a minimal llm.LLMProvider fake used to confirm CallLLM
+// routes to an explicit NPCTickInput.Provider override instead of the
+// config-driven default.
+type stubProvider struct {
+	response llm.LLMResponse
+}
+
+func (s *stubProvider) Generate(ctx context.Context, request llm.LLMRequest) (llm.LLMResponse, error) {
+	return s.response, nil
+}
+
+func (s *stubProvider) GenerateStream(ctx context.Context, request llm.LLMRequest) (<-chan llm.LLMChunk, error) {
+	ch := make(chan llm.LLMChunk, 1)
+	ch <- llm.LLMChunk{Content: s.response.Response, FinishReason: "stop"}
+	close(ch)
+	return ch, nil
+}
+
 func init() {
 	logging.InitLogger("debug")
 }
 
+func TestCallLLM_UsesExplicitProviderOverride(t *testing.T) {
+	provider := &stubProvider{response: llm.LLMResponse{Response: "hello from the override"}}
+
+	response, err := CallLLM(context.Background(), provider, llm.LLMRequest{Prompt: "hi"})
+	if err != nil {
+		t.Fatalf("CallLLM returned an error: %v", err)
+	}
+	if response.Response != "hello from the override" {
+		t.Errorf("expected the override provider's response, got %q", response.Response)
+	}
+}
+
 func TestParseSurroundings(t *testing.T) {
 	surroundings := []Surrounding{
 		{Name: "Surrounding 1", Description: "Description 1"},
@@ -33,12 +66,19 @@ func TestParseSurroundings(t *testing.T) {
 func TestParseKnowledgeGraph(t *testing.T) {
 	knowledgeGraph := kg.KnowledgeGraph{
 		Nodes: []kg.Node{
-			{ID: "Node 1", Data: map[string]interface{}{"name": "Node 1"}},
+			{ID: "npc-1", Data: map[string]interface{}{"name": "Elara"}},
+			{ID: "node-2", Data: map[string]interface{}{"name": "Node 2"}},
+			{ID: "node-3", Data: map[string]interface{}{"name": "Node 3"}},
+		},
+		Edges: []kg.Edge{
+			{Source: "npc-1", Target: "node-2"},
+			{Source: "node-2", Target: "node-3"},
 		},
 	}
 
-	// Test with depth > 0
-	knowledgeGraphString, err := ParseKnowledgeGraph(NPCTickInput{
+	// Anchored on the NPC's own name, depth 1 should reach node-2 but not
+	// the two-hop-away node-3.
+	knowledgeGraphString, err := ParseKnowledgeGraph("Elara", NPCTickInput{
 		KnowledgeGraph:      knowledgeGraph,
 		KnowledgeGraphDepth: 1,
 	})
@@ -47,13 +87,25 @@ func TestParseKnowledgeGraph(t *testing.T) {
 	}
 
 	fmt.Println(knowledgeGraphString)
-	expected := "<knowledge_graph>\n\t<nodes>\n\t\t<node>\n\t\t\t<node_id>Node 1</node_id>\n\t\t\t<node_data>map[name:Node 1]</node_data>\n\t\t</node>\n\t</nodes>\n\t<edges>\n\t</edges>\n</knowledge_graph>"
+	expected := "<knowledge_graph>\n\t<nodes>\n\t\t<node>\n\t\t\t<node_id>npc-1</node_id>\n\t\t\t<node_data>map[name:Elara]</node_data>\n\t\t</node>\n\t\t<node>\n\t\t\t<node_id>node-2</node_id>\n\t\t\t<node_data>map[name:Node 2]</node_data>\n\t\t</node>\n\t</nodes>\n\t<edges>\n\t\t<edge>\n\t\t\t<edge_source>npc-1</edge_source>\n\t\t\t<edge_target>node-2</edge_target>\n\t\t\t<edge_data>map[]</edge_data>\n\t\t</edge>\n\t</edges>\n</knowledge_graph>"
 	if knowledgeGraphString != expected {
 		t.Errorf("Expected %s, got %s", expected, knowledgeGraphString)
 	}
 
+	// Depth 2 should additionally reach node-3.
+	knowledgeGraphStringDeep, err := ParseKnowledgeGraph("Elara", NPCTickInput{
+		KnowledgeGraph:      knowledgeGraph,
+		KnowledgeGraphDepth: 2,
+	})
+	if err != nil {
+		t.Errorf("Error parsing knowledge graph at depth 2: %v", err)
+	}
+	if !strings.Contains(knowledgeGraphStringDeep, "node-3") {
+		t.Errorf("Expected depth-2 walk to reach node-3, got %s", knowledgeGraphStringDeep)
+	}
+
 	// Test with depth = 0
-	knowledgeGraphStringEmpty, err := ParseKnowledgeGraph(NPCTickInput{
+	knowledgeGraphStringEmpty, err := ParseKnowledgeGraph("Elara", NPCTickInput{
 		KnowledgeGraph:      knowledgeGraph,
 		KnowledgeGraphDepth: 0,
 	})
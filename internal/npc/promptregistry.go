@@ -0,0 +1,293 @@
+package npc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/piercegov/llm-npc-backend/internal/logging"
+)
+
+// templateFilenamePattern matches template files like "npc_system.innkeeper.en.v2.txt":
+// npc_system.<archetype>.<locale>.v<version>.txt
+var templateFilenamePattern = regexp.MustCompile(`^npc_system\.([a-zA-Z0-9_-]+)\.([a-zA-Z0-9_-]+)\.v(\d+)\.txt$`)
+
+const (
+	defaultArchetype = "default"
+	defaultLocale    = "en"
+)
+
+// fallbackPromptTemplate is used when no on-disk template matches, e.g. a
+// fresh checkout whose prompts/ directory hasn't been populated yet.
+const fallbackPromptTemplate = `You are playing the role of %s, a character in a video game.
+
+Background: %s
+
+IMPORTANT INSTRUCTIONS:
+1. If you want to speak, you must use the speak tool.
+2. Do NOT include any meta-commentary, stage directions, or actions outside of thinking tags unless they are tool calls.
+3. Stay in character at all times when speaking.
+4. Use tools when appropriate. If you want to speak, use the speak tool. If you want to remember something for later, use the scratchpad tools.
+
+There is no actual user, think of the user as the game itself. You are a character in a video game. You are interacting with the world around you, as well as other characters.
+You don't always need to do something. If you don't have anything to do, you can just think.`
+
+// TemplateMeta describes a single loaded prompt template, as reported by GET /npc/prompts.
+type TemplateMeta struct {
+	Name         string    `json:"name"` // "<archetype>.<locale>.v<version>"
+	Archetype    string    `json:"archetype"`
+	Locale       string    `json:"locale"`
+	Version      int       `json:"version"`
+	SHA          string    `json:"sha"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+type promptTemplate struct {
+	meta    TemplateMeta
+	content string
+}
+
+// PromptOptions selects which template PromptRegistry.Build should render.
+type PromptOptions struct {
+	// Template, if set, pins an exact "archetype.locale" (latest version) or
+	// "archetype.locale.vN" name. Populated from NPC.PromptTemplate or the
+	// "prompt_variant" query parameter on POST /npc/act, letting a designer
+	// A/B different templates against the same NPC. Takes precedence over
+	// Archetype/Locale below.
+	Template string
+	// Archetype selects a character template family, e.g. "innkeeper". Empty
+	// means "default".
+	Archetype string
+	// Locale selects a template's language/region, e.g. "en". Empty means "en".
+	Locale string
+}
+
+// PromptRegistry loads NPC system prompt templates from a directory and
+// watches it for changes via fsnotify, so a game designer can iterate on
+// prompts without restarting the backend. Templates are named
+// "npc_system.<archetype>.<locale>.v<version>.txt"; the highest version for a
+// given archetype/locale is used unless PromptOptions.Template pins one
+// exactly.
+type PromptRegistry struct {
+	mu        sync.RWMutex
+	dir       string
+	templates map[string]promptTemplate // key: "archetype.locale.vN"
+	watcher   *fsnotify.Watcher
+	stop      chan struct{}
+}
+
+// NewPromptRegistry creates a PromptRegistry rooted at dir, performs an
+// initial load, and starts watching dir for changes. A missing dir is not an
+// error: the registry starts empty and Build falls back to the embedded
+// default template until prompts/ is created and reloaded.
+func NewPromptRegistry(dir string) (*PromptRegistry, error) {
+	r := &PromptRegistry{
+		dir:       dir,
+		templates: make(map[string]promptTemplate),
+		stop:      make(chan struct{}),
+	}
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("creating prompt registry watcher: %w", err)
+	}
+	if err := watcher.Add(dir); err != nil {
+		logging.Warn("Prompt registry: not watching prompts directory", "dir", dir, "error", err)
+		watcher.Close()
+	} else {
+		r.watcher = watcher
+		go r.watch()
+	}
+
+	return r, nil
+}
+
+func (r *PromptRegistry) watch() {
+	for {
+		select {
+		case event, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				logging.Error("Prompt registry: reload failed", "error", err)
+			} else {
+				logging.Info("Prompt registry: reloaded templates after filesystem change", "dir", r.dir, "path", event.Name)
+			}
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			logging.Error("Prompt registry: watcher error", "error", err)
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// Reload rescans the templates directory. It's also called by POST
+// /npc/prompts/reload so an operator can force a reload without waiting on
+// fsnotify, e.g. on filesystems where it isn't reliable.
+func (r *PromptRegistry) Reload() error {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			r.mu.Lock()
+			r.templates = make(map[string]promptTemplate)
+			r.mu.Unlock()
+			return nil
+		}
+		return fmt.Errorf("reading prompts directory %q: %w", r.dir, err)
+	}
+
+	loaded := make(map[string]promptTemplate, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := templateFilenamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		archetype, locale := match[1], match[2]
+		version, err := strconv.Atoi(match[3])
+		if err != nil {
+			continue
+		}
+
+		path := filepath.Join(r.dir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			logging.Warn("Prompt registry: failed to read template", "path", path, "error", err)
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			logging.Warn("Prompt registry: failed to stat template", "path", path, "error", err)
+			continue
+		}
+
+		sum := sha256.Sum256(content)
+		key := fmt.Sprintf("%s.%s.v%d", archetype, locale, version)
+		loaded[key] = promptTemplate{
+			meta: TemplateMeta{
+				Name:         key,
+				Archetype:    archetype,
+				Locale:       locale,
+				Version:      version,
+				SHA:          hex.EncodeToString(sum[:]),
+				LastModified: info.ModTime(),
+			},
+			content: string(content),
+		}
+	}
+
+	r.mu.Lock()
+	r.templates = loaded
+	r.mu.Unlock()
+	return nil
+}
+
+// List returns metadata for every loaded template, sorted by name.
+func (r *PromptRegistry) List() []TemplateMeta {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	metas := make([]TemplateMeta, 0, len(r.templates))
+	for _, tmpl := range r.templates {
+		metas = append(metas, tmpl.meta)
+	}
+	sort.Slice(metas, func(i, j int) bool { return metas[i].Name < metas[j].Name })
+	return metas
+}
+
+// Build renders the system prompt for an NPC, selecting a template per opts.
+// Resolution order:
+//  1. opts.Template, exact ("archetype.locale.vN") or version-less
+//     ("archetype.locale", latest version).
+//  2. The latest version of opts.Archetype/opts.Locale ("default"/"en" if unset).
+//  3. The latest version of the "default" archetype in that locale.
+//  4. The embedded fallback template.
+func (r *PromptRegistry) Build(name, backgroundStory string, opts PromptOptions) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if opts.Template != "" {
+		if tmpl, ok := r.lookupLocked(opts.Template); ok {
+			return fmt.Sprintf(tmpl.content, name, backgroundStory)
+		}
+	}
+
+	archetype := opts.Archetype
+	if archetype == "" {
+		archetype = defaultArchetype
+	}
+	locale := opts.Locale
+	if locale == "" {
+		locale = defaultLocale
+	}
+
+	if tmpl, ok := r.latestLocked(archetype, locale); ok {
+		return fmt.Sprintf(tmpl.content, name, backgroundStory)
+	}
+	if archetype != defaultArchetype {
+		if tmpl, ok := r.latestLocked(defaultArchetype, locale); ok {
+			return fmt.Sprintf(tmpl.content, name, backgroundStory)
+		}
+	}
+
+	return fmt.Sprintf(fallbackPromptTemplate, name, backgroundStory)
+}
+
+// lookupLocked resolves an exact or version-less template name. Callers must hold r.mu.
+func (r *PromptRegistry) lookupLocked(name string) (promptTemplate, bool) {
+	if tmpl, ok := r.templates[name]; ok {
+		return tmpl, true
+	}
+	archetype, locale, ok := strings.Cut(name, ".")
+	if !ok {
+		return promptTemplate{}, false
+	}
+	return r.latestLocked(archetype, locale)
+}
+
+// latestLocked returns the highest-versioned template for archetype/locale. Callers must hold r.mu.
+func (r *PromptRegistry) latestLocked(archetype, locale string) (promptTemplate, bool) {
+	var best promptTemplate
+	found := false
+	for _, tmpl := range r.templates {
+		if tmpl.meta.Archetype != archetype || tmpl.meta.Locale != locale {
+			continue
+		}
+		if !found || tmpl.meta.Version > best.meta.Version {
+			best = tmpl
+			found = true
+		}
+	}
+	return best, found
+}
+
+// Close stops the registry's filesystem watcher.
+func (r *PromptRegistry) Close() error {
+	close(r.stop)
+	if r.watcher != nil {
+		return r.watcher.Close()
+	}
+	return nil
+}
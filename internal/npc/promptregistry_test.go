@@ -0,0 +1,111 @@
+package npc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTemplate(t *testing.T, dir, filename, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, filename), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write template %s: %v", filename, err)
+	}
+}
+
+func TestPromptRegistry_BuildSelectsLatestVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "npc_system.innkeeper.en.v1.txt", "v1: %s / %s")
+	writeTemplate(t, dir, "npc_system.innkeeper.en.v2.txt", "v2: %s / %s")
+
+	reg, err := NewPromptRegistry(dir)
+	if err != nil {
+		t.Fatalf("NewPromptRegistry() error = %v", err)
+	}
+	defer reg.Close()
+
+	got := reg.Build("Elara", "An innkeeper", PromptOptions{Archetype: "innkeeper", Locale: "en"})
+	want := "v2: Elara / An innkeeper"
+	if got != want {
+		t.Errorf("Build() = %q, want %q", got, want)
+	}
+}
+
+func TestPromptRegistry_BuildPinnedTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "npc_system.innkeeper.en.v1.txt", "v1: %s / %s")
+	writeTemplate(t, dir, "npc_system.innkeeper.en.v2.txt", "v2: %s / %s")
+
+	reg, err := NewPromptRegistry(dir)
+	if err != nil {
+		t.Fatalf("NewPromptRegistry() error = %v", err)
+	}
+	defer reg.Close()
+
+	got := reg.Build("Elara", "An innkeeper", PromptOptions{Template: "innkeeper.en.v1"})
+	want := "v1: Elara / An innkeeper"
+	if got != want {
+		t.Errorf("Build() with pinned template = %q, want %q", got, want)
+	}
+}
+
+func TestPromptRegistry_BuildFallsBackToEmbeddedTemplate(t *testing.T) {
+	reg, err := NewPromptRegistry(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewPromptRegistry() error = %v", err)
+	}
+	defer reg.Close()
+
+	got := reg.Build("Elara", "An innkeeper", PromptOptions{Archetype: "innkeeper", Locale: "en"})
+	if got == "" {
+		t.Error("Build() with no matching templates should fall back to the embedded default, got empty string")
+	}
+}
+
+func TestPromptRegistry_List(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "npc_system.innkeeper.en.v1.txt", "v1: %s / %s")
+	writeTemplate(t, dir, "npc_system.guard.en.v1.txt", "guard: %s / %s")
+	writeTemplate(t, dir, "not_a_template.txt", "ignored")
+
+	reg, err := NewPromptRegistry(dir)
+	if err != nil {
+		t.Fatalf("NewPromptRegistry() error = %v", err)
+	}
+	defer reg.Close()
+
+	templates := reg.List()
+	if len(templates) != 2 {
+		t.Fatalf("List() returned %d templates, want 2: %+v", len(templates), templates)
+	}
+	if templates[0].Name != "guard.en.v1" || templates[1].Name != "innkeeper.en.v1" {
+		t.Errorf("List() not sorted by name: %+v", templates)
+	}
+	for _, tmpl := range templates {
+		if tmpl.SHA == "" {
+			t.Errorf("expected a non-empty SHA for template %s", tmpl.Name)
+		}
+	}
+}
+
+func TestPromptRegistry_Reload(t *testing.T) {
+	dir := t.TempDir()
+	reg, err := NewPromptRegistry(dir)
+	if err != nil {
+		t.Fatalf("NewPromptRegistry() error = %v", err)
+	}
+	defer reg.Close()
+
+	if len(reg.List()) != 0 {
+		t.Fatalf("expected an empty registry before any templates are written")
+	}
+
+	writeTemplate(t, dir, "npc_system.innkeeper.en.v1.txt", "v1: %s / %s")
+	if err := reg.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	if len(reg.List()) != 1 {
+		t.Fatalf("expected 1 template after Reload(), got %d", len(reg.List()))
+	}
+}
@@ -1,27 +1,50 @@
 package npc
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
 	"github.com/google/uuid"
+	"github.com/piercegov/llm-npc-backend/internal/kg"
+	"github.com/piercegov/llm-npc-backend/internal/logging"
 )
 
-// NPCStorage provides thread-safe in-memory storage for NPCs
+// NPCStorage provides thread-safe NPC storage. A Store backs it for
+// durability; an in-memory cache mirrors the store so reads never touch
+// disk, while every write goes through the store first.
 type NPCStorage struct {
-	npcs map[string]*NPC
-	mu   sync.RWMutex
+	store Store
+	mu    sync.RWMutex
+	npcs  map[string]*NPC
 }
 
-// NewNPCStorage creates a new NPC storage instance
+// NewNPCStorage creates NPC storage backed by an in-memory Store only: NPCs
+// do not survive a restart. Use NewNPCStorageWithStore for a durable backend.
 func NewNPCStorage() *NPCStorage {
-	return &NPCStorage{
-		npcs: make(map[string]*NPC),
+	return NewNPCStorageWithStore(NewMemoryStore())
+}
+
+// NewNPCStorageWithStore creates NPC storage backed by store, loading
+// whatever NPCs it already holds (e.g. from a previous run) into the
+// in-memory cache.
+func NewNPCStorageWithStore(store Store) *NPCStorage {
+	s := &NPCStorage{store: store, npcs: make(map[string]*NPC)}
+
+	existing, err := store.List(context.Background())
+	if err != nil {
+		logging.Error("Failed to load NPCs from store", "error", err)
+	} else {
+		s.npcs = existing
 	}
+
+	return s
 }
 
-// Register adds a new NPC and returns its generated ID
-func (s *NPCStorage) Register(name, backgroundStory string) (string, error) {
+// Register adds a new NPC and returns its generated ID. agentName is
+// optional ("" means the NPC uses no agents.Agent) and is resolved against
+// NPCTickInput.AgentRegistry at tick time, not here.
+func (s *NPCStorage) Register(name, backgroundStory, agentName string) (string, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -32,6 +55,11 @@ func (s *NPCStorage) Register(name, backgroundStory string) (string, error) {
 	npc := &NPC{
 		Name:            name,
 		BackgroundStory: backgroundStory,
+		AgentName:       agentName,
+	}
+
+	if err := s.store.Put(context.Background(), id, npc); err != nil {
+		return "", fmt.Errorf("persist NPC: %w", err)
 	}
 
 	// Store NPC
@@ -76,6 +104,10 @@ func (s *NPCStorage) Delete(id string) error {
 		return fmt.Errorf("NPC with ID %s not found", id)
 	}
 
+	if err := s.store.Delete(context.Background(), id); err != nil {
+		return fmt.Errorf("delete persisted NPC: %w", err)
+	}
+
 	delete(s.npcs, id)
 	return nil
 }
@@ -86,4 +118,27 @@ func (s *NPCStorage) Count() int {
 	defer s.mu.RUnlock()
 
 	return len(s.npcs)
-}
\ No newline at end of file
+}
+
+// UpdateKnowledgeGraph replaces the persisted KnowledgeGraph for an NPC, so
+// facts it has learned survive a restart alongside its name and background
+// story.
+func (s *NPCStorage) UpdateKnowledgeGraph(id string, graph kg.KnowledgeGraph) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	npc, exists := s.npcs[id]
+	if !exists {
+		return fmt.Errorf("NPC with ID %s not found", id)
+	}
+
+	updated := *npc
+	updated.KnowledgeGraph = graph
+
+	if err := s.store.Put(context.Background(), id, &updated); err != nil {
+		return fmt.Errorf("persist NPC knowledge graph: %w", err)
+	}
+
+	s.npcs[id] = &updated
+	return nil
+}
@@ -0,0 +1,304 @@
+package npc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// Store is the persistence layer behind NPCStorage, mirroring
+// tools.SessionStore: implementations must be safe for concurrent use, and
+// persist an NPC's KnowledgeGraph alongside it so facts an NPC has learned
+// survive a restart along with its name and background story.
+type Store interface {
+	Get(ctx context.Context, id string) (*NPC, error)
+	Put(ctx context.Context, id string, npc *NPC) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) (map[string]*NPC, error)
+}
+
+// ErrNPCNotFound is returned by Store implementations when an NPC ID is unknown.
+var ErrNPCNotFound = errors.New("npc not found")
+
+// NewStore builds a Store from a backend URL, selected by scheme:
+//
+//	memory://                in-memory map, the default, does not survive restarts
+//	file:///path/to/npcs.json a JSON-file snapshot, rewritten atomically on every change
+//	bolt:///path/to/npcs.db   a local BoltDB-backed store
+//
+// An empty rawURL defaults to "memory://".
+func NewStore(rawURL string) (Store, error) {
+	if rawURL == "" {
+		rawURL = "memory://"
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid npc store URL %q: %w", rawURL, err)
+	}
+
+	switch parsed.Scheme {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "file", "json":
+		path := parsed.Path
+		if path == "" {
+			path = parsed.Opaque
+		}
+		return NewJSONFileStore(path)
+	case "bolt", "boltdb":
+		path := parsed.Path
+		if path == "" {
+			path = parsed.Opaque
+		}
+		return NewBoltStore(path)
+	default:
+		return nil, fmt.Errorf("unsupported npc store scheme: %s", parsed.Scheme)
+	}
+}
+
+// MemoryStore is the original in-memory Store implementation.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	npcs map[string]*NPC
+}
+
+// NewMemoryStore creates an empty in-memory NPC store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{npcs: make(map[string]*NPC)}
+}
+
+func (m *MemoryStore) Get(ctx context.Context, id string) (*NPC, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	npc, exists := m.npcs[id]
+	if !exists {
+		return nil, ErrNPCNotFound
+	}
+	clone := *npc
+	return &clone, nil
+}
+
+func (m *MemoryStore) Put(ctx context.Context, id string, npc *NPC) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	clone := *npc
+	m.npcs[id] = &clone
+	return nil
+}
+
+func (m *MemoryStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.npcs[id]; !exists {
+		return ErrNPCNotFound
+	}
+	delete(m.npcs, id)
+	return nil
+}
+
+func (m *MemoryStore) List(ctx context.Context) (map[string]*NPC, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[string]*NPC, len(m.npcs))
+	for id, npc := range m.npcs {
+		clone := *npc
+		result[id] = &clone
+	}
+	return result, nil
+}
+
+// jsonFileSnapshot is the on-disk shape JSONFileStore reads and writes.
+type jsonFileSnapshot struct {
+	NPCs map[string]*NPC `json:"npcs"`
+}
+
+// JSONFileStore persists every NPC as a single JSON snapshot file,
+// rewritten atomically on each change. Simpler and more inspectable than a
+// database for small NPC rosters, at the cost of rewriting the whole file
+// on every write.
+type JSONFileStore struct {
+	path string
+	mu   sync.Mutex
+	npcs map[string]*NPC
+}
+
+// NewJSONFileStore opens (or creates) a JSON snapshot file at path, loading
+// any NPCs already recorded there.
+func NewJSONFileStore(path string) (*JSONFileStore, error) {
+	s := &JSONFileStore{path: path, npcs: make(map[string]*NPC)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read npc snapshot: %w", err)
+	}
+
+	var snapshot jsonFileSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("parse npc snapshot: %w", err)
+	}
+	if snapshot.NPCs != nil {
+		s.npcs = snapshot.NPCs
+	}
+	return s, nil
+}
+
+func (s *JSONFileStore) Get(ctx context.Context, id string) (*NPC, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	npc, exists := s.npcs[id]
+	if !exists {
+		return nil, ErrNPCNotFound
+	}
+	clone := *npc
+	return &clone, nil
+}
+
+func (s *JSONFileStore) Put(ctx context.Context, id string, npc *NPC) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clone := *npc
+	s.npcs[id] = &clone
+	return s.saveLocked()
+}
+
+func (s *JSONFileStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.npcs[id]; !exists {
+		return ErrNPCNotFound
+	}
+	delete(s.npcs, id)
+	return s.saveLocked()
+}
+
+func (s *JSONFileStore) List(ctx context.Context) (map[string]*NPC, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make(map[string]*NPC, len(s.npcs))
+	for id, npc := range s.npcs {
+		clone := *npc
+		result[id] = &clone
+	}
+	return result, nil
+}
+
+// saveLocked rewrites the snapshot file atomically: it writes to a temp file
+// in the same directory and renames it over the original, so a crash
+// mid-write never leaves a corrupt snapshot behind. Callers must hold s.mu.
+func (s *JSONFileStore) saveLocked() error {
+	data, err := json.MarshalIndent(jsonFileSnapshot{NPCs: s.npcs}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+var npcsBucket = []byte("npcs")
+
+// BoltStore persists NPCs to a local BoltDB file, the same approach
+// tools.FileSessionStore and webhook.BoltDeliveryStore use.
+type BoltStore struct {
+	db *bbolt.DB
+}
+
+// NewBoltStore opens (creating if necessary) a BoltDB-backed NPC store at path.
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(npcsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Close closes the underlying BoltDB file.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *BoltStore) Get(ctx context.Context, id string) (*NPC, error) {
+	var npc *NPC
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(npcsBucket).Get([]byte(id))
+		if data == nil {
+			return ErrNPCNotFound
+		}
+		npc = &NPC{}
+		return json.Unmarshal(data, npc)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return npc, nil
+}
+
+func (s *BoltStore) Put(ctx context.Context, id string, npc *NPC) error {
+	data, err := json.Marshal(npc)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(npcsBucket).Put([]byte(id), data)
+	})
+}
+
+func (s *BoltStore) Delete(ctx context.Context, id string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(npcsBucket)
+		if b.Get([]byte(id)) == nil {
+			return ErrNPCNotFound
+		}
+		return b.Delete([]byte(id))
+	})
+}
+
+func (s *BoltStore) List(ctx context.Context) (map[string]*NPC, error) {
+	result := make(map[string]*NPC)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(npcsBucket).ForEach(func(k, v []byte) error {
+			var npc NPC
+			if err := json.Unmarshal(v, &npc); err != nil {
+				return err
+			}
+			result[string(k)] = &npc
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
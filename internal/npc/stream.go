@@ -0,0 +1,251 @@
+package npc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/piercegov/llm-npc-backend/internal/llm"
+	"github.com/piercegov/llm-npc-backend/internal/logging"
+)
+
+// TickEvent is one incremental update from NPC.ActForTickStream: token
+// output, tool-call assembly, tool execution, and round/continuation
+// boundaries, across however many continue_thinking rounds a tick takes.
+// Type determines which of the other fields are populated; see the
+// TickEvent* constants.
+type TickEvent struct {
+	Type string
+
+	// Round is the thinking round this event belongs to, starting at 0.
+	Round int
+
+	// TokenDelta/TokenContent are set on TickEventTokenDelta: TokenDelta is
+	// the text this chunk added, TokenContent the full response accumulated
+	// so far this round.
+	TokenDelta   string
+	TokenContent string
+
+	// ToolCallID/ToolCallName are set on TickEventToolCallStart, identifying
+	// the tool call that this and any following TickEventToolCallArgsDelta
+	// events with the same ToolCallID build up.
+	ToolCallID   string
+	ToolCallName string
+
+	// ToolCallArgsFragment is set on TickEventToolCallArgsDelta: the raw
+	// JSON text fragment this chunk appended to ToolCallID's arguments.
+	ToolCallArgsFragment string
+
+	// ToolResult is set on TickEventToolResult, once a tool call from this
+	// round has finished executing.
+	ToolResult *ToolResult
+
+	// Result is set on TickEventRoundComplete (this round's InferenceRound
+	// only) and on TickEventDone (the full, final NPCTickResult across every
+	// round, in the same shape ActForTick would have returned).
+	Result *NPCTickResult
+
+	// Err is set on TickEventDone when the tick failed.
+	Err error
+}
+
+const (
+	// TickEventTokenDelta is emitted for every non-empty text delta in a round.
+	TickEventTokenDelta = "token_delta"
+	// TickEventToolCallStart is emitted the first time a tool call's name
+	// arrives in an LLMChunk's ToolCallDelta.
+	TickEventToolCallStart = "tool_call_start"
+	// TickEventToolCallArgsDelta is emitted for every argument fragment of
+	// an in-progress tool call. Only fires for providers whose LLMChunk sets
+	// ToolCallDelta; providers that only ever deliver complete tool calls
+	// emit neither this nor TickEventToolCallStart, only TickEventToolResult.
+	TickEventToolCallArgsDelta = "tool_call_args_delta"
+	// TickEventToolResult is emitted once per tool call after it executes.
+	TickEventToolResult = "tool_result"
+	// TickEventThinkingContinued is emitted when continue_thinking was used
+	// and another round is about to start.
+	TickEventThinkingContinued = "thinking_continued"
+	// TickEventRoundComplete is emitted once a round's tool calls (if any)
+	// have all executed, whether or not it continues into another round.
+	TickEventRoundComplete = "round_complete"
+	// TickEventDone is the terminal event: exactly one is sent, after which
+	// the channel is closed.
+	TickEventDone = "done"
+)
+
+// ActForTickStream is a streaming sibling of ActForTick: instead of blocking
+// until every continue_thinking round finishes, it emits a TickEvent as
+// soon as each piece of progress happens - a token delta, a tool call
+// starting to assemble, a tool finishing execution, a round completing - so
+// a caller can render an NPC's response live instead of waiting out
+// maxThinkingDepth rounds of inference. The first round's request is built
+// synchronously, so setup errors (template/knowledge-graph parsing, a
+// failed initial LLM call) are returned directly; everything from there
+// runs in a goroutine that emits events until a terminal TickEventDone,
+// then closes the channel.
+func (n *NPC) ActForTickStream(ctx context.Context, input NPCTickInput) (<-chan TickEvent, error) {
+	llmRequest, input, shortCircuit, err := n.buildTickRequest(ctx, input, 0)
+	if err != nil {
+		return nil, err
+	}
+	if shortCircuit != nil {
+		events := make(chan TickEvent, 1)
+		events <- TickEvent{Type: TickEventDone, Result: shortCircuit}
+		close(events)
+		return events, nil
+	}
+
+	chunks, err := CallLLMStream(ctx, input.Provider, llmRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan TickEvent)
+	go n.streamRounds(ctx, input, chunks, events)
+	return events, nil
+}
+
+// streamRounds drains chunks for the current round, translating each into
+// TickEvents, executes any tool calls, decides whether to continue
+// thinking, and either streams the next round or emits the terminal
+// TickEventDone. It closes events before returning.
+func (n *NPC) streamRounds(ctx context.Context, input NPCTickInput, chunks <-chan llm.LLMChunk, events chan<- TickEvent) {
+	defer close(events)
+
+	var allRounds []InferenceRound
+	var responseParts []string
+	depth := 0
+
+	for {
+		llmResponse, err := n.streamOneRound(ctx, input, chunks, depth, events)
+		if err != nil {
+			logging.Error("NPC tick stream: error streaming round: %v", err)
+			events <- TickEvent{Type: TickEventDone, Round: depth, Err: err, Result: &NPCTickResult{
+				Rounds:       allRounds,
+				LLMResponse:  strings.Join(responseParts, "\n"),
+				ErrorMessage: fmt.Sprintf("Error calling LLM: %v", err),
+				Err:          err,
+			}}
+			return
+		}
+
+		toolResults, usedContinueThinking := n.executeToolUses(ctx, input, llmResponse.ToolUses)
+		for _, toolResult := range toolResults {
+			toolResult := toolResult
+			events <- TickEvent{Type: TickEventToolResult, Round: depth, ToolResult: &toolResult}
+		}
+
+		round := InferenceRound{
+			RoundNumber: depth + 1,
+			LLMResponse: llmResponse.Response,
+			ToolsUsed:   toolResults,
+			Success:     true,
+		}
+		allRounds = append(allRounds, round)
+
+		continuing := usedContinueThinking && depth < maxThinkingDepth && input.ToolRegistry != nil
+
+		part := llmResponse.Response
+		if continuing || depth > 0 {
+			part = fmt.Sprintf("=== Inference %d ===\n%s", round.RoundNumber, llmResponse.Response)
+		}
+		responseParts = append(responseParts, part)
+
+		events <- TickEvent{Type: TickEventRoundComplete, Round: depth, Result: &NPCTickResult{
+			Rounds:      []InferenceRound{round},
+			LLMResponse: part,
+			Success:     true,
+		}}
+
+		if !continuing {
+			events <- TickEvent{Type: TickEventDone, Round: depth, Result: &NPCTickResult{
+				Rounds:      allRounds,
+				LLMResponse: strings.Join(responseParts, "\n"),
+				Success:     true,
+			}}
+			return
+		}
+
+		events <- TickEvent{Type: TickEventThinkingContinued, Round: depth}
+
+		input = n.continuationInput(input, toolResults)
+		depth++
+
+		var llmRequest llm.LLMRequest
+		var shortCircuit *NPCTickResult
+		llmRequest, input, shortCircuit, err = n.buildTickRequest(ctx, input, depth)
+		if err != nil {
+			logging.Error("NPC tick stream: error building round %d request: %v", depth, err)
+			events <- TickEvent{Type: TickEventDone, Round: depth, Err: err, Result: &NPCTickResult{
+				Rounds:       allRounds,
+				LLMResponse:  strings.Join(responseParts, "\n"),
+				ErrorMessage: err.Error(),
+				Err:          err,
+			}}
+			return
+		}
+		if shortCircuit != nil {
+			shortCircuit.Rounds = append(allRounds, shortCircuit.Rounds...)
+			shortCircuit.LLMResponse = strings.Join(append(responseParts, shortCircuit.LLMResponse), "\n")
+			events <- TickEvent{Type: TickEventDone, Round: depth, Result: shortCircuit}
+			return
+		}
+
+		chunks, err = CallLLMStream(ctx, input.Provider, llmRequest)
+		if err != nil {
+			logging.Error("NPC tick stream: error starting round %d stream: %v", depth, err)
+			events <- TickEvent{Type: TickEventDone, Round: depth, Err: err, Result: &NPCTickResult{
+				Rounds:       allRounds,
+				LLMResponse:  strings.Join(responseParts, "\n"),
+				ErrorMessage: err.Error(),
+				Err:          err,
+			}}
+			return
+		}
+	}
+}
+
+// streamOneRound drains chunks to completion, relaying token deltas and
+// tool-call assembly progress as TickEvents, and returns the equivalent of
+// what a non-streaming Generate call would have returned for the same
+// round, so the caller can execute tools and decide on continuation exactly
+// as actForTickWithDepth does. It remembers the round's response via
+// input.MemoryRetriever, matching actForTickWithDepth's behavior.
+func (n *NPC) streamOneRound(ctx context.Context, input NPCTickInput, chunks <-chan llm.LLMChunk, depth int, events chan<- TickEvent) (llm.LLMResponse, error) {
+	startedToolCalls := make(map[string]bool)
+	var last llm.LLMChunk
+
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			return llm.LLMResponse{}, chunk.Err
+		}
+
+		if chunk.Delta != "" {
+			events <- TickEvent{Type: TickEventTokenDelta, Round: depth, TokenDelta: chunk.Delta, TokenContent: chunk.Content}
+		}
+
+		if delta := chunk.ToolCallDelta; delta != nil {
+			if delta.Name != "" && !startedToolCalls[delta.ID] {
+				startedToolCalls[delta.ID] = true
+				events <- TickEvent{Type: TickEventToolCallStart, Round: depth, ToolCallID: delta.ID, ToolCallName: delta.Name}
+			}
+			if delta.ArgsFragment != "" {
+				events <- TickEvent{Type: TickEventToolCallArgsDelta, Round: depth, ToolCallID: delta.ID, ToolCallArgsFragment: delta.ArgsFragment}
+			}
+		}
+
+		last = chunk
+	}
+
+	if input.MemoryRetriever != nil && last.Content != "" {
+		if err := input.MemoryRetriever.Remember(ctx, n.Name, last.Content, nil); err != nil {
+			logging.Error("Error remembering tick response: %v", err)
+		}
+	}
+
+	return llm.LLMResponse{
+		Response:   last.Content,
+		ToolUses:   last.ToolUses,
+		Structured: last.Structured,
+	}, nil
+}
@@ -0,0 +1,135 @@
+package npc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/piercegov/llm-npc-backend/internal/llm"
+	"github.com/piercegov/llm-npc-backend/internal/tools"
+)
+
+// multiRoundStreamProvider streams a continue_thinking tool call on its
+// first GenerateStream call, then a plain final response on its second, so
+// tests can exercise ActForTickStream's round loop.
+type multiRoundStreamProvider struct {
+	calls int
+}
+
+func (p *multiRoundStreamProvider) Generate(ctx context.Context, request llm.LLMRequest) (llm.LLMResponse, error) {
+	return llm.LLMResponse{}, nil
+}
+
+func (p *multiRoundStreamProvider) GenerateStream(ctx context.Context, request llm.LLMRequest) (<-chan llm.LLMChunk, error) {
+	p.calls++
+	ch := make(chan llm.LLMChunk, 8)
+	defer close(ch)
+
+	if p.calls == 1 {
+		ch <- llm.LLMChunk{Content: "Thinking", Delta: "Thinking"}
+		ch <- llm.LLMChunk{
+			Content:       "Thinking",
+			ToolCallDelta: &llm.ToolCallDelta{ID: "call_1", Name: "continue_thinking", ArgsFragment: `{"reason"`},
+		}
+		ch <- llm.LLMChunk{
+			Content:       "Thinking",
+			ToolCallDelta: &llm.ToolCallDelta{ID: "call_1", ArgsFragment: `:"testing"}`},
+			ToolUses:      []llm.ToolUse{{ToolName: "continue_thinking", ToolArgs: map[string]interface{}{"reason": "testing"}}},
+			FinishReason:  "tool_calls",
+		}
+		return ch, nil
+	}
+
+	ch <- llm.LLMChunk{Content: "Done thinking", Delta: "Done thinking", FinishReason: "stop"}
+	return ch, nil
+}
+
+func TestActForTickStream_MultiRoundContinueThinking(t *testing.T) {
+	npc := &NPC{Name: "Tester", BackgroundStory: "A tester."}
+	provider := &multiRoundStreamProvider{}
+	toolRegistry := tools.NewToolRegistry()
+
+	events, err := npc.ActForTickStream(context.Background(), NPCTickInput{
+		ToolRegistry: toolRegistry,
+		Provider:     provider,
+	})
+	if err != nil {
+		t.Fatalf("ActForTickStream returned an error: %v", err)
+	}
+
+	var seenTypes []string
+	var sawToolCallStart, sawToolResult, sawThinkingContinued bool
+	var final *TickEvent
+	for event := range events {
+		event := event
+		seenTypes = append(seenTypes, event.Type)
+		switch event.Type {
+		case TickEventToolCallStart:
+			if event.ToolCallID != "call_1" || event.ToolCallName != "continue_thinking" {
+				t.Errorf("unexpected tool call start: %+v", event)
+			}
+			sawToolCallStart = true
+		case TickEventToolResult:
+			if event.ToolResult == nil || event.ToolResult.ToolName != "continue_thinking" || !event.ToolResult.Success {
+				t.Errorf("unexpected tool result: %+v", event.ToolResult)
+			}
+			sawToolResult = true
+		case TickEventThinkingContinued:
+			sawThinkingContinued = true
+		case TickEventDone:
+			final = &event
+		}
+	}
+
+	if !sawToolCallStart {
+		t.Error("expected a TickEventToolCallStart for the continue_thinking call")
+	}
+	if !sawToolResult {
+		t.Error("expected a TickEventToolResult for the continue_thinking call")
+	}
+	if !sawThinkingContinued {
+		t.Error("expected a TickEventThinkingContinued between rounds")
+	}
+	if final == nil {
+		t.Fatal("expected a terminal TickEventDone")
+	}
+	if final.Err != nil {
+		t.Errorf("expected a successful tick, got Err: %v", final.Err)
+	}
+	if final.Result == nil || !final.Result.Success {
+		t.Fatalf("expected a successful final Result, got %+v", final.Result)
+	}
+	if len(final.Result.Rounds) != 2 {
+		t.Fatalf("expected 2 rounds, got %d: %+v", len(final.Result.Rounds), final.Result.Rounds)
+	}
+	if provider.calls != 2 {
+		t.Errorf("expected the provider to be called once per round (2 total), got %d", provider.calls)
+	}
+}
+
+func TestActForTickStream_SingleRoundNoContinuation(t *testing.T) {
+	npc := &NPC{Name: "Tester", BackgroundStory: "A tester."}
+	provider := &multiRoundStreamProvider{calls: 1} // skip straight to the final-response branch
+
+	events, err := npc.ActForTickStream(context.Background(), NPCTickInput{Provider: provider})
+	if err != nil {
+		t.Fatalf("ActForTickStream returned an error: %v", err)
+	}
+
+	var final *TickEvent
+	for event := range events {
+		event := event
+		if event.Type == TickEventDone {
+			final = &event
+		}
+	}
+
+	if final == nil || final.Result == nil {
+		t.Fatal("expected a terminal TickEventDone with a Result")
+	}
+	if final.Result.LLMResponse != "Done thinking" {
+		t.Errorf("expected the single round's response unprefixed, got %q", final.Result.LLMResponse)
+	}
+	if len(final.Result.Rounds) != 1 {
+		t.Errorf("expected exactly 1 round, got %d", len(final.Result.Rounds))
+	}
+}
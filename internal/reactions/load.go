@@ -0,0 +1,142 @@
+package reactions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/piercegov/llm-npc-backend/internal/logging"
+)
+
+// ruleFile is the on-disk YAML shape for one rule definition file: either a
+// single rule, or a list of them under "rules", so a designer can group an
+// entire stage's rules into one file without repeating boilerplate.
+type ruleFile struct {
+	Rule  `yaml:",inline"`
+	Rules []Rule `yaml:"rules"`
+}
+
+// NewEngineFromDir builds an Engine whose rules are loaded from every
+// ".yaml"/".yml" file directly under dir (non-recursively), registers its
+// Prometheus counters with registerer (see NewEngine), performs an initial
+// load, and watches dir for changes via fsnotify - mirroring
+// agents.Registry/npc.PromptRegistry so a designer can iterate on reactive
+// rules without restarting the backend. A missing dir is not an error: the
+// Engine loads empty and matches nothing until dir exists and is reloaded.
+func NewEngineFromDir(dir string, registerer prometheus.Registerer) (*Engine, error) {
+	e := NewEngine(registerer)
+	e.dir = dir
+	e.stop = make(chan struct{})
+
+	if err := e.Reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("creating reaction engine watcher: %w", err)
+	}
+	if err := watcher.Add(dir); err != nil {
+		logging.Warn("Reaction engine: not watching rules directory", "dir", dir, "error", err)
+		watcher.Close()
+	} else {
+		e.watcher = watcher
+		go e.watch()
+	}
+
+	return e, nil
+}
+
+func (e *Engine) watch() {
+	for {
+		select {
+		case event, ok := <-e.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+			if err := e.Reload(); err != nil {
+				logging.Error("Reaction engine: reload failed", "error", err)
+			} else {
+				logging.Info("Reaction engine: reloaded rules after filesystem change", "dir", e.dir, "path", event.Name)
+			}
+		case err, ok := <-e.watcher.Errors:
+			if !ok {
+				return
+			}
+			logging.Error("Reaction engine: watcher error", "error", err)
+		case <-e.stop:
+			return
+		}
+	}
+}
+
+// Close stops the filesystem watcher, if NewEngineFromDir started one.
+func (e *Engine) Close() {
+	if e.stop != nil {
+		close(e.stop)
+	}
+	if e.watcher != nil {
+		e.watcher.Close()
+	}
+}
+
+// Reload rescans e.dir and replaces every loaded rule with what's found
+// there. A rule file that fails to parse, or a rule whose filter fails to
+// compile, is logged and skipped rather than failing the whole reload - one
+// designer's typo shouldn't take every other rule down with it.
+func (e *Engine) Reload() error {
+	entries, err := os.ReadDir(e.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			e.reset()
+			return nil
+		}
+		return fmt.Errorf("reading reactions directory %q: %w", e.dir, err)
+	}
+
+	var loaded []Rule
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if ext != ".yaml" && ext != ".yml" {
+			continue
+		}
+
+		path := filepath.Join(e.dir, entry.Name())
+		content, err := os.ReadFile(path)
+		if err != nil {
+			logging.Warn("Reaction engine: failed to read rule definition", "path", path, "error", err)
+			continue
+		}
+
+		var file ruleFile
+		if err := yaml.Unmarshal(content, &file); err != nil {
+			logging.Warn("Reaction engine: failed to parse rule definition", "path", path, "error", err)
+			continue
+		}
+
+		rules := file.Rules
+		if file.Rule.Name != "" {
+			rules = append([]Rule{file.Rule}, rules...)
+		}
+		loaded = append(loaded, rules...)
+	}
+
+	e.reset()
+	for _, rule := range loaded {
+		if err := e.AddRule(rule); err != nil {
+			logging.Warn("Reaction engine: skipping invalid rule", "rule", rule.Name, "error", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package reactions
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/piercegov/llm-npc-backend/internal/logging"
+)
+
+func init() {
+	logging.InitLogger("debug")
+}
+
+func writeRuleFile(t *testing.T, dir, filename, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, filename), []byte(content), 0644); err != nil {
+		t.Fatalf("writing %s: %v", filename, err)
+	}
+}
+
+func TestNewEngineFromDir_LoadsSingleAndListRuleFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeRuleFile(t, dir, "threat.yaml", `
+name: detect-threat
+filter: event.EventType == "attacked"
+onsuccess: respond
+`)
+	writeRuleFile(t, dir, "responses.yaml", `
+rules:
+  - name: flee-if-weak
+    stage: respond
+    filter: npc.State.health < 20
+    statics:
+      - target: force_tool
+        value: flee
+  - name: fight-if-strong
+    stage: respond
+    filter: npc.State.health >= 20
+    statics:
+      - target: force_tool
+        value: attack
+`)
+
+	e, err := NewEngineFromDir(dir, nil)
+	if err != nil {
+		t.Fatalf("NewEngineFromDir: %v", err)
+	}
+	defer e.Close()
+
+	names := e.RuleNames()
+	if len(names) != 3 {
+		t.Fatalf("expected 3 rules loaded, got %+v", names)
+	}
+
+	result := e.Evaluate(Input{
+		Event:    Event{EventType: "attacked"},
+		NPCState: map[string]int{"health": 5},
+	})
+	if len(result.ForceTools) != 1 || result.ForceTools[0] != "flee" {
+		t.Errorf("expected the staged rules across both files to fire together, got %+v", result)
+	}
+}
+
+func TestNewEngineFromDir_MissingDirectoryStartsEmpty(t *testing.T) {
+	e, err := NewEngineFromDir(filepath.Join(t.TempDir(), "does-not-exist"), nil)
+	if err != nil {
+		t.Fatalf("NewEngineFromDir should tolerate a missing directory, got: %v", err)
+	}
+	defer e.Close()
+
+	if len(e.RuleNames()) != 0 {
+		t.Fatalf("expected no rules to be loaded, got %+v", e.RuleNames())
+	}
+}
+
+func TestNewEngineFromDir_SkipsUnparsableAndInvalidFilterFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeRuleFile(t, dir, "broken.yaml", "not: [valid yaml")
+	writeRuleFile(t, dir, "bad-filter.yaml", `
+name: bad
+filter: this is not an expression
+`)
+	writeRuleFile(t, dir, "good.yaml", `
+name: good
+filter: "true"
+`)
+
+	e, err := NewEngineFromDir(dir, nil)
+	if err != nil {
+		t.Fatalf("NewEngineFromDir: %v", err)
+	}
+	defer e.Close()
+
+	if names := e.RuleNames(); len(names) != 1 || names[0] != "good" {
+		t.Fatalf("expected only the valid rule to load, got %+v", names)
+	}
+}
+
+func TestEngine_Reload_ReplacesRules(t *testing.T) {
+	dir := t.TempDir()
+	writeRuleFile(t, dir, "rule.yaml", `
+name: r1
+filter: "true"
+`)
+
+	e, err := NewEngineFromDir(dir, nil)
+	if err != nil {
+		t.Fatalf("NewEngineFromDir: %v", err)
+	}
+	defer e.Close()
+
+	if names := e.RuleNames(); len(names) != 1 || names[0] != "r1" {
+		t.Fatalf("expected r1 loaded, got %+v", names)
+	}
+
+	writeRuleFile(t, dir, "rule.yaml", `
+name: r2
+filter: "true"
+`)
+	if err := e.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	if names := e.RuleNames(); len(names) != 1 || names[0] != "r2" {
+		t.Fatalf("expected only r2 loaded after reload, got %+v", names)
+	}
+}
@@ -0,0 +1,387 @@
+// Package reactions lets a game designer script a pre-LLM reactive layer as
+// YAML instead of Go: a set of named Rules, grouped into Stages, each with
+// an antonmedv/expr filter evaluated against the tick's event/NPC
+// state/surroundings/knowledge graph. Before ActForTick calls the LLM, the
+// Engine walks its stages - starting at the entry stage (Stage left empty)
+// and following a matching rule's OnSuccess to the next one - applying
+// every match's Statics to a scratch Context: extra prompt hints,
+// knowledge-graph nodes to inject, a forced/forbidden tool list, or a
+// response that short-circuits the LLM call entirely. It's the same
+// "staged parser node" shape as a hand-rolled lexer/parser pipeline, just
+// aimed at NPC behavior rather than text.
+//
+// This is deliberately a different engine from tools/rules: that package
+// fires tool calls *after* the LLM respond by watching tool_result/
+// scratchpad/kg facts accumulate (a forward-chaining alpha-memory engine);
+// this one runs *before* the LLM is ever called, judging the raw tick
+// input itself.
+package reactions
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/piercegov/llm-npc-backend/internal/kg"
+	"github.com/piercegov/llm-npc-backend/internal/logging"
+)
+
+// Static targets a Rule's Statics entry can set in a matched Context.
+const (
+	// TargetPromptHint appends Value (a string) as an extra line in the
+	// system prompt.
+	TargetPromptHint = "prompt_hint"
+	// TargetInjectNode merges Value (a kg.Node, or an equivalent
+	// map[string]interface{}) into the knowledge graph passed to the LLM.
+	TargetInjectNode = "inject_node"
+	// TargetForceTool adds Value (a tool name) to the tools the LLM is
+	// required to be offered, regardless of the acting agent's AllowedTools.
+	TargetForceTool = "force_tool"
+	// TargetForbidTool removes Value (a tool name) from the tools the LLM is
+	// offered, even if the acting agent's AllowedTools would otherwise
+	// include it.
+	TargetForbidTool = "forbid_tool"
+	// TargetShortCircuit sets Value (a string) as the tick's final response
+	// and skips the LLM call entirely. Evaluation stops as soon as this
+	// target is set - nothing later should still run before a call that's
+	// no longer going to happen.
+	TargetShortCircuit = "short_circuit"
+)
+
+// Static is one scratch-Context mutation a matched Rule applies. See the
+// Target* constants for which Value shape each target expects.
+type Static struct {
+	Target string      `yaml:"target"`
+	Value  interface{} `yaml:"value"`
+}
+
+// Rule is one staged reactive rule, authored as YAML and compiled once at
+// load time.
+type Rule struct {
+	// Name identifies this rule for logging, metrics, and Engine.byName
+	// lookups; it must be unique across every loaded rule.
+	Name string `yaml:"name"`
+	// Stage groups this rule with others evaluated together. The Engine
+	// always starts at the entry stage (Stage left empty/omitted) and only
+	// advances to another stage when a matching rule in the current one
+	// names it via OnSuccess - entry is fixed rather than "whichever stage
+	// was loaded first" so a rule's place in the pipeline doesn't depend on
+	// which YAML file it happens to live in or the directory's read order.
+	Stage string `yaml:"stage"`
+	// Filter is an antonmedv/expr boolean expression evaluated against this
+	// rule's Input (see env): e.g.
+	// `event.EventType == "attacked" && npc.State.Health < 20`.
+	Filter string `yaml:"filter"`
+	// Statics are applied, in order, to the scratch Context when Filter
+	// matches.
+	Statics []Static `yaml:"statics"`
+	// OnSuccess names the stage the Engine should evaluate next if this
+	// rule matches. Empty means matching this rule doesn't advance the
+	// Engine past its own stage.
+	OnSuccess string `yaml:"onsuccess"`
+
+	program *vm.Program
+}
+
+// Context accumulates what matched Rules contributed during one Evaluate
+// call, for buildTickRequest to fold into the LLM request it assembles (or,
+// if ShortCircuit is set, to return directly instead of calling the LLM at
+// all).
+type Context struct {
+	PromptHints  []string
+	InjectNodes  []kg.Node
+	ForceTools   []string
+	ForbidTools  []string
+	ShortCircuit string
+}
+
+// Input is the subset of an NPC tick's data a Rule's Filter can reference.
+// It's defined independently of the npc package (rather than importing
+// npc.NPCTickInput directly) so npc can import reactions without a cycle;
+// npc.go converts its own types into an Input when calling Evaluate.
+type Input struct {
+	NPCName        string
+	Event          Event
+	Surroundings   []Surrounding
+	NPCState       interface{}
+	KnowledgeGraph kg.KnowledgeGraph
+}
+
+// Event mirrors npc.NPCTickEvent.
+type Event struct {
+	EventType        string
+	EventDescription string
+}
+
+// Surrounding mirrors npc.Surrounding.
+type Surrounding struct {
+	Name        string
+	Description string
+}
+
+// compile parses r.Filter once via antonmedv/expr, so Evaluate only has to
+// run the compiled program per call instead of re-parsing the expression
+// every time.
+func (r *Rule) compile() error {
+	program, err := expr.Compile(r.Filter, expr.AsBool())
+	if err != nil {
+		return fmt.Errorf("compiling filter for rule %q: %w", r.Name, err)
+	}
+	r.program = program
+	return nil
+}
+
+// env builds the antonmedv/expr evaluation environment for input: a plain
+// map so a Filter can use dot-notation on "event", "npc.State",
+// "surroundings", and "kg" without the reactions package needing a
+// compile-time type for whatever fields a game's npc.NPCState ends up
+// having. NPCState is round-tripped through JSON for exactly that reason -
+// it's currently an empty placeholder in this repo (see npc.NPCState's own
+// NOTE), and a struct-typed field here would hide fields that don't exist
+// yet from a Filter that wants to reference them.
+func env(input Input) map[string]interface{} {
+	state := map[string]interface{}{}
+	if input.NPCState != nil {
+		if raw, err := json.Marshal(input.NPCState); err == nil {
+			_ = json.Unmarshal(raw, &state)
+		}
+	}
+
+	surroundings := make([]map[string]interface{}, 0, len(input.Surroundings))
+	for _, s := range input.Surroundings {
+		surroundings = append(surroundings, map[string]interface{}{
+			"Name":        s.Name,
+			"Description": s.Description,
+		})
+	}
+
+	nodes := make([]map[string]interface{}, 0, len(input.KnowledgeGraph.Nodes))
+	for _, n := range input.KnowledgeGraph.Nodes {
+		nodes = append(nodes, map[string]interface{}{"ID": n.ID, "Data": n.Data})
+	}
+
+	return map[string]interface{}{
+		"event": map[string]interface{}{
+			"EventType":        input.Event.EventType,
+			"EventDescription": input.Event.EventDescription,
+		},
+		"npc": map[string]interface{}{
+			"Name":  input.NPCName,
+			"State": state,
+		},
+		"surroundings": surroundings,
+		"kg": map[string]interface{}{
+			"Nodes": nodes,
+		},
+	}
+}
+
+// Engine owns a set of compiled Rules grouped by Stage, and the Prometheus
+// counters that track each one's hits/misses. The zero value is not usable;
+// construct one with NewEngine or NewEngineFromDir.
+type Engine struct {
+	mu      sync.RWMutex
+	byStage map[string][]*Rule
+	byName  map[string]*Rule
+
+	hits   *prometheus.CounterVec
+	misses *prometheus.CounterVec
+
+	// dir/watcher/stop are only set by NewEngineFromDir; an Engine built
+	// directly with NewEngine and AddRule has no directory to watch.
+	dir     string
+	watcher *fsnotify.Watcher
+	stop    chan struct{}
+}
+
+// NewEngine creates an empty Engine whose per-rule hit/miss counters
+// register to registerer. A nil registerer gets its own private
+// prometheus.Registry, so tests (and any other code constructing more than
+// one Engine in the same process) don't collide on prometheus's global
+// DefaultRegisterer; production wiring passes prometheus.DefaultRegisterer
+// so these counters are exposed wherever the rest of the app's metrics are.
+func NewEngine(registerer prometheus.Registerer) *Engine {
+	if registerer == nil {
+		registerer = prometheus.NewRegistry()
+	}
+	factory := promauto.With(registerer)
+
+	return &Engine{
+		byStage: make(map[string][]*Rule),
+		byName:  make(map[string]*Rule),
+		hits: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "npc_reaction_rule_hits_total",
+			Help: "Number of times a reaction rule's filter matched a tick.",
+		}, []string{"rule"}),
+		misses: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "npc_reaction_rule_misses_total",
+			Help: "Number of times a reaction rule's filter was evaluated but did not match.",
+		}, []string{"rule"}),
+	}
+}
+
+// AddRule compiles rule's Filter and adds it to the Engine, returning an
+// error (and leaving the Engine unchanged) if the filter doesn't compile or
+// rule.Name duplicates one already added.
+func (e *Engine) AddRule(rule Rule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("reaction rule missing a name")
+	}
+	if err := rule.compile(); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, exists := e.byName[rule.Name]; exists {
+		return fmt.Errorf("reaction rule %q already registered", rule.Name)
+	}
+
+	r := rule
+	e.byStage[r.Stage] = append(e.byStage[r.Stage], &r)
+	e.byName[r.Name] = &r
+	return nil
+}
+
+// Evaluate runs input through e's staged rules, starting at the entry stage
+// (Stage == ""), applying every matching rule's Statics to a fresh Context
+// and following the first match's OnSuccess (if set) into the next stage. A
+// stage whose rules all miss ends the walk there. Evaluation stops
+// immediately once a Static sets Context.ShortCircuit, since nothing past
+// that point should still run before the LLM call it was meant to skip.
+func (e *Engine) Evaluate(input Input) *Context {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	result := &Context{}
+	if len(e.byName) == 0 {
+		return result
+	}
+
+	environment := env(input)
+	stage := ""
+	visited := make(map[string]bool)
+
+	for !visited[stage] {
+		visited[stage] = true
+		nextStage := ""
+
+		for _, rule := range e.byStage[stage] {
+			matched, err := vm.Run(rule.program, environment)
+			if err != nil {
+				logging.Error("reactions: rule filter errored, treating as a miss", "rule", rule.Name, "error", err)
+				e.misses.WithLabelValues(rule.Name).Inc()
+				continue
+			}
+			if ok, _ := matched.(bool); !ok {
+				e.misses.WithLabelValues(rule.Name).Inc()
+				continue
+			}
+
+			e.hits.WithLabelValues(rule.Name).Inc()
+			logging.Debug("reactions: rule matched", "rule", rule.Name, "stage", stage, "npc_name", input.NPCName)
+
+			applyStatics(result, rule.Statics)
+			if rule.OnSuccess != "" && nextStage == "" {
+				nextStage = rule.OnSuccess
+			}
+			if result.ShortCircuit != "" {
+				return result
+			}
+		}
+
+		stage = nextStage
+	}
+
+	return result
+}
+
+// RuleNames returns the name of every loaded rule, sorted.
+func (e *Engine) RuleNames() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	names := make([]string, 0, len(e.byName))
+	for name := range e.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// reset clears every loaded rule/stage without touching e's Prometheus
+// counters (a Reload shouldn't drop metrics history for rules that are
+// still present, and counters can't be unregistered mid-process anyway).
+func (e *Engine) reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.byStage = make(map[string][]*Rule)
+	e.byName = make(map[string]*Rule)
+}
+
+// applyStatics folds each Static into ctx in order. An unrecognized Target
+// or a Value of the wrong shape is logged and skipped, rather than failing
+// the whole rule - a typo in one static shouldn't discard the others. A
+// short_circuit Static ends the fold: the Statics after it are not applied.
+func applyStatics(ctx *Context, statics []Static) {
+	for _, static := range statics {
+		switch static.Target {
+		case TargetPromptHint:
+			if hint, ok := static.Value.(string); ok {
+				ctx.PromptHints = append(ctx.PromptHints, hint)
+			}
+		case TargetInjectNode:
+			if node, ok := toNode(static.Value); ok {
+				ctx.InjectNodes = append(ctx.InjectNodes, node)
+			}
+		case TargetForceTool:
+			if name, ok := static.Value.(string); ok {
+				ctx.ForceTools = append(ctx.ForceTools, name)
+			}
+		case TargetForbidTool:
+			if name, ok := static.Value.(string); ok {
+				ctx.ForbidTools = append(ctx.ForbidTools, name)
+			}
+		case TargetShortCircuit:
+			if response, ok := static.Value.(string); ok {
+				ctx.ShortCircuit = response
+				return
+			}
+		default:
+			logging.Warn("reactions: static has unrecognized target, skipping", "target", static.Target)
+		}
+	}
+}
+
+// toNode converts a Static's Value into a kg.Node, accepting either a
+// kg.Node directly (set by Go callers building Rules in code) or the
+// map[string]interface{} shape YAML unmarshals "value: {id: ..., data:
+// {...}}" into.
+func toNode(value interface{}) (kg.Node, bool) {
+	if node, ok := value.(kg.Node); ok {
+		return node, true
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return kg.Node{}, false
+	}
+	node := kg.Node{}
+	if id, ok := m["id"].(string); ok {
+		node.ID = id
+	}
+	if data, ok := m["data"].(map[string]interface{}); ok {
+		node.Data = data
+	}
+	if node.ID == "" {
+		return kg.Node{}, false
+	}
+	return node, true
+}
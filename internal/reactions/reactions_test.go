@@ -0,0 +1,142 @@
+package reactions
+
+import (
+	"testing"
+
+	"github.com/piercegov/llm-npc-backend/internal/kg"
+)
+
+func TestEngine_Evaluate_MatchAppliesStatics(t *testing.T) {
+	e := NewEngine(nil)
+	if err := e.AddRule(Rule{
+		Name:   "low-health-warning",
+		Filter: `npc.State.health < 20`,
+		Statics: []Static{
+			{Target: TargetPromptHint, Value: "The NPC is badly hurt and should act cautious."},
+			{Target: TargetForbidTool, Value: "attack"},
+		},
+	}); err != nil {
+		t.Fatalf("AddRule returned an error: %v", err)
+	}
+
+	result := e.Evaluate(Input{NPCState: map[string]int{"health": 10}})
+
+	if len(result.PromptHints) != 1 || result.PromptHints[0] != "The NPC is badly hurt and should act cautious." {
+		t.Errorf("expected a prompt hint, got %+v", result.PromptHints)
+	}
+	if len(result.ForbidTools) != 1 || result.ForbidTools[0] != "attack" {
+		t.Errorf("expected 'attack' forbidden, got %+v", result.ForbidTools)
+	}
+}
+
+func TestEngine_Evaluate_NoMatchReturnsEmptyContext(t *testing.T) {
+	e := NewEngine(nil)
+	if err := e.AddRule(Rule{
+		Name:   "only-when-attacked",
+		Filter: `event.EventType == "attacked"`,
+		Statics: []Static{
+			{Target: TargetPromptHint, Value: "Defend yourself!"},
+		},
+	}); err != nil {
+		t.Fatalf("AddRule returned an error: %v", err)
+	}
+
+	result := e.Evaluate(Input{Event: Event{EventType: "greeted"}})
+
+	if len(result.PromptHints) != 0 {
+		t.Errorf("expected no prompt hints, got %+v", result.PromptHints)
+	}
+}
+
+func TestEngine_Evaluate_OnSuccessAdvancesStage(t *testing.T) {
+	e := NewEngine(nil)
+	if err := e.AddRule(Rule{
+		Name:      "detect-threat",
+		Filter:    `event.EventType == "attacked"`,
+		OnSuccess: "respond",
+	}); err != nil {
+		t.Fatalf("AddRule returned an error: %v", err)
+	}
+	if err := e.AddRule(Rule{
+		Name:   "flee-if-weak",
+		Stage:  "respond",
+		Filter: `npc.State.health < 20`,
+		Statics: []Static{
+			{Target: TargetForceTool, Value: "flee"},
+		},
+	}); err != nil {
+		t.Fatalf("AddRule returned an error: %v", err)
+	}
+
+	result := e.Evaluate(Input{
+		Event:    Event{EventType: "attacked"},
+		NPCState: map[string]int{"health": 5},
+	})
+
+	if len(result.ForceTools) != 1 || result.ForceTools[0] != "flee" {
+		t.Errorf("expected the 'respond' stage's rule to fire via onsuccess, got %+v", result.ForceTools)
+	}
+}
+
+func TestEngine_Evaluate_ShortCircuitStopsEvaluation(t *testing.T) {
+	e := NewEngine(nil)
+	if err := e.AddRule(Rule{
+		Name:   "bypass-llm",
+		Filter: `event.EventType == "asleep"`,
+		Statics: []Static{
+			{Target: TargetShortCircuit, Value: "Zzzzz."},
+			{Target: TargetPromptHint, Value: "should never apply"},
+		},
+	}); err != nil {
+		t.Fatalf("AddRule returned an error: %v", err)
+	}
+
+	result := e.Evaluate(Input{Event: Event{EventType: "asleep"}})
+
+	if result.ShortCircuit != "Zzzzz." {
+		t.Errorf("expected ShortCircuit to be set, got %+v", result)
+	}
+	if len(result.PromptHints) != 0 {
+		t.Errorf("expected evaluation to stop at the short-circuiting static, got %+v", result.PromptHints)
+	}
+}
+
+func TestEngine_Evaluate_EmptyEngineIsANoOp(t *testing.T) {
+	e := NewEngine(nil)
+	result := e.Evaluate(Input{})
+	if result.ShortCircuit != "" || len(result.PromptHints) != 0 {
+		t.Errorf("expected an empty Context from an Engine with no rules, got %+v", result)
+	}
+}
+
+func TestEngine_AddRule_RejectsDuplicateNameAndBadFilter(t *testing.T) {
+	e := NewEngine(nil)
+	if err := e.AddRule(Rule{Name: "r1", Filter: `true`}); err != nil {
+		t.Fatalf("AddRule returned an error: %v", err)
+	}
+	if err := e.AddRule(Rule{Name: "r1", Filter: `true`}); err == nil {
+		t.Error("expected a duplicate rule name to be rejected")
+	}
+	if err := e.AddRule(Rule{Name: "r2", Filter: `this is not an expression`}); err == nil {
+		t.Error("expected an uncompilable filter to be rejected")
+	}
+}
+
+func TestToNode_AcceptsKGNodeAndYAMLShape(t *testing.T) {
+	if node, ok := toNode(kg.Node{ID: "n1"}); !ok || node.ID != "n1" {
+		t.Errorf("expected a direct kg.Node to pass through, got %+v, %v", node, ok)
+	}
+
+	yamlShape := map[string]interface{}{
+		"id":   "n2",
+		"data": map[string]interface{}{"kind": "location"},
+	}
+	node, ok := toNode(yamlShape)
+	if !ok || node.ID != "n2" || node.Data["kind"] != "location" {
+		t.Errorf("expected the YAML map shape to convert, got %+v, %v", node, ok)
+	}
+
+	if _, ok := toNode("not a node"); ok {
+		t.Error("expected a non-map, non-Node value to be rejected")
+	}
+}
@@ -3,7 +3,9 @@ package tools
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/piercegov/llm-npc-backend/internal/kg"
 	"github.com/piercegov/llm-npc-backend/internal/llm"
 )
 
@@ -28,6 +30,15 @@ type ToolHandler func(ctx context.Context, npcID string, args map[string]interfa
 type ToolRegistry struct {
 	tools    map[string]llm.Tool
 	handlers map[string]ToolHandler
+	graph    *kg.Graph
+}
+
+// SetGraph wires graph into the registry so that every successful ExecuteTool
+// call also records a ToolInvocation node, linked to the calling NPC and to
+// any entity IDs mentioned in the result's Data. Optional: a nil graph (the
+// default) leaves tool execution unchanged.
+func (r *ToolRegistry) SetGraph(graph *kg.Graph) {
+	r.graph = graph
 }
 
 // NewToolRegistry creates a new tool registry with built-in tools
@@ -99,6 +110,37 @@ func (r *ToolRegistry) GetToolsWithSession(sessionTools []llm.Tool) []llm.Tool {
 	return tools
 }
 
+// Subset returns a new ToolRegistry containing only the named tools (plus
+// the always-available continue_thinking control-flow tool), sharing this
+// registry's handlers and graph wiring. A nil names means no restriction:
+// the registry is returned unchanged; a non-nil, empty names restricts to
+// continue_thinking only. Used by agents.Agent to scope an NPC down to,
+// e.g., only trade tools.
+func (r *ToolRegistry) Subset(names []string) *ToolRegistry {
+	if names == nil {
+		return r
+	}
+
+	allowed := make(map[string]struct{}, len(names)+1)
+	for _, name := range names {
+		allowed[name] = struct{}{}
+	}
+	allowed["continue_thinking"] = struct{}{}
+
+	subset := &ToolRegistry{
+		tools:    make(map[string]llm.Tool),
+		handlers: make(map[string]ToolHandler),
+		graph:    r.graph,
+	}
+	for name, tool := range r.tools {
+		if _, ok := allowed[name]; ok {
+			subset.tools[name] = tool
+			subset.handlers[name] = r.handlers[name]
+		}
+	}
+	return subset
+}
+
 // ExecuteTool executes a tool by name
 func (r *ToolRegistry) ExecuteTool(ctx context.Context, npcID string, toolUse llm.ToolUse) (ToolResult, error) {
 	handler, exists := r.handlers[toolUse.ToolName]
@@ -109,39 +151,96 @@ func (r *ToolRegistry) ExecuteTool(ctx context.Context, npcID string, toolUse ll
 		}, fmt.Errorf("unknown tool: %s", toolUse.ToolName)
 	}
 
-	// Validate arguments match expected parameters
-	tool, _ := r.tools[toolUse.ToolName]
-	if err := validateArgs(tool, toolUse.ToolArgs); err != nil {
+	// Validate arguments match expected parameters, coercing e.g. a
+	// "format": "date-time" string into a time.Time along the way.
+	tool := r.tools[toolUse.ToolName]
+	coercedArgs, err := validateArgs(tool, toolUse.ToolArgs)
+	if err != nil {
 		return ToolResult{
 			Success: false,
 			Message: err.Error(),
 		}, err
 	}
 
-	return handler(ctx, npcID, toolUse.ToolArgs)
+	result, err := handler(ctx, npcID, coercedArgs)
+	if err == nil && result.Success {
+		r.projectInvocation(npcID, toolUse.ToolName, result)
+	}
+	return result, err
 }
 
-// validateArgs validates that the provided arguments match the expected parameters
-func validateArgs(tool llm.Tool, args map[string]interface{}) error {
-	// Check required parameters
-	for name, param := range tool.Parameters {
-		if param.Required {
-			if _, exists := args[name]; !exists {
-				return fmt.Errorf("missing required parameter: %s", name)
-			}
-		}
+// projectInvocation materializes a successful tool call as a ToolInvocation
+// node, linked to the calling NPC and to any entity the result mentions via
+// an "entity_id" or "entity_ids" key in its Data, when a graph is wired in.
+func (r *ToolRegistry) projectInvocation(npcID, toolName string, result ToolResult) {
+	if r.graph == nil {
+		return
 	}
 
-	// Check for unexpected parameters
-	for argName := range args {
-		if _, exists := tool.Parameters[argName]; !exists {
-			return fmt.Errorf("unexpected parameter: %s", argName)
-		}
+	npcNodeID := "npc:" + npcID
+	r.graph.UpsertNode(kg.Node{
+		ID: npcNodeID,
+		Data: map[string]interface{}{
+			"kind":   "npc",
+			"npc_id": npcID,
+		},
+	})
+
+	invocationNodeID := fmt.Sprintf("tool_invocation:%s:%s:%d", npcID, toolName, time.Now().UnixNano())
+	r.graph.UpsertNode(kg.Node{
+		ID: invocationNodeID,
+		Data: map[string]interface{}{
+			"kind":      "tool_invocation",
+			"npc_id":    npcID,
+			"tool_name": toolName,
+			"timestamp": time.Now().Format(time.RFC3339),
+		},
+	})
+	r.graph.AddEdge(kg.Edge{
+		Source: npcNodeID,
+		Target: invocationNodeID,
+		Data:   map[string]interface{}{"type": "invoked"},
+	})
+
+	for _, entityID := range entityIDsMentioned(result) {
+		entityNodeID := "entity:" + entityID
+		r.graph.UpsertNode(kg.Node{
+			ID: entityNodeID,
+			Data: map[string]interface{}{
+				"kind":      "entity",
+				"entity_id": entityID,
+			},
+		})
+		r.graph.AddEdge(kg.Edge{
+			Source: invocationNodeID,
+			Target: entityNodeID,
+			Data:   map[string]interface{}{"type": "mentions"},
+		})
 	}
+}
 
-	// TODO: Add type validation based on param.Type
+// entityIDsMentioned extracts entity IDs from a ToolResult's Data, following
+// the "entity_id" (single string) or "entity_ids" (string slice) convention.
+func entityIDsMentioned(result ToolResult) []string {
+	if result.Data == nil {
+		return nil
+	}
 
-	return nil
+	var ids []string
+	if id, ok := result.Data["entity_id"].(string); ok && id != "" {
+		ids = append(ids, id)
+	}
+	switch raw := result.Data["entity_ids"].(type) {
+	case []string:
+		ids = append(ids, raw...)
+	case []interface{}:
+		for _, v := range raw {
+			if id, ok := v.(string); ok && id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
 }
 
 // CombinedToolRegistry wraps a ToolRegistry with session-specific tools
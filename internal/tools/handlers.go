@@ -89,7 +89,7 @@ func (h *ToolHandlers) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logging.Info("Tools registered successfully",
+	logging.FromContext(r.Context()).Info("Tools registered successfully",
 		"session_id", req.SessionID,
 		"tools_count", len(req.Tools),
 		"tool_names", toolNames,
@@ -109,10 +109,34 @@ func (h *ToolHandlers) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// SessionHistoryHandler handles GET /tools/session/{id}/history
+func (h *ToolHandlers) SessionHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	sessionID := api.PathParam(r, "id")
+	if sessionID == "" {
+		api.WriteErrorResponse(w, http.StatusBadRequest, "Session ID is required", api.ErrCodeValidation, nil, r.Context())
+		return
+	}
+
+	history, err := h.sessionManager.GetSessionHistory(sessionID)
+	if err != nil {
+		api.WriteErrorResponse(w, http.StatusNotFound, "Session not found", api.ErrCodeNotFound, nil, r.Context())
+		return
+	}
+
+	response := map[string]interface{}{
+		"session_id": sessionID,
+		"history":    history,
+		"count":      len(history),
+		"success":    true,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 // SessionInfoHandler handles GET /tools/session/{id}
 func (h *ToolHandlers) SessionInfoHandler(w http.ResponseWriter, r *http.Request) {
-	// Extract session ID from URL path
-	sessionID := r.URL.Path[len("/tools/session/"):]
+	sessionID := api.PathParam(r, "id")
 	if sessionID == "" {
 		api.WriteErrorResponse(w, http.StatusBadRequest, "Session ID is required", api.ErrCodeValidation, nil, r.Context())
 		return
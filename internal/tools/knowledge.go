@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/piercegov/llm-npc-backend/internal/kg"
+	"github.com/piercegov/llm-npc-backend/internal/llm"
+)
+
+// RegisterKnowledgeTools registers the query_knowledge tool, which lets an
+// NPC retrieve structured recall from graph (the same Graph that
+// ScratchpadStorage and ToolRegistry project Memory/ToolInvocation nodes
+// into) alongside its flat scratchpad.
+func RegisterKnowledgeTools(registry *ToolRegistry, graph *kg.Graph) error {
+	queryToolDef := llm.Tool{
+		Name:        "query_knowledge",
+		Description: "Look up structured recall from your knowledge graph: memories, tool invocations, and entities linked to you or to a specific entity",
+		Parameters: map[string]llm.ToolParameter{
+			"entity_id": {
+				Type:        llm.TypeString,
+				Description: "If set, start the traversal from this entity instead of from yourself",
+				Required:    false,
+			},
+			"hops": {
+				Type:        llm.TypeNumber,
+				Description: "How many edge hops to traverse outward (default 1)",
+				Required:    false,
+			},
+		},
+	}
+
+	handler := func(ctx context.Context, npcID string, args map[string]interface{}) (ToolResult, error) {
+		return handleQueryKnowledge(graph, npcID, args)
+	}
+
+	return registry.RegisterTool(queryToolDef, handler)
+}
+
+func handleQueryKnowledge(graph *kg.Graph, npcID string, args map[string]interface{}) (ToolResult, error) {
+	if graph == nil {
+		return ToolResult{Success: false, Message: "knowledge graph is not enabled"}, fmt.Errorf("knowledge graph is not enabled")
+	}
+
+	hops := 1
+	if raw, ok := args["hops"].(float64); ok && raw > 0 {
+		hops = int(raw)
+	}
+
+	startNodeID := "npc:" + npcID
+	if entityID, ok := args["entity_id"].(string); ok && entityID != "" {
+		startNodeID = "entity:" + entityID
+	}
+
+	if _, exists := graph.Node(startNodeID); !exists {
+		return ToolResult{Success: true, Message: "No knowledge found", Data: map[string]interface{}{"nodes": []interface{}{}}}, nil
+	}
+
+	neighbors := graph.KHop(startNodeID, hops)
+	nodes := make([]map[string]interface{}, 0, len(neighbors))
+	for _, node := range neighbors {
+		nodes = append(nodes, map[string]interface{}{
+			"id":   node.ID,
+			"data": node.Data,
+		})
+	}
+
+	return ToolResult{
+		Success: true,
+		Message: fmt.Sprintf("Found %d related nodes within %d hop(s)", len(nodes), hops),
+		Data: map[string]interface{}{
+			"nodes": nodes,
+		},
+	}, nil
+}
@@ -0,0 +1,309 @@
+// Package rules lets game designers register declarative rules of the form
+// "when condition C over recent tool results, scratchpad entries, or KG
+// facts holds, invoke action A" without waiting for the LLM to decide.
+// A RuleSession accumulates Facts asserted by the tick loop (scratchpad
+// writes, tool executions, KG updates) and re-evaluates only the rules whose
+// conditions reference a fact kind that just changed, the way a forward-
+// chaining engine's alpha memories limit re-evaluation to affected rules.
+package rules
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/piercegov/llm-npc-backend/internal/llm"
+	"github.com/piercegov/llm-npc-backend/internal/tools"
+)
+
+// Fact kinds a Condition can reference.
+const (
+	KindScratchpad = "scratchpad"
+	KindToolResult = "tool_result"
+	KindKGNode     = "kg_node"
+	KindNPCState   = "npc_state"
+)
+
+// Fact is a single piece of evidence asserted into a RuleSession. Data holds
+// kind-specific fields (e.g. scratchpad facts carry "value" in Data), kept
+// as a plain map rather than a typed union so rules loaded from JSON can
+// reference fields by name without a parallel Go type per fact kind.
+type Fact struct {
+	Kind      string                 `json:"kind"`
+	NPCID     string                 `json:"npc_id"`
+	Key       string                 `json:"key"`
+	Data      map[string]interface{} `json:"data"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// Condition is a single predicate over the most recent Fact of Fact kind
+// matching Key (or any fact of that kind for the NPC, if Key is empty).
+// Conditions are declarative so a Rule can be loaded from JSON rather than
+// requiring a compiled-in predicate function.
+type Condition struct {
+	Fact  string      `json:"fact"`            // one of the Kind* constants
+	Key   string      `json:"key,omitempty"`   // restricts to a specific Fact.Key, e.g. a scratchpad key
+	Field string      `json:"field,omitempty"` // field within Fact.Data to compare; ignored by "exists"
+	Op    string      `json:"op"`              // "exists", "eq", "neq", "contains", "gt", "lt"
+	Value interface{} `json:"value,omitempty"`
+}
+
+// Rule is a single "when Conditions all hold, invoke Action" entry. Rules
+// with a higher Priority are evaluated first; a rule only refires once the
+// facts that satisfied it change (see RuleSession.fired).
+type Rule struct {
+	Name       string                 `json:"name"`
+	Priority   int                    `json:"priority"`
+	Conditions []Condition            `json:"conditions"`
+	Action     string                 `json:"action"`
+	Args       map[string]interface{} `json:"args"`
+}
+
+// FiredRule records a Rule that matched and the ToolResult its Action produced.
+type FiredRule struct {
+	Rule   Rule
+	Result tools.ToolResult
+}
+
+// ActionService resolves a Rule's Action to a ToolHandler registered in a
+// ToolRegistry, and invokes it the same way an LLM-issued tool call would,
+// so fired rules and LLM tool calls are indistinguishable in the audit trail.
+type ActionService struct {
+	registry *tools.ToolRegistry
+}
+
+// NewActionService wraps registry so RuleSession can invoke a Rule's Action
+// through it.
+func NewActionService(registry *tools.ToolRegistry) *ActionService {
+	return &ActionService{registry: registry}
+}
+
+// Invoke executes a Rule's Action tool against registry with npcID as the
+// acting NPC.
+func (a *ActionService) Invoke(ctx context.Context, npcID string, rule Rule) (tools.ToolResult, error) {
+	return a.registry.ExecuteTool(ctx, npcID, llm.ToolUse{ToolName: rule.Action, ToolArgs: rule.Args})
+}
+
+// RuleSession owns a set of Rules and the alpha memory (most recent Fact per
+// kind/NPC/key) they evaluate against, and fires an ActionService to carry
+// out matched rules' Actions.
+type RuleSession struct {
+	mu      sync.Mutex
+	actions *ActionService
+	rules   []Rule
+	// memory[kind][npcID][key] is the most recent Fact asserted for that
+	// tuple identity; asserting a new fact for the same tuple overwrites it,
+	// which is what keeps memory bounded and lets a rule stop matching once
+	// the underlying fact changes.
+	memory map[string]map[string]map[string]Fact
+	// fired[ruleName+"|"+npcID] is the signature of the fact tuple that last
+	// fired that rule for that NPC, so the same facts don't refire the rule
+	// until something they depend on changes.
+	fired map[string]string
+}
+
+// NewRuleSession creates an empty RuleSession whose fired rules invoke
+// actions through actions.
+func NewRuleSession(actions *ActionService) *RuleSession {
+	return &RuleSession{
+		actions: actions,
+		memory:  make(map[string]map[string]map[string]Fact),
+		fired:   make(map[string]string),
+	}
+}
+
+// AddRule registers rule with the session.
+func (s *RuleSession) AddRule(rule Rule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = append(s.rules, rule)
+}
+
+// LoadRulesFromFile reads a JSON array of Rules from path and adds them to
+// the session, so a game designer can iterate on reactive behavior without a
+// rebuild.
+func (s *RuleSession) LoadRulesFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read rules file: %w", err)
+	}
+
+	var loaded []Rule
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("parse rules file: %w", err)
+	}
+
+	s.mu.Lock()
+	s.rules = append(s.rules, loaded...)
+	s.mu.Unlock()
+	return nil
+}
+
+// Assert records fact in the session's alpha memory and evaluates every
+// rule with a Condition referencing fact.Kind, returning any that fired.
+// Limiting re-evaluation to rules touched by the changed fact kind is what
+// keeps this incremental rather than rechecking every rule on every assert.
+func (s *RuleSession) Assert(ctx context.Context, fact Fact) ([]FiredRule, error) {
+	s.mu.Lock()
+	byNPC, exists := s.memory[fact.Kind]
+	if !exists {
+		byNPC = make(map[string]map[string]Fact)
+		s.memory[fact.Kind] = byNPC
+	}
+	byKey, exists := byNPC[fact.NPCID]
+	if !exists {
+		byKey = make(map[string]Fact)
+		byNPC[fact.NPCID] = byKey
+	}
+	byKey[fact.Key] = fact
+
+	var affected []Rule
+	for _, rule := range s.rules {
+		if ruleReferencesKind(rule, fact.Kind) {
+			affected = append(affected, rule)
+		}
+	}
+	sort.SliceStable(affected, func(i, j int) bool { return affected[i].Priority > affected[j].Priority })
+	s.mu.Unlock()
+
+	var fired []FiredRule
+	for _, rule := range affected {
+		tuple, ok := s.matchLocked(rule, fact.NPCID)
+		if !ok {
+			continue
+		}
+
+		signature := signatureOf(tuple)
+		dedupKey := rule.Name + "|" + fact.NPCID
+
+		s.mu.Lock()
+		if s.fired[dedupKey] == signature {
+			s.mu.Unlock()
+			continue
+		}
+		s.fired[dedupKey] = signature
+		s.mu.Unlock()
+
+		result, err := s.actions.Invoke(ctx, fact.NPCID, rule)
+		if err != nil {
+			return fired, fmt.Errorf("rule %q action %q: %w", rule.Name, rule.Action, err)
+		}
+		fired = append(fired, FiredRule{Rule: rule, Result: result})
+	}
+
+	return fired, nil
+}
+
+// ruleReferencesKind reports whether any of rule's Conditions reads facts of kind.
+func ruleReferencesKind(rule Rule, kind string) bool {
+	for _, cond := range rule.Conditions {
+		if cond.Fact == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// matchLocked reports whether every Condition in rule currently holds for
+// npcID, and returns the tuple of facts that satisfied them.
+func (s *RuleSession) matchLocked(rule Rule, npcID string) ([]Fact, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tuple := make([]Fact, 0, len(rule.Conditions))
+	for _, cond := range rule.Conditions {
+		fact, ok := s.findMatchLocked(cond, npcID)
+		if !ok {
+			return nil, false
+		}
+		tuple = append(tuple, fact)
+	}
+	return tuple, true
+}
+
+// findMatchLocked returns the first fact of cond.Fact kind for npcID that
+// satisfies cond, restricted to cond.Key if set. Callers must hold s.mu.
+func (s *RuleSession) findMatchLocked(cond Condition, npcID string) (Fact, bool) {
+	byKey, exists := s.memory[cond.Fact][npcID]
+	if !exists {
+		return Fact{}, false
+	}
+
+	if cond.Key != "" {
+		fact, exists := byKey[cond.Key]
+		if !exists || !conditionHolds(cond, fact) {
+			return Fact{}, false
+		}
+		return fact, true
+	}
+
+	for _, fact := range byKey {
+		if conditionHolds(cond, fact) {
+			return fact, true
+		}
+	}
+	return Fact{}, false
+}
+
+// conditionHolds evaluates cond.Op against fact, reading fact.Data[cond.Field]
+// unless cond.Op is "exists".
+func conditionHolds(cond Condition, fact Fact) bool {
+	if cond.Op == "exists" {
+		return true
+	}
+
+	actual, exists := fact.Data[cond.Field]
+	if !exists {
+		return false
+	}
+
+	switch cond.Op {
+	case "eq":
+		return fmt.Sprint(actual) == fmt.Sprint(cond.Value)
+	case "neq":
+		return fmt.Sprint(actual) != fmt.Sprint(cond.Value)
+	case "contains":
+		actualStr, actualOK := actual.(string)
+		valueStr, valueOK := cond.Value.(string)
+		return actualOK && valueOK && strings.Contains(actualStr, valueStr)
+	case "gt":
+		a, aOK := toFloat(actual)
+		b, bOK := toFloat(cond.Value)
+		return aOK && bOK && a > b
+	case "lt":
+		a, aOK := toFloat(actual)
+		b, bOK := toFloat(cond.Value)
+		return aOK && bOK && a < b
+	default:
+		return false
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// signatureOf hashes a matched fact tuple's content so RuleSession can tell
+// whether the facts behind a past firing have since changed.
+func signatureOf(tuple []Fact) string {
+	h := sha256.New()
+	for _, fact := range tuple {
+		data, _ := json.Marshal(fact)
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,111 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/piercegov/llm-npc-backend/internal/llm"
+	"github.com/piercegov/llm-npc-backend/internal/tools"
+)
+
+func newTestSession(t *testing.T) (*RuleSession, *tools.ToolRegistry) {
+	t.Helper()
+
+	registry := tools.NewToolRegistry()
+	fired := false
+	handler := func(ctx context.Context, npcID string, args map[string]interface{}) (tools.ToolResult, error) {
+		fired = true
+		return tools.ToolResult{Success: true, Message: "sounded the alarm"}, nil
+	}
+	if err := registry.RegisterTool(llm.Tool{Name: "sound_alarm", Parameters: map[string]llm.ToolParameter{}}, handler); err != nil {
+		t.Fatalf("RegisterTool() error = %v", err)
+	}
+	_ = fired
+
+	return NewRuleSession(NewActionService(registry)), registry
+}
+
+func TestRuleSession_FiresWhenConditionHolds(t *testing.T) {
+	session, _ := newTestSession(t)
+	session.AddRule(Rule{
+		Name:     "intruder-alarm",
+		Priority: 10,
+		Conditions: []Condition{
+			{Fact: KindScratchpad, Key: "saw", Field: "value", Op: "eq", Value: "intruder"},
+		},
+		Action: "sound_alarm",
+	})
+
+	fired, err := session.Assert(context.Background(), Fact{
+		Kind:      KindScratchpad,
+		NPCID:     "npc-1",
+		Key:       "saw",
+		Data:      map[string]interface{}{"value": "intruder"},
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("Assert() error = %v", err)
+	}
+	if len(fired) != 1 || fired[0].Rule.Name != "intruder-alarm" {
+		t.Fatalf("expected intruder-alarm to fire once, got %+v", fired)
+	}
+	if !fired[0].Result.Success {
+		t.Fatalf("expected action to succeed, got %+v", fired[0].Result)
+	}
+}
+
+func TestRuleSession_DoesNotRefireUnchangedFacts(t *testing.T) {
+	session, _ := newTestSession(t)
+	session.AddRule(Rule{
+		Name:       "greet-once",
+		Conditions: []Condition{{Fact: KindScratchpad, Key: "met", Op: "exists"}},
+		Action:     "sound_alarm",
+	})
+
+	fact := Fact{Kind: KindScratchpad, NPCID: "npc-1", Key: "met", Data: map[string]interface{}{"value": "yes"}, Timestamp: time.Now()}
+
+	fired, err := session.Assert(context.Background(), fact)
+	if err != nil {
+		t.Fatalf("Assert() error = %v", err)
+	}
+	if len(fired) != 1 {
+		t.Fatalf("expected first assert to fire, got %d", len(fired))
+	}
+
+	fired, err = session.Assert(context.Background(), fact)
+	if err != nil {
+		t.Fatalf("Assert() error = %v", err)
+	}
+	if len(fired) != 0 {
+		t.Fatalf("expected unchanged fact to not refire, got %d", len(fired))
+	}
+}
+
+func TestRuleSession_RefiresAfterFactChanges(t *testing.T) {
+	session, _ := newTestSession(t)
+	session.AddRule(Rule{
+		Name:       "greet-once",
+		Conditions: []Condition{{Fact: KindScratchpad, Key: "met", Op: "exists"}},
+		Action:     "sound_alarm",
+	})
+
+	base := Fact{Kind: KindScratchpad, NPCID: "npc-1", Key: "met", Timestamp: time.Now()}
+
+	if _, err := session.Assert(context.Background(), withValue(base, "yes")); err != nil {
+		t.Fatalf("Assert() error = %v", err)
+	}
+
+	fired, err := session.Assert(context.Background(), withValue(base, "no"))
+	if err != nil {
+		t.Fatalf("Assert() error = %v", err)
+	}
+	if len(fired) != 1 {
+		t.Fatalf("expected changed fact to refire the rule, got %d", len(fired))
+	}
+}
+
+func withValue(fact Fact, value string) Fact {
+	fact.Data = map[string]interface{}{"value": value}
+	return fact
+}
@@ -6,15 +6,35 @@ import (
 	"sync"
 	"time"
 
+	"github.com/piercegov/llm-npc-backend/internal/kg"
 	"github.com/piercegov/llm-npc-backend/internal/llm"
+	"github.com/piercegov/llm-npc-backend/internal/logging"
 )
 
-// ScratchpadStorage manages the persistent memory storage for all NPCs
+// ScratchpadStorage manages the persistent memory storage for all NPCs. A
+// ScratchpadStore backs it for durability: every write updates the in-memory
+// map and then appends a log entry there, and NewScratchpadStorageWithStore
+// replays the log to rebuild that map on startup. storage is a derived view
+// of log, the same way a database's current state is a fold over its WAL;
+// log itself is retained so HistoryFor/SnapshotAt/Replay can answer "what
+// did this NPC believe at time T" without re-reading the store.
 type ScratchpadStorage struct {
+	store   ScratchpadStore
 	storage map[string]*NPCScratchpad
+	log     []ScratchpadLogEntry
+	graph   *kg.Graph
 	mu      sync.RWMutex
 }
 
+// SetGraph wires graph into the storage so that every future write_scratchpad
+// call also upserts a Memory node (and an owns edge from the NPC) into it.
+// Optional: a nil graph (the default) leaves scratchpad behavior unchanged.
+func (s *ScratchpadStorage) SetGraph(graph *kg.Graph) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.graph = graph
+}
+
 // NPCScratchpad stores memory entries for a specific NPC
 type NPCScratchpad struct {
 	Entries map[string]ScratchpadEntry
@@ -27,11 +47,30 @@ type ScratchpadEntry struct {
 	Timestamp time.Time
 }
 
-// NewScratchpadStorage creates a new scratchpad storage
+// NewScratchpadStorage creates scratchpad storage backed by an in-memory
+// ScratchpadStore only: memories do not survive a restart. Use
+// NewScratchpadStorageWithStore for a durable backend.
 func NewScratchpadStorage() *ScratchpadStorage {
-	return &ScratchpadStorage{
-		storage: make(map[string]*NPCScratchpad),
+	return NewScratchpadStorageWithStore(NewNullScratchpadStore())
+}
+
+// NewScratchpadStorageWithStore creates scratchpad storage backed by store,
+// replaying its log to rebuild the in-memory map from whatever a previous
+// run left behind.
+func NewScratchpadStorageWithStore(store ScratchpadStore) *ScratchpadStorage {
+	s := &ScratchpadStorage{store: store, storage: make(map[string]*NPCScratchpad)}
+
+	existing, log, err := store.Load(context.Background())
+	if err != nil {
+		logging.Error("Failed to load scratchpads from store", "error", err)
+	} else {
+		if existing != nil {
+			s.storage = existing
+		}
+		s.log = log
 	}
+
+	return s
 }
 
 // RegisterScratchpadTools registers all scratchpad-related tools
@@ -53,11 +92,11 @@ func RegisterScratchpadTools(registry *ToolRegistry, storage *ScratchpadStorage)
 			},
 		},
 	}
-	
+
 	if err := registry.RegisterTool(writeToolDef, storage.handleWrite); err != nil {
 		return err
 	}
-	
+
 	// Read tool
 	readToolDef := llm.Tool{
 		Name:        "read_scratchpad",
@@ -70,22 +109,22 @@ func RegisterScratchpadTools(registry *ToolRegistry, storage *ScratchpadStorage)
 			},
 		},
 	}
-	
+
 	if err := registry.RegisterTool(readToolDef, storage.handleRead); err != nil {
 		return err
 	}
-	
+
 	// List tool
 	listToolDef := llm.Tool{
 		Name:        "list_scratchpad",
 		Description: "List all memories stored in your scratchpad",
 		Parameters:  map[string]llm.ToolParameter{}, // No parameters
 	}
-	
+
 	if err := registry.RegisterTool(listToolDef, storage.handleList); err != nil {
 		return err
 	}
-	
+
 	// Delete tool
 	deleteToolDef := llm.Tool{
 		Name:        "delete_scratchpad",
@@ -98,27 +137,60 @@ func RegisterScratchpadTools(registry *ToolRegistry, storage *ScratchpadStorage)
 			},
 		},
 	}
-	
+
 	if err := registry.RegisterTool(deleteToolDef, storage.handleDelete); err != nil {
 		return err
 	}
-	
+
+	// Debug tool: lets an operator ask "what did NPC X believe at timestamp
+	// T1 vs T2" and see what changed between the two snapshots.
+	replayToolDef := llm.Tool{
+		Name:        "replay_scratchpad",
+		Description: "Compare an NPC's scratchpad at two points in time (RFC3339 timestamps) and report what changed",
+		Parameters: map[string]llm.ToolParameter{
+			"at": {
+				Type:        llm.TypeString,
+				Description: "RFC3339 timestamp to snapshot the scratchpad at",
+				Required:    true,
+			},
+			"compare_to": {
+				Type:        llm.TypeString,
+				Description: "RFC3339 timestamp to diff against; defaults to now if omitted",
+				Required:    false,
+			},
+		},
+	}
+
+	if err := registry.RegisterTool(replayToolDef, storage.handleReplay); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-// GetAllScratchpads returns all scratchpads for admin/debug purposes
+// GetAllScratchpads returns all scratchpads for admin/debug purposes.
+//
+// This snapshots s.storage under s.mu and then releases it before locking
+// any individual scratchpad.mu: handleWrite/handleDelete lock scratchpad.mu
+// before appendLog takes s.mu, so holding s.mu while acquiring scratchpad.mu
+// here would invert that order and the two could deadlock against each
+// other under concurrent load.
 func (s *ScratchpadStorage) GetAllScratchpads() map[string]map[string]interface{} {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-	
-	result := make(map[string]map[string]interface{})
-	
+	scratchpads := make(map[string]*NPCScratchpad, len(s.storage))
 	for npcID, scratchpad := range s.storage {
+		scratchpads[npcID] = scratchpad
+	}
+	s.mu.RUnlock()
+
+	result := make(map[string]map[string]interface{})
+
+	for npcID, scratchpad := range scratchpads {
 		scratchpad.mu.RLock()
-		
+
 		npcData := make(map[string]interface{})
 		entries := make([]map[string]interface{}, 0, len(scratchpad.Entries))
-		
+
 		for key, entry := range scratchpad.Entries {
 			entries = append(entries, map[string]interface{}{
 				"key":       key,
@@ -126,22 +198,118 @@ func (s *ScratchpadStorage) GetAllScratchpads() map[string]map[string]interface{
 				"timestamp": entry.Timestamp.Format(time.RFC3339),
 			})
 		}
-		
+
 		npcData["entries"] = entries
 		npcData["count"] = len(entries)
 		result[npcID] = npcData
-		
+
 		scratchpad.mu.RUnlock()
 	}
-	
+
 	return result
 }
 
+// appendLog records entry in the in-memory history used by HistoryFor,
+// SnapshotAt, and Replay.
+func (s *ScratchpadStorage) appendLog(entry ScratchpadLogEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.log = append(s.log, entry)
+}
+
+// HistoryFor returns every logged write or delete for npcID's key, oldest first.
+func (s *ScratchpadStorage) HistoryFor(npcID, key string) []ScratchpadLogEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var history []ScratchpadLogEntry
+	for _, entry := range s.log {
+		if entry.NPCID == npcID && entry.Key == key {
+			history = append(history, entry)
+		}
+	}
+	return history
+}
+
+// SnapshotAt reconstructs npcID's scratchpad entries as of t by folding every
+// logged write/delete up to and including t, giving a deterministic answer
+// to "what did this NPC believe at time T" independent of the current state.
+func (s *ScratchpadStorage) SnapshotAt(npcID string, t time.Time) map[string]ScratchpadEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot := make(map[string]ScratchpadEntry)
+	for _, entry := range s.log {
+		if entry.NPCID != npcID || entry.Timestamp.After(t) {
+			continue
+		}
+		if entry.Op == ScratchpadOpDelete {
+			delete(snapshot, entry.Key)
+			continue
+		}
+		snapshot[entry.Key] = ScratchpadEntry{Value: entry.Value, Timestamp: entry.Timestamp}
+	}
+	return snapshot
+}
+
+// Replay returns npcID's logged writes/deletes with a Timestamp in [from, to], oldest first.
+func (s *ScratchpadStorage) Replay(npcID string, from, to time.Time) []ScratchpadLogEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var replayed []ScratchpadLogEntry
+	for _, entry := range s.log {
+		if entry.NPCID == npcID && !entry.Timestamp.Before(from) && !entry.Timestamp.After(to) {
+			replayed = append(replayed, entry)
+		}
+	}
+	return replayed
+}
+
+// projectWrite materializes a scratchpad write as a Memory node (keyed by
+// npcID and key, so later writes to the same key upsert rather than
+// duplicate) plus an owns edge from the NPC node, when a graph is wired in.
+func (s *ScratchpadStorage) projectWrite(npcID, key, value string, timestamp time.Time) {
+	s.mu.RLock()
+	graph := s.graph
+	s.mu.RUnlock()
+	if graph == nil {
+		return
+	}
+
+	npcNodeID := "npc:" + npcID
+	graph.UpsertNode(kg.Node{
+		ID: npcNodeID,
+		Data: map[string]interface{}{
+			"kind":   "npc",
+			"npc_id": npcID,
+		},
+	})
+
+	memoryNodeID := "memory:" + npcID + ":" + key
+	graph.UpsertNode(kg.Node{
+		ID: memoryNodeID,
+		Data: map[string]interface{}{
+			"kind":      "memory",
+			"npc_id":    npcID,
+			"key":       key,
+			"value":     value,
+			"timestamp": timestamp.Format(time.RFC3339),
+		},
+	})
+
+	graph.AddEdge(kg.Edge{
+		Source: npcNodeID,
+		Target: memoryNodeID,
+		Data:   map[string]interface{}{"type": "owns"},
+	})
+}
+
 // getOrCreateScratchpad returns the scratchpad for an NPC, creating it if it doesn't exist
 func (s *ScratchpadStorage) getOrCreateScratchpad(npcID string) *NPCScratchpad {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	
+
 	scratchpad, exists := s.storage[npcID]
 	if !exists {
 		scratchpad = &NPCScratchpad{
@@ -158,22 +326,34 @@ func (s *ScratchpadStorage) handleWrite(ctx context.Context, npcID string, args
 	if !ok || key == "" {
 		return ToolResult{Success: false, Message: "key must be a non-empty string"}, fmt.Errorf("invalid key")
 	}
-	
+
 	value, ok := args["value"].(string)
 	if !ok {
 		return ToolResult{Success: false, Message: "value must be a string"}, fmt.Errorf("invalid value")
 	}
-	
+
 	scratchpad := s.getOrCreateScratchpad(npcID)
-	
+	timestamp := time.Now()
+
+	// Hold scratchpad.mu across the in-memory update and the durable append
+	// so two concurrent writes to the same NPC can't apply to Entries in one
+	// order but land in the log in the other: HistoryFor/SnapshotAt/Replay
+	// fold the log to answer "what did this NPC believe at time T", and that
+	// answer must agree with what Entries actually holds at every T.
 	scratchpad.mu.Lock()
-	defer scratchpad.mu.Unlock()
-	
 	scratchpad.Entries[key] = ScratchpadEntry{
 		Value:     value,
-		Timestamp: time.Now(),
+		Timestamp: timestamp,
 	}
-	
+	entry := ScratchpadLogEntry{NPCID: npcID, Key: key, Op: ScratchpadOpWrite, Value: value, Timestamp: timestamp}
+	s.appendLog(entry)
+	if err := s.store.Append(ctx, entry); err != nil {
+		logging.Error("Failed to persist scratchpad write", "error", err, "npc_id", npcID, "key", key)
+	}
+	scratchpad.mu.Unlock()
+
+	s.projectWrite(npcID, key, value, timestamp)
+
 	return ToolResult{
 		Success: true,
 		Message: fmt.Sprintf("Stored memory: %s = %s", key, value),
@@ -190,23 +370,23 @@ func (s *ScratchpadStorage) handleRead(ctx context.Context, npcID string, args m
 	if !ok || key == "" {
 		return ToolResult{Success: false, Message: "key must be a non-empty string"}, fmt.Errorf("invalid key")
 	}
-	
+
 	s.mu.RLock()
 	scratchpad, exists := s.storage[npcID]
 	s.mu.RUnlock()
-	
+
 	if !exists {
 		return ToolResult{Success: false, Message: fmt.Sprintf("No memory found with key: %s", key)}, nil
 	}
-	
+
 	scratchpad.mu.RLock()
 	defer scratchpad.mu.RUnlock()
-	
+
 	entry, exists := scratchpad.Entries[key]
 	if !exists {
 		return ToolResult{Success: false, Message: fmt.Sprintf("No memory found with key: %s", key)}, nil
 	}
-	
+
 	return ToolResult{
 		Success: true,
 		Message: fmt.Sprintf("%s: %s", key, entry.Value),
@@ -223,17 +403,17 @@ func (s *ScratchpadStorage) handleList(ctx context.Context, npcID string, args m
 	s.mu.RLock()
 	scratchpad, exists := s.storage[npcID]
 	s.mu.RUnlock()
-	
+
 	if !exists || len(scratchpad.Entries) == 0 {
 		return ToolResult{Success: true, Message: "No memories stored"}, nil
 	}
-	
+
 	scratchpad.mu.RLock()
 	defer scratchpad.mu.RUnlock()
-	
+
 	memories := make([]map[string]interface{}, 0, len(scratchpad.Entries))
 	message := "Stored memories:\n"
-	
+
 	for key, entry := range scratchpad.Entries {
 		memories = append(memories, map[string]interface{}{
 			"key":       key,
@@ -242,7 +422,7 @@ func (s *ScratchpadStorage) handleList(ctx context.Context, npcID string, args m
 		})
 		message += fmt.Sprintf("- %s: %s\n", key, entry.Value)
 	}
-	
+
 	return ToolResult{
 		Success: true,
 		Message: message,
@@ -253,30 +433,90 @@ func (s *ScratchpadStorage) handleList(ctx context.Context, npcID string, args m
 	}, nil
 }
 
+// handleReplay handles the replay_scratchpad debug tool, diffing the
+// scratchpad snapshots at "at" and "compare_to".
+func (s *ScratchpadStorage) handleReplay(ctx context.Context, npcID string, args map[string]interface{}) (ToolResult, error) {
+	atStr, ok := args["at"].(string)
+	if !ok || atStr == "" {
+		return ToolResult{Success: false, Message: "at must be an RFC3339 timestamp"}, fmt.Errorf("invalid at")
+	}
+	at, err := time.Parse(time.RFC3339, atStr)
+	if err != nil {
+		return ToolResult{Success: false, Message: fmt.Sprintf("invalid at timestamp: %v", err)}, err
+	}
+
+	compareTo := time.Now()
+	if compareToStr, ok := args["compare_to"].(string); ok && compareToStr != "" {
+		compareTo, err = time.Parse(time.RFC3339, compareToStr)
+		if err != nil {
+			return ToolResult{Success: false, Message: fmt.Sprintf("invalid compare_to timestamp: %v", err)}, err
+		}
+	}
+
+	before := s.SnapshotAt(npcID, at)
+	after := s.SnapshotAt(npcID, compareTo)
+
+	added := make(map[string]string)
+	changed := make(map[string]string)
+	removed := make([]string, 0)
+
+	for key, entry := range after {
+		prior, existed := before[key]
+		switch {
+		case !existed:
+			added[key] = entry.Value
+		case prior.Value != entry.Value:
+			changed[key] = entry.Value
+		}
+	}
+	for key := range before {
+		if _, stillThere := after[key]; !stillThere {
+			removed = append(removed, key)
+		}
+	}
+
+	return ToolResult{
+		Success: true,
+		Message: fmt.Sprintf("%d added, %d changed, %d removed between %s and %s", len(added), len(changed), len(removed), atStr, compareTo.Format(time.RFC3339)),
+		Data: map[string]interface{}{
+			"added":   added,
+			"changed": changed,
+			"removed": removed,
+		},
+	}, nil
+}
+
 // handleDelete handles the delete_scratchpad tool
 func (s *ScratchpadStorage) handleDelete(ctx context.Context, npcID string, args map[string]interface{}) (ToolResult, error) {
 	key, ok := args["key"].(string)
 	if !ok || key == "" {
 		return ToolResult{Success: false, Message: "key must be a non-empty string"}, fmt.Errorf("invalid key")
 	}
-	
+
 	s.mu.RLock()
 	scratchpad, exists := s.storage[npcID]
 	s.mu.RUnlock()
-	
+
 	if !exists {
 		return ToolResult{Success: false, Message: fmt.Sprintf("No memory found with key: %s", key)}, nil
 	}
-	
+
+	// See handleWrite: scratchpad.mu stays held across the in-memory delete
+	// and the durable append so the two can't be observed out of order by a
+	// concurrent write/delete to the same NPC's scratchpad.
 	scratchpad.mu.Lock()
-	defer scratchpad.mu.Unlock()
-	
 	if _, exists := scratchpad.Entries[key]; !exists {
+		scratchpad.mu.Unlock()
 		return ToolResult{Success: false, Message: fmt.Sprintf("No memory found with key: %s", key)}, nil
 	}
-	
 	delete(scratchpad.Entries, key)
-	
+	entry := ScratchpadLogEntry{NPCID: npcID, Key: key, Op: ScratchpadOpDelete, Timestamp: time.Now()}
+	s.appendLog(entry)
+	if err := s.store.Append(ctx, entry); err != nil {
+		logging.Error("Failed to persist scratchpad delete", "error", err, "npc_id", npcID, "key", key)
+	}
+	scratchpad.mu.Unlock()
+
 	return ToolResult{
 		Success: true,
 		Message: fmt.Sprintf("Deleted memory with key: %s", key),
@@ -284,4 +524,4 @@ func (s *ScratchpadStorage) handleDelete(ctx context.Context, npcID string, args
 			"key": key,
 		},
 	}, nil
-}
\ No newline at end of file
+}
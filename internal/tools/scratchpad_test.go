@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestScratchpadStorage_SnapshotAtReconstructsPastState simulates an NPC
+// overwriting a memory and checks that SnapshotAt still reports what it
+// believed before the overwrite, not just the current value.
+func TestScratchpadStorage_SnapshotAtReconstructsPastState(t *testing.T) {
+	storage := NewScratchpadStorage()
+
+	if _, err := storage.handleWrite(context.Background(), "npc-1", map[string]interface{}{"key": "mood", "value": "curious"}); err != nil {
+		t.Fatalf("handleWrite() error = %v", err)
+	}
+	between := time.Now()
+	if _, err := storage.handleWrite(context.Background(), "npc-1", map[string]interface{}{"key": "mood", "value": "afraid"}); err != nil {
+		t.Fatalf("handleWrite() error = %v", err)
+	}
+
+	past := storage.SnapshotAt("npc-1", between)
+	if past["mood"].Value != "curious" {
+		t.Fatalf("SnapshotAt(between) mood = %q, want %q", past["mood"].Value, "curious")
+	}
+
+	now := storage.SnapshotAt("npc-1", time.Now())
+	if now["mood"].Value != "afraid" {
+		t.Fatalf("SnapshotAt(now) mood = %q, want %q", now["mood"].Value, "afraid")
+	}
+
+	history := storage.HistoryFor("npc-1", "mood")
+	if len(history) != 2 {
+		t.Fatalf("HistoryFor() returned %d entries, want 2", len(history))
+	}
+}
+
+// TestScratchpadStorage_SnapshotAtOmitsDeletedKeys checks that a key deleted
+// before the snapshot time doesn't appear in it, even though it existed earlier.
+func TestScratchpadStorage_SnapshotAtOmitsDeletedKeys(t *testing.T) {
+	storage := NewScratchpadStorage()
+
+	if _, err := storage.handleWrite(context.Background(), "npc-1", map[string]interface{}{"key": "secret", "value": "hidden passage"}); err != nil {
+		t.Fatalf("handleWrite() error = %v", err)
+	}
+	if _, err := storage.handleDelete(context.Background(), "npc-1", map[string]interface{}{"key": "secret"}); err != nil {
+		t.Fatalf("handleDelete() error = %v", err)
+	}
+
+	snapshot := storage.SnapshotAt("npc-1", time.Now())
+	if _, exists := snapshot["secret"]; exists {
+		t.Fatalf("expected deleted key to be absent from snapshot, got %+v", snapshot)
+	}
+}
+
+// TestJSONLScratchpadStore_SurvivesRestart simulates killing and restarting
+// the server: ScratchpadStorage writes a memory, is closed, and a fresh
+// ScratchpadStorage opened against the same log file must see both the
+// current state and the full history unchanged.
+func TestJSONLScratchpadStore_SurvivesRestart(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "scratchpad.jsonl")
+
+	store, err := NewJSONLScratchpadStore(logPath)
+	if err != nil {
+		t.Fatalf("NewJSONLScratchpadStore() error = %v", err)
+	}
+
+	storage := NewScratchpadStorageWithStore(store)
+	if _, err := storage.handleWrite(context.Background(), "npc-1", map[string]interface{}{"key": "ally", "value": "the blacksmith"}); err != nil {
+		t.Fatalf("handleWrite() error = %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("store.Close() error = %v", err)
+	}
+
+	reopened, err := NewJSONLScratchpadStore(logPath)
+	if err != nil {
+		t.Fatalf("reopening store error = %v", err)
+	}
+	defer reopened.Close()
+
+	restarted := NewScratchpadStorageWithStore(reopened)
+	if len(restarted.HistoryFor("npc-1", "ally")) != 1 {
+		t.Fatalf("expected history to survive restart, got %+v", restarted.HistoryFor("npc-1", "ally"))
+	}
+	if got := restarted.SnapshotAt("npc-1", time.Now())["ally"].Value; got != "the blacksmith" {
+		t.Fatalf("SnapshotAt() ally = %q, want %q", got, "the blacksmith")
+	}
+}
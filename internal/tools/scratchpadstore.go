@@ -0,0 +1,272 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// Scratchpad log operations, recorded on every ScratchpadLogEntry so the log
+// is independently replayable without relying on a zero Value to mean delete.
+const (
+	ScratchpadOpWrite  = "write"
+	ScratchpadOpDelete = "delete"
+)
+
+// ScratchpadLogEntry is a single durable log record for a scratchpad write or
+// delete, as appended by ScratchpadStore.Append. The full ordered log (not
+// just its folded projection) is what lets ScratchpadStorage answer "what did
+// this NPC believe at time T" after a restart.
+type ScratchpadLogEntry struct {
+	NPCID string `json:"npc_id"`
+	Key   string `json:"key"`
+	Op    string `json:"op"`
+	Value string `json:"value,omitempty"`
+	// CausingToolUseID is stored and replayed with the entry when set, so a
+	// replay can be traced back to the call that caused it. The scratchpad
+	// tools' own writes and deletes leave it empty.
+	CausingToolUseID string    `json:"causing_tool_use_id,omitempty"`
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+// ScratchpadStore is the durability layer behind ScratchpadStorage. Unlike
+// npc.Store, which persists whole-object snapshots, it is an append-only
+// log: every write or delete is appended via Append, and Load replays the
+// full log in order, both to rebuild the in-memory scratchpads a previous
+// run held and to seed ScratchpadStorage's history for time-travel replay.
+type ScratchpadStore interface {
+	Append(ctx context.Context, entry ScratchpadLogEntry) error
+	Load(ctx context.Context) (map[string]*NPCScratchpad, []ScratchpadLogEntry, error)
+}
+
+// NewScratchpadStore builds a ScratchpadStore from a backend URL, selected by scheme:
+//
+//	memory://                  no-op store, the default, does not survive restarts
+//	file:///path/to/log.jsonl  an append-only JSON-lines log, replayed on load
+//	bolt:///path/to/log.db     a local BoltDB-backed append-only log
+//
+// An empty rawURL defaults to "memory://".
+func NewScratchpadStore(rawURL string) (ScratchpadStore, error) {
+	if rawURL == "" {
+		rawURL = "memory://"
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scratchpad store URL %q: %w", rawURL, err)
+	}
+
+	switch parsed.Scheme {
+	case "", "memory":
+		return NewNullScratchpadStore(), nil
+	case "file", "jsonl":
+		path := parsed.Path
+		if path == "" {
+			path = parsed.Opaque
+		}
+		return NewJSONLScratchpadStore(path)
+	case "bolt", "boltdb":
+		path := parsed.Path
+		if path == "" {
+			path = parsed.Opaque
+		}
+		return NewBoltScratchpadStore(path)
+	default:
+		return nil, fmt.Errorf("unsupported scratchpad store scheme: %s", parsed.Scheme)
+	}
+}
+
+// NullScratchpadStore discards every append and loads nothing. It backs the
+// default NewScratchpadStorage, where scratchpads are memory-only.
+type NullScratchpadStore struct{}
+
+// NewNullScratchpadStore creates a ScratchpadStore that does not persist anything.
+func NewNullScratchpadStore() *NullScratchpadStore {
+	return &NullScratchpadStore{}
+}
+
+func (NullScratchpadStore) Append(ctx context.Context, entry ScratchpadLogEntry) error {
+	return nil
+}
+
+func (NullScratchpadStore) Load(ctx context.Context) (map[string]*NPCScratchpad, []ScratchpadLogEntry, error) {
+	return nil, nil, nil
+}
+
+// applyEntry folds a single ScratchpadLogEntry into an in-progress replay map.
+func applyEntry(scratchpads map[string]*NPCScratchpad, entry ScratchpadLogEntry) {
+	scratchpad, exists := scratchpads[entry.NPCID]
+	if !exists {
+		scratchpad = &NPCScratchpad{Entries: make(map[string]ScratchpadEntry)}
+		scratchpads[entry.NPCID] = scratchpad
+	}
+
+	if entry.Op == ScratchpadOpDelete {
+		delete(scratchpad.Entries, entry.Key)
+		return
+	}
+
+	scratchpad.Entries[entry.Key] = ScratchpadEntry{
+		Value:     entry.Value,
+		Timestamp: entry.Timestamp,
+	}
+}
+
+// JSONLScratchpadStore appends one JSON object per line to a log file,
+// replaying it in order on Load. Simple and inspectable, at the cost of an
+// ever-growing file with no compaction.
+type JSONLScratchpadStore struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewJSONLScratchpadStore opens (creating if necessary) a JSON-lines log
+// file at path, ready to append to.
+func NewJSONLScratchpadStore(path string) (*JSONLScratchpadStore, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("open scratchpad log: %w", err)
+	}
+	return &JSONLScratchpadStore{file: file}, nil
+}
+
+// Close closes the underlying log file.
+func (s *JSONLScratchpadStore) Close() error {
+	return s.file.Close()
+}
+
+func (s *JSONLScratchpadStore) Append(ctx context.Context, entry ScratchpadLogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.file.Write(append(data, '\n'))
+	return err
+}
+
+func (s *JSONLScratchpadStore) Load(ctx context.Context) (map[string]*NPCScratchpad, []ScratchpadLogEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Seek(0, 0); err != nil {
+		return nil, nil, fmt.Errorf("seek scratchpad log: %w", err)
+	}
+
+	result := make(map[string]*NPCScratchpad)
+	var log []ScratchpadLogEntry
+	scanner := bufio.NewScanner(s.file)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry ScratchpadLogEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, nil, fmt.Errorf("parse scratchpad log entry: %w", err)
+		}
+		applyEntry(result, entry)
+		log = append(log, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("read scratchpad log: %w", err)
+	}
+
+	if _, err := s.file.Seek(0, 2); err != nil {
+		return nil, nil, fmt.Errorf("seek scratchpad log: %w", err)
+	}
+
+	return result, log, nil
+}
+
+var scratchpadLogBucket = []byte("scratchpad_log")
+
+// BoltScratchpadStore appends log entries to a local BoltDB bucket (the
+// same file-backed approach webhook.BoltDeliveryStore uses for deliveries),
+// keyed by a monotonically increasing sequence number so Load replays them
+// in write order.
+type BoltScratchpadStore struct {
+	db *bbolt.DB
+}
+
+// NewBoltScratchpadStore opens (creating if necessary) a BoltDB-backed
+// scratchpad log at path.
+func NewBoltScratchpadStore(path string) (*BoltScratchpadStore, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(scratchpadLogBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltScratchpadStore{db: db}, nil
+}
+
+// Close closes the underlying BoltDB file.
+func (s *BoltScratchpadStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *BoltScratchpadStore) Append(ctx context.Context, entry ScratchpadLogEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(scratchpadLogBucket)
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		return b.Put(itob(seq), data)
+	})
+}
+
+func (s *BoltScratchpadStore) Load(ctx context.Context) (map[string]*NPCScratchpad, []ScratchpadLogEntry, error) {
+	result := make(map[string]*NPCScratchpad)
+	var log []ScratchpadLogEntry
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(scratchpadLogBucket).ForEach(func(k, v []byte) error {
+			var entry ScratchpadLogEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+			applyEntry(result, entry)
+			log = append(log, entry)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return result, log, nil
+}
+
+// itob encodes a sequence number as an 8-byte big-endian key, so BoltDB's
+// natural byte-order iteration matches write order.
+func itob(seq uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, seq)
+	return b
+}
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -14,21 +15,43 @@ type Session struct {
 	Tools     map[string]llm.Tool
 	CreatedAt time.Time
 	LastUsed  time.Time
+	Audit     []ToolCallAuditRecord
 }
 
-// SessionManager manages game sessions and their custom tools
+// SessionManager manages game sessions and their custom tools, backed by a
+// pluggable SessionStore so sessions, their tool sets, and audit history can
+// survive process restarts.
 type SessionManager struct {
-	sessions map[string]*Session
-	mu       sync.RWMutex
+	s SessionStore
 	// Configuration
 	expirationDuration time.Duration
+	stopCleanup        chan struct{}
+
+	// sessionLocksMu guards sessionLocks itself; each entry then serializes
+	// that one session ID's read-modify-write within this process, so two
+	// concurrent RegisterSession calls for the same session in the same
+	// process don't both retry the store's own compare-and-swap against
+	// each other for no reason. The actual cross-process safety comes from
+	// SessionStore.Update, which every backend implements atomically (e.g.
+	// EtcdSessionStore CASes against the mod revision it read, the same as
+	// Touch) - so two replicas racing the same session ID still can't
+	// silently clobber each other, even without this in-process lock.
+	sessionLocksMu sync.Mutex
+	sessionLocks   map[string]*sync.Mutex
 }
 
-// NewSessionManager creates a new session manager
+// NewSessionManager creates a new session manager backed by an in-memory store.
 func NewSessionManager() *SessionManager {
+	return NewSessionManagerWithStore(NewMemorySessionStore())
+}
+
+// NewSessionManagerWithStore creates a session manager backed by the given SessionStore.
+func NewSessionManagerWithStore(store SessionStore) *SessionManager {
 	sm := &SessionManager{
-		sessions:           make(map[string]*Session),
+		s:                  store,
 		expirationDuration: 1 * time.Hour, // Sessions expire after 1 hour of inactivity
+		stopCleanup:        make(chan struct{}),
+		sessionLocks:       make(map[string]*sync.Mutex),
 	}
 
 	// Start cleanup goroutine
@@ -37,44 +60,63 @@ func NewSessionManager() *SessionManager {
 	return sm
 }
 
+// Close stops the background cleanup goroutine.
+func (sm *SessionManager) Close() {
+	close(sm.stopCleanup)
+}
+
+// lockFor returns the per-session mutex serializing read-modify-write
+// sequences against sm.s for sessionID, creating it on first use.
+func (sm *SessionManager) lockFor(sessionID string) *sync.Mutex {
+	sm.sessionLocksMu.Lock()
+	defer sm.sessionLocksMu.Unlock()
+
+	lock, ok := sm.sessionLocks[sessionID]
+	if !ok {
+		lock = &sync.Mutex{}
+		sm.sessionLocks[sessionID] = lock
+	}
+	return lock
+}
+
 // RegisterSession creates or updates a session with custom tools
 func (sm *SessionManager) RegisterSession(sessionID string, tools []llm.Tool) error {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
-
-	session, exists := sm.sessions[sessionID]
-	if !exists {
-		session = &Session{
-			ID:        sessionID,
-			Tools:     make(map[string]llm.Tool),
-			CreatedAt: time.Now(),
-			LastUsed:  time.Now(),
+	lock := sm.lockFor(sessionID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	_, err := sm.s.Update(context.Background(), sessionID, func(session *Session) (*Session, error) {
+		if session == nil {
+			session = &Session{
+				ID:        sessionID,
+				Tools:     make(map[string]llm.Tool),
+				CreatedAt: time.Now(),
+			}
 		}
-		sm.sessions[sessionID] = session
-	} else {
 		session.LastUsed = time.Now()
-	}
 
-	// Add or update tools for this session
-	for _, tool := range tools {
-		session.Tools[tool.Name] = tool
-	}
+		// Add or update tools for this session
+		for _, tool := range tools {
+			session.Tools[tool.Name] = tool
+		}
 
-	return nil
+		return session, nil
+	})
+	return err
 }
 
 // GetSessionTools returns all tools registered for a session
 func (sm *SessionManager) GetSessionTools(sessionID string) ([]llm.Tool, error) {
-	sm.mu.RLock()
-	defer sm.mu.RUnlock()
+	ctx := context.Background()
 
-	session, exists := sm.sessions[sessionID]
-	if !exists {
+	session, err := sm.s.Get(ctx, sessionID)
+	if err != nil {
 		return nil, fmt.Errorf("session not found: %s", sessionID)
 	}
 
-	// Update last used time
-	session.LastUsed = time.Now()
+	if err := sm.s.Touch(ctx, sessionID); err != nil {
+		return nil, fmt.Errorf("failed to touch session: %w", err)
+	}
 
 	// Convert map to slice
 	tools := make([]llm.Tool, 0, len(session.Tools))
@@ -85,53 +127,104 @@ func (sm *SessionManager) GetSessionTools(sessionID string) ([]llm.Tool, error)
 	return tools, nil
 }
 
-// TouchSession updates the last used time for a session
-func (sm *SessionManager) TouchSession(sessionID string) {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
+// RecordToolCall appends a tool-call audit record to a session's history.
+func (sm *SessionManager) RecordToolCall(sessionID string, record ToolCallAuditRecord) error {
+	lock := sm.lockFor(sessionID)
+	lock.Lock()
+	defer lock.Unlock()
 
-	if session, exists := sm.sessions[sessionID]; exists {
-		session.LastUsed = time.Now()
+	_, err := sm.s.Update(context.Background(), sessionID, func(session *Session) (*Session, error) {
+		if session == nil {
+			return nil, fmt.Errorf("session not found: %s", sessionID)
+		}
+		session.Audit = append(session.Audit, record)
+		return session, nil
+	})
+	return err
+}
+
+// GetSessionHistory returns the tool-call audit trail persisted alongside a session.
+func (sm *SessionManager) GetSessionHistory(sessionID string) ([]ToolCallAuditRecord, error) {
+	session, err := sm.s.Get(context.Background(), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("session not found: %s", sessionID)
 	}
+	return session.Audit, nil
+}
+
+// TouchSession updates the last used time for a session
+func (sm *SessionManager) TouchSession(sessionID string) {
+	_ = sm.s.Touch(context.Background(), sessionID)
 }
 
 // DeleteSession removes a session and its tools
 func (sm *SessionManager) DeleteSession(sessionID string) error {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
-
-	if _, exists := sm.sessions[sessionID]; !exists {
+	if err := sm.s.Delete(context.Background(), sessionID); err != nil {
 		return fmt.Errorf("session not found: %s", sessionID)
 	}
 
-	delete(sm.sessions, sessionID)
+	sm.sessionLocksMu.Lock()
+	delete(sm.sessionLocks, sessionID)
+	sm.sessionLocksMu.Unlock()
+
 	return nil
 }
 
 // GetSessionCount returns the number of active sessions
 func (sm *SessionManager) GetSessionCount() int {
-	sm.mu.RLock()
-	defer sm.mu.RUnlock()
-	return len(sm.sessions)
+	counter, ok := sm.s.(interface{ Count(context.Context) (int, error) })
+	if !ok {
+		return 0
+	}
+	count, err := counter.Count(context.Background())
+	if err != nil {
+		return 0
+	}
+	return count
 }
 
-// cleanupExpiredSessions runs periodically to remove expired sessions
+// cleanupExpiredSessions runs periodically to remove expired sessions. Against
+// a distributed SessionStore this becomes a leader-elected task (see
+// NewEtcdSessionStore) so multiple backend replicas don't race to delete the
+// same sessions.
 func (sm *SessionManager) cleanupExpiredSessions() {
 	ticker := time.NewTicker(5 * time.Minute) // Check every 5 minutes
 	defer ticker.Stop()
 
-	for range ticker.C {
-		sm.mu.Lock()
-		now := time.Now()
-		for sessionID, session := range sm.sessions {
-			if now.Sub(session.LastUsed) > sm.expirationDuration {
-				delete(sm.sessions, sessionID)
+	leaderAware, isLeaderAware := sm.s.(leaderAwareStore)
+
+	for {
+		select {
+		case <-sm.stopCleanup:
+			return
+		case <-ticker.C:
+			if isLeaderAware && !leaderAware.IsLeader() {
+				continue
+			}
+
+			ctx := context.Background()
+			cutoff := time.Now().Add(-sm.expirationDuration)
+			expired, err := sm.s.ListExpired(ctx, cutoff)
+			if err != nil {
+				continue
+			}
+			for _, sessionID := range expired {
+				_ = sm.s.Delete(ctx, sessionID)
+
+				sm.sessionLocksMu.Lock()
+				delete(sm.sessionLocks, sessionID)
+				sm.sessionLocksMu.Unlock()
 			}
 		}
-		sm.mu.Unlock()
 	}
 }
 
+// leaderAwareStore is implemented by SessionStore backends where cleanup must
+// be coordinated across replicas (e.g. the etcd-backed store).
+type leaderAwareStore interface {
+	IsLeader() bool
+}
+
 // ToolRegistrationRequest represents the request to register tools for a session
 type ToolRegistrationRequest struct {
 	SessionID string     `json:"session_id" binding:"required"`
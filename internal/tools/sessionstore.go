@@ -0,0 +1,205 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/piercegov/llm-npc-backend/internal/llm"
+)
+
+// ToolCallAuditRecord is a single tool invocation recorded against a session,
+// persisted alongside the session so GET /tools/session/{id}/history survives restarts.
+type ToolCallAuditRecord struct {
+	ToolName  string                 `json:"tool_name"`
+	Args      map[string]interface{} `json:"args"`
+	Success   bool                   `json:"success"`
+	Timestamp time.Time              `json:"timestamp"`
+}
+
+// SessionStore is the persistence layer behind SessionManager. Implementations
+// must be safe for concurrent use.
+type SessionStore interface {
+	Get(ctx context.Context, sessionID string) (*Session, error)
+	Put(ctx context.Context, session *Session) error
+	Delete(ctx context.Context, sessionID string) error
+	Touch(ctx context.Context, sessionID string) error
+	ListExpired(ctx context.Context, olderThan time.Time) ([]string, error)
+
+	// Update fetches sessionID, applies mutate to it, and persists the
+	// result, all as a single atomic operation per backend (Etcd via
+	// compare-and-swap against the mod revision it read, the same way Touch
+	// does; Memory/File under their own single lock/transaction) - so two
+	// callers racing the same session ID can't silently clobber each
+	// other's write the way a plain Get-then-Put would. If sessionID
+	// doesn't exist yet, mutate is called with a nil session; mutate
+	// decides whether that's an error (RecordToolCall) or something to
+	// create (RegisterSession).
+	Update(ctx context.Context, sessionID string, mutate func(session *Session) (*Session, error)) (*Session, error)
+}
+
+// ErrSessionNotFound is returned by SessionStore implementations when a session ID is unknown.
+var ErrSessionNotFound = fmt.Errorf("session not found")
+
+// NewSessionStore builds a SessionStore from a backend URL, selected by scheme:
+//
+//	memory://                in-memory map, the default, does not survive restarts
+//	file:///path/to/sessions.db   a local BoltDB-backed store
+//	etcd://host:port[,host2:port2,...]/prefix   a distributed, etcd-backed store
+//
+// An empty rawURL defaults to "memory://".
+func NewSessionStore(rawURL string) (SessionStore, error) {
+	if rawURL == "" {
+		rawURL = "memory://"
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid session store URL %q: %w", rawURL, err)
+	}
+
+	switch parsed.Scheme {
+	case "", "memory":
+		return NewMemorySessionStore(), nil
+	case "file", "bolt", "boltdb":
+		path := parsed.Path
+		if path == "" {
+			path = parsed.Opaque
+		}
+		return NewFileSessionStore(path)
+	case "etcd":
+		return NewEtcdSessionStore(parsed)
+	case "redis":
+		return nil, fmt.Errorf("redis session store backend is not yet implemented")
+	default:
+		return nil, fmt.Errorf("unsupported session store scheme: %s", parsed.Scheme)
+	}
+}
+
+// MemorySessionStore is the original in-memory SessionStore implementation.
+type MemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+// NewMemorySessionStore creates an empty in-memory session store.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]*Session)}
+}
+
+func (m *MemorySessionStore) Get(ctx context.Context, sessionID string) (*Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	clone := *session
+	return &clone, nil
+}
+
+func (m *MemorySessionStore) Put(ctx context.Context, session *Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	clone := *session
+	m.sessions[session.ID] = &clone
+	return nil
+}
+
+func (m *MemorySessionStore) Delete(ctx context.Context, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.sessions[sessionID]; !exists {
+		return ErrSessionNotFound
+	}
+	delete(m.sessions, sessionID)
+	return nil
+}
+
+func (m *MemorySessionStore) Touch(ctx context.Context, sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return ErrSessionNotFound
+	}
+	session.LastUsed = time.Now()
+	return nil
+}
+
+func (m *MemorySessionStore) Update(ctx context.Context, sessionID string, mutate func(*Session) (*Session, error)) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var current *Session
+	if existing, exists := m.sessions[sessionID]; exists {
+		clone := *existing
+		current = &clone
+	}
+
+	updated, err := mutate(current)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := *updated
+	m.sessions[sessionID] = &clone
+	return updated, nil
+}
+
+// Count returns the number of sessions currently in the store.
+func (m *MemorySessionStore) Count(ctx context.Context) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.sessions), nil
+}
+
+func (m *MemorySessionStore) ListExpired(ctx context.Context, olderThan time.Time) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var expired []string
+	for id, session := range m.sessions {
+		if session.LastUsed.Before(olderThan) {
+			expired = append(expired, id)
+		}
+	}
+	return expired, nil
+}
+
+// sessionRecord is the JSON-serializable form of a Session, used by the
+// persistent SessionStore implementations.
+type sessionRecord struct {
+	ID        string                `json:"id"`
+	Tools     map[string]llm.Tool   `json:"tools"`
+	CreatedAt time.Time             `json:"created_at"`
+	LastUsed  time.Time             `json:"last_used"`
+	Audit     []ToolCallAuditRecord `json:"audit"`
+}
+
+func toSessionRecord(s *Session) sessionRecord {
+	return sessionRecord{
+		ID:        s.ID,
+		Tools:     s.Tools,
+		CreatedAt: s.CreatedAt,
+		LastUsed:  s.LastUsed,
+		Audit:     s.Audit,
+	}
+}
+
+func (r sessionRecord) toSession() *Session {
+	return &Session{
+		ID:        r.ID,
+		Tools:     r.Tools,
+		CreatedAt: r.CreatedAt,
+		LastUsed:  r.LastUsed,
+		Audit:     r.Audit,
+	}
+}
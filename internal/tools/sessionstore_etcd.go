@@ -0,0 +1,259 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/piercegov/llm-npc-backend/internal/logging"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+const etcdElectionName = "llm-npc-backend/session-cleanup-leader"
+
+// EtcdSessionStore is a distributed SessionStore backed by etcd, so multiple
+// backend replicas can share session state and coordinate cleanup via a
+// leader-elected lease/lock instead of all racing to delete expired sessions.
+type EtcdSessionStore struct {
+	client   *clientv3.Client
+	prefix   string
+	session  *concurrency.Session
+	election *concurrency.Election
+	isLeader bool
+}
+
+// NewEtcdSessionStore connects to an etcd cluster described by a parsed
+// "etcd://host:port[,host2:port2,...]/prefix" URL and starts campaigning for
+// cleanup leadership in the background.
+func NewEtcdSessionStore(parsed *url.URL) (*EtcdSessionStore, error) {
+	endpoints := strings.Split(parsed.Host, ",")
+	prefix := strings.TrimPrefix(parsed.Path, "/")
+	if prefix == "" {
+		prefix = "llm-npc-backend/sessions"
+	}
+
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: 5 * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	concurrencySession, err := concurrency.NewSession(client)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	store := &EtcdSessionStore{
+		client:   client,
+		prefix:   prefix,
+		session:  concurrencySession,
+		election: concurrency.NewElection(concurrencySession, etcdElectionName),
+	}
+
+	go store.campaignForLeadership()
+
+	return store, nil
+}
+
+// campaignForLeadership blocks on the etcd election until this replica
+// becomes the cleanup leader, retrying every 5 seconds while Campaign
+// fails. Once leader, it holds leadership until the etcd session closes
+// (on Close or lease loss), then clears isLeader and returns without
+// campaigning again.
+func (e *EtcdSessionStore) campaignForLeadership() {
+	for {
+		if err := e.election.Campaign(context.Background(), "cleanup-leader"); err != nil {
+			logging.Warn("Etcd session store leadership campaign failed, retrying", "error", err)
+			time.Sleep(5 * time.Second)
+			continue
+		}
+
+		e.isLeader = true
+		logging.Info("Became session cleanup leader")
+
+		<-e.session.Done()
+		e.isLeader = false
+		logging.Warn("Lost session cleanup leadership, etcd session closed")
+		return
+	}
+}
+
+// IsLeader reports whether this replica currently holds the cleanup lease.
+func (e *EtcdSessionStore) IsLeader() bool {
+	return e.isLeader
+}
+
+// Close releases the etcd session/election and closes the client connection.
+func (e *EtcdSessionStore) Close() error {
+	_ = e.election.Resign(context.Background())
+	_ = e.session.Close()
+	return e.client.Close()
+}
+
+func (e *EtcdSessionStore) key(sessionID string) string {
+	return e.prefix + "/" + sessionID
+}
+
+func (e *EtcdSessionStore) Get(ctx context.Context, sessionID string) (*Session, error) {
+	resp, err := e.client.Get(ctx, e.key(sessionID))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, ErrSessionNotFound
+	}
+
+	var record sessionRecord
+	if err := json.Unmarshal(resp.Kvs[0].Value, &record); err != nil {
+		return nil, err
+	}
+	return record.toSession(), nil
+}
+
+func (e *EtcdSessionStore) Put(ctx context.Context, session *Session) error {
+	data, err := json.Marshal(toSessionRecord(session))
+	if err != nil {
+		return err
+	}
+	_, err = e.client.Put(ctx, e.key(session.ID), string(data))
+	return err
+}
+
+func (e *EtcdSessionStore) Delete(ctx context.Context, sessionID string) error {
+	resp, err := e.client.Delete(ctx, e.key(sessionID))
+	if err != nil {
+		return err
+	}
+	if resp.Deleted == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// etcdTouchMaxAttempts bounds Touch's compare-and-swap retry loop against
+// another replica concurrently writing the same session key.
+const etcdTouchMaxAttempts = 5
+
+// Touch updates sessionID's LastUsed via a compare-and-swap on the key's mod
+// revision, retrying on conflict instead of Get-then-Put'ing blind: two
+// replicas touching (or registering) the same session concurrently would
+// otherwise race, with the loser's write silently overwriting the winner's.
+func (e *EtcdSessionStore) Touch(ctx context.Context, sessionID string) error {
+	key := e.key(sessionID)
+
+	for attempt := 0; attempt < etcdTouchMaxAttempts; attempt++ {
+		resp, err := e.client.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if len(resp.Kvs) == 0 {
+			return ErrSessionNotFound
+		}
+
+		var record sessionRecord
+		if err := json.Unmarshal(resp.Kvs[0].Value, &record); err != nil {
+			return err
+		}
+		record.LastUsed = time.Now()
+
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+
+		txnResp, err := e.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(key), "=", resp.Kvs[0].ModRevision)).
+			Then(clientv3.OpPut(key, string(data))).
+			Commit()
+		if err != nil {
+			return err
+		}
+		if txnResp.Succeeded {
+			return nil
+		}
+		// Another writer updated this key between our Get and Txn; retry.
+	}
+	return fmt.Errorf("touching session %s: lost the compare-and-swap race %d times in a row", sessionID, etcdTouchMaxAttempts)
+}
+
+// Update generalizes Touch's compare-and-swap retry loop to an arbitrary
+// caller-supplied mutation, so RegisterSession and RecordToolCall get the
+// same cross-replica safety Touch already has instead of a blind Get-then-Put.
+func (e *EtcdSessionStore) Update(ctx context.Context, sessionID string, mutate func(*Session) (*Session, error)) (*Session, error) {
+	key := e.key(sessionID)
+
+	for attempt := 0; attempt < etcdTouchMaxAttempts; attempt++ {
+		resp, err := e.client.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		var current *Session
+		var modRevision int64
+		if len(resp.Kvs) > 0 {
+			var record sessionRecord
+			if err := json.Unmarshal(resp.Kvs[0].Value, &record); err != nil {
+				return nil, err
+			}
+			current = record.toSession()
+			modRevision = resp.Kvs[0].ModRevision
+		}
+
+		updated, err := mutate(current)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(toSessionRecord(updated))
+		if err != nil {
+			return nil, err
+		}
+
+		txnResp, err := e.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(key), "=", modRevision)).
+			Then(clientv3.OpPut(key, string(data))).
+			Commit()
+		if err != nil {
+			return nil, err
+		}
+		if txnResp.Succeeded {
+			return updated, nil
+		}
+		// Another writer updated this key between our Get and Txn; retry.
+	}
+	return nil, fmt.Errorf("updating session %s: lost the compare-and-swap race %d times in a row", sessionID, etcdTouchMaxAttempts)
+}
+
+func (e *EtcdSessionStore) Count(ctx context.Context) (int, error) {
+	resp, err := e.client.Get(ctx, e.prefix+"/", clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return 0, err
+	}
+	return int(resp.Count), nil
+}
+
+func (e *EtcdSessionStore) ListExpired(ctx context.Context, olderThan time.Time) ([]string, error) {
+	resp, err := e.client.Get(ctx, e.prefix+"/", clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []string
+	for _, kv := range resp.Kvs {
+		var record sessionRecord
+		if err := json.Unmarshal(kv.Value, &record); err != nil {
+			continue
+		}
+		if record.LastUsed.Before(olderThan) {
+			expired = append(expired, record.ID)
+		}
+	}
+	return expired, nil
+}
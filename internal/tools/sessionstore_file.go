@@ -0,0 +1,168 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var sessionsBucket = []byte("sessions")
+
+// FileSessionStore persists sessions to a local BoltDB file, so a single
+// backend replica survives process restarts without an external dependency.
+type FileSessionStore struct {
+	db *bbolt.DB
+}
+
+// NewFileSessionStore opens (creating if necessary) a BoltDB-backed session store at path.
+func NewFileSessionStore(path string) (*FileSessionStore, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(sessionsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &FileSessionStore{db: db}, nil
+}
+
+// Close closes the underlying BoltDB file.
+func (f *FileSessionStore) Close() error {
+	return f.db.Close()
+}
+
+func (f *FileSessionStore) Get(ctx context.Context, sessionID string) (*Session, error) {
+	var record sessionRecord
+	found := false
+
+	err := f.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(sessionsBucket).Get([]byte(sessionID))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrSessionNotFound
+	}
+
+	return record.toSession(), nil
+}
+
+func (f *FileSessionStore) Put(ctx context.Context, session *Session) error {
+	data, err := json.Marshal(toSessionRecord(session))
+	if err != nil {
+		return err
+	}
+
+	return f.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(sessionsBucket).Put([]byte(session.ID), data)
+	})
+}
+
+func (f *FileSessionStore) Delete(ctx context.Context, sessionID string) error {
+	existed := false
+
+	err := f.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(sessionsBucket)
+		if bucket.Get([]byte(sessionID)) == nil {
+			return nil
+		}
+		existed = true
+		return bucket.Delete([]byte(sessionID))
+	})
+	if err != nil {
+		return err
+	}
+	if !existed {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (f *FileSessionStore) Touch(ctx context.Context, sessionID string) error {
+	session, err := f.Get(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	session.LastUsed = time.Now()
+	return f.Put(ctx, session)
+}
+
+func (f *FileSessionStore) Update(ctx context.Context, sessionID string, mutate func(*Session) (*Session, error)) (*Session, error) {
+	var result *Session
+
+	err := f.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(sessionsBucket)
+		data := bucket.Get([]byte(sessionID))
+
+		var current *Session
+		if data != nil {
+			var record sessionRecord
+			if err := json.Unmarshal(data, &record); err != nil {
+				return err
+			}
+			current = record.toSession()
+		}
+
+		updated, err := mutate(current)
+		if err != nil {
+			return err
+		}
+
+		encoded, err := json.Marshal(toSessionRecord(updated))
+		if err != nil {
+			return err
+		}
+
+		result = updated
+		return bucket.Put([]byte(sessionID), encoded)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (f *FileSessionStore) Count(ctx context.Context) (int, error) {
+	count := 0
+	err := f.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(sessionsBucket).ForEach(func(k, v []byte) error {
+			count++
+			return nil
+		})
+	})
+	return count, err
+}
+
+func (f *FileSessionStore) ListExpired(ctx context.Context, olderThan time.Time) ([]string, error) {
+	var expired []string
+
+	err := f.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(sessionsBucket).ForEach(func(k, v []byte) error {
+			var record sessionRecord
+			if err := json.Unmarshal(v, &record); err != nil {
+				return err
+			}
+			if record.LastUsed.Before(olderThan) {
+				expired = append(expired, record.ID)
+			}
+			return nil
+		})
+	})
+
+	return expired, err
+}
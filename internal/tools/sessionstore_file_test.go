@@ -0,0 +1,59 @@
+package tools
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/piercegov/llm-npc-backend/internal/llm"
+)
+
+// TestFileSessionStore_SurvivesRestart simulates killing and restarting the
+// server mid-conversation: a SessionManager registers a session and records a
+// tool call, is closed (as on shutdown), and a fresh SessionManager opened
+// against the same file must see the session's tools and history unchanged.
+func TestFileSessionStore_SurvivesRestart(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "sessions.db")
+
+	store, err := NewFileSessionStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewFileSessionStore() error = %v", err)
+	}
+
+	sm := NewSessionManagerWithStore(store)
+	if err := sm.RegisterSession("session-1", []llm.Tool{{Name: "get_weather", Description: "test tool"}}); err != nil {
+		t.Fatalf("RegisterSession() error = %v", err)
+	}
+	if err := sm.RecordToolCall("session-1", ToolCallAuditRecord{ToolName: "get_weather", Success: true}); err != nil {
+		t.Fatalf("RecordToolCall() error = %v", err)
+	}
+	sm.Close()
+	if err := store.Close(); err != nil {
+		t.Fatalf("store.Close() error = %v", err)
+	}
+
+	// Simulate the process restarting against the same on-disk store.
+	reopened, err := NewFileSessionStore(dbPath)
+	if err != nil {
+		t.Fatalf("reopening store error = %v", err)
+	}
+	defer reopened.Close()
+
+	restarted := NewSessionManagerWithStore(reopened)
+	defer restarted.Close()
+
+	toolsAfterRestart, err := restarted.GetSessionTools("session-1")
+	if err != nil {
+		t.Fatalf("GetSessionTools() after restart error = %v", err)
+	}
+	if len(toolsAfterRestart) != 1 || toolsAfterRestart[0].Name != "get_weather" {
+		t.Errorf("expected session tools to survive restart, got %+v", toolsAfterRestart)
+	}
+
+	history, err := restarted.GetSessionHistory("session-1")
+	if err != nil {
+		t.Fatalf("GetSessionHistory() after restart error = %v", err)
+	}
+	if len(history) != 1 || history[0].ToolName != "get_weather" {
+		t.Errorf("expected tool-call history to survive restart, got %+v", history)
+	}
+}
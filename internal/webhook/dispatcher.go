@@ -0,0 +1,313 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/piercegov/llm-npc-backend/internal/logging"
+)
+
+// defaultWorkers is how many goroutines concurrently attempt deliveries when
+// Dispatcher is given a non-positive worker count.
+const defaultWorkers = 4
+
+// RetryPolicy configures how Dispatcher retries a delivery that failed with a
+// retryable status (429 or 5xx), mirroring llm.LMStudio's own RetryPolicy.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Jitter scales each computed delay by a random factor in
+	// [1-Jitter, 1+Jitter]. Zero disables jitter.
+	Jitter float64
+}
+
+// defaultRetryPolicy is used when Dispatcher.Retry is left zero-valued.
+var defaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    time.Minute,
+	Jitter:      0.2,
+}
+
+// backoff computes the delay before retry attempt's next try (1-indexed):
+// BaseDelay * 2^(attempt-1), capped at MaxDelay, then jittered.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = defaultRetryPolicy.BaseDelay
+	}
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = defaultRetryPolicy.MaxDelay
+	}
+
+	delay := float64(base) * math.Pow(2, float64(attempt-1))
+	if delay <= 0 || delay > float64(maxDelay) {
+		delay = float64(maxDelay)
+	}
+
+	if p.Jitter > 0 {
+		factor := 1 - p.Jitter + rand.Float64()*2*p.Jitter
+		delay *= factor
+	}
+
+	return time.Duration(delay)
+}
+
+// Dispatcher fans out NPC events to every subscribed webhook and drives each
+// delivery through a bounded worker pool, so a slow or unreachable webhook
+// endpoint never blocks the caller (e.g. ActHandler).
+type Dispatcher struct {
+	registry *Registry
+	store    DeliveryStore
+	client   *http.Client
+	retry    RetryPolicy
+	workers  int
+	// validateURL is re-checked immediately before every delivery attempt,
+	// defaulting to validateWebhookURL. Tests that deliver to a local
+	// httptest server (necessarily plain http on a loopback address)
+	// override it, the same way they override retry.
+	validateURL func(string) error
+
+	jobs chan *Delivery
+	wg   sync.WaitGroup
+	stop chan struct{}
+}
+
+// NewDispatcher creates a Dispatcher with the given number of concurrent
+// delivery workers (a non-positive count uses defaultWorkers).
+func NewDispatcher(registry *Registry, store DeliveryStore, workers int) *Dispatcher {
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	return &Dispatcher{
+		registry:    registry,
+		store:       store,
+		client:      newWebhookHTTPClient(10 * time.Second),
+		retry:       defaultRetryPolicy,
+		workers:     workers,
+		validateURL: validateWebhookURL,
+		jobs:        make(chan *Delivery, 256),
+		stop:        make(chan struct{}),
+	}
+}
+
+// Start launches the worker pool and requeues any delivery a previous run
+// left Pending. Registry is in-memory and Register mints a fresh ID, so a
+// requeued delivery whose webhook was registered by a previous run is
+// marked Failed ("webhook is no longer registered") rather than sent.
+func (d *Dispatcher) Start() {
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go d.worker()
+	}
+
+	pending, err := d.store.Pending()
+	if err != nil {
+		logging.Error("Failed to load pending webhook deliveries", "error", err)
+		return
+	}
+	for _, delivery := range pending {
+		d.enqueue(delivery)
+	}
+}
+
+// Stop signals every worker to finish its current delivery and return, then
+// waits for them to exit.
+func (d *Dispatcher) Stop() {
+	close(d.stop)
+	d.wg.Wait()
+}
+
+// Fire persists a Pending delivery for every webhook npcID has subscribed to
+// eventType and enqueues it for the worker pool, returning immediately
+// without waiting for any delivery attempt.
+func (d *Dispatcher) Fire(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return fmt.Errorf("marshal webhook payload: %w", err)
+	}
+
+	for _, cfg := range d.registry.For(event.NPCID, event.Type) {
+		now := time.Now()
+		delivery := &Delivery{
+			ID:          uuid.New().String(),
+			WebhookID:   cfg.ID,
+			NPCID:       event.NPCID,
+			URL:         cfg.URL,
+			EventType:   event.Type,
+			Payload:     payload,
+			RequestID:   event.RequestID,
+			Status:      StatusPending,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			NextAttempt: now,
+		}
+
+		if err := d.store.Save(delivery); err != nil {
+			logging.Error("Failed to persist webhook delivery", "error", err, "npc_id", event.NPCID, "webhook_id", cfg.ID)
+			continue
+		}
+		d.enqueue(delivery)
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) enqueue(delivery *Delivery) {
+	select {
+	case d.jobs <- delivery:
+	case <-d.stop:
+	}
+}
+
+func (d *Dispatcher) worker() {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.stop:
+			return
+		case delivery := <-d.jobs:
+			d.attempt(delivery)
+		}
+	}
+}
+
+// attempt waits out any scheduled backoff, sends delivery, and persists the
+// resulting status, re-enqueuing it if the failure is retryable.
+func (d *Dispatcher) attempt(delivery *Delivery) {
+	if wait := time.Until(delivery.NextAttempt); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-d.stop:
+			return
+		}
+	}
+
+	cfg, ok := d.registry.Get(delivery.NPCID, delivery.WebhookID)
+	if !ok {
+		delivery.Status = StatusFailed
+		delivery.LastError = "webhook is no longer registered"
+		d.save(delivery)
+		return
+	}
+
+	delivery.Attempts++
+	delivery.UpdatedAt = time.Now()
+
+	// Re-validate at dispatch time, not just registration time: the
+	// webhook's hostname may now resolve to a blocked address it didn't
+	// resolve to when it was registered (DNS rebinding), and this also
+	// catches any webhook registered before this check existed.
+	if err := d.validateURL(cfg.URL); err != nil {
+		delivery.Status = StatusFailed
+		delivery.LastError = err.Error()
+		d.save(delivery)
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, cfg.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		delivery.Status = StatusFailed
+		delivery.LastError = err.Error()
+		d.save(delivery)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Signature", sign(cfg.Secret, delivery.Payload))
+	req.Header.Set("X-Webhook-Event", string(delivery.EventType))
+	req.Header.Set("X-Webhook-Delivery", delivery.ID)
+	if delivery.RequestID != "" {
+		req.Header.Set("X-Request-ID", delivery.RequestID)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		d.retryOrFail(delivery, 0, err)
+		return
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		delivery.Status = StatusDelivered
+		delivery.LastError = ""
+		d.save(delivery)
+		return
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		d.retryOrFail(delivery, parseRetryAfterHeader(resp.Header.Get("Retry-After")), fmt.Errorf("webhook endpoint returned status %d", resp.StatusCode))
+		return
+	}
+
+	// Any other 4xx can never succeed by retrying the same payload.
+	delivery.Status = StatusFailed
+	delivery.LastError = fmt.Sprintf("webhook endpoint returned status %d", resp.StatusCode)
+	d.save(delivery)
+}
+
+// retryOrFail marks delivery Failed once it has exhausted d.retry.MaxAttempts,
+// otherwise schedules its next attempt (honoring retryAfter, if given by the
+// endpoint) and re-enqueues it.
+func (d *Dispatcher) retryOrFail(delivery *Delivery, retryAfter time.Duration, cause error) {
+	delivery.LastError = cause.Error()
+
+	maxAttempts := d.retry.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultRetryPolicy.MaxAttempts
+	}
+	if delivery.Attempts >= maxAttempts {
+		delivery.Status = StatusFailed
+		d.save(delivery)
+		return
+	}
+
+	delay := retryAfter
+	if delay <= 0 {
+		delay = d.retry.backoff(delivery.Attempts)
+	}
+	delivery.Status = StatusPending
+	delivery.NextAttempt = time.Now().Add(delay)
+	d.save(delivery)
+	d.enqueue(delivery)
+}
+
+func (d *Dispatcher) save(delivery *Delivery) {
+	if err := d.store.Save(delivery); err != nil {
+		logging.Error("Failed to persist webhook delivery", "error", err, "delivery_id", delivery.ID)
+	}
+}
+
+// parseRetryAfterHeader parses a Retry-After header value, which per RFC
+// 9110 is either a delay in seconds or an HTTP-date. An unparsable or
+// already-past value yields zero, leaving the caller to fall back to its
+// own computed backoff.
+func parseRetryAfterHeader(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(when); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
@@ -0,0 +1,127 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestDispatcher(t *testing.T, registry *Registry) (*Dispatcher, DeliveryStore) {
+	t.Helper()
+
+	store, err := NewBoltDeliveryStore(filepath.Join(t.TempDir(), "webhooks.db"))
+	if err != nil {
+		t.Fatalf("NewBoltDeliveryStore() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	d := NewDispatcher(registry, store, 2)
+	d.retry = RetryPolicy{MaxAttempts: 3, BaseDelay: 5 * time.Millisecond, MaxDelay: 20 * time.Millisecond}
+	// httptest servers are plain http on a loopback address, which
+	// validateWebhookURL (rightly) rejects for real traffic and the default
+	// client's Transport (rightly) refuses to dial; these tests are about
+	// delivery/retry behavior, not URL validation, which has its own tests.
+	d.validateURL = func(string) error { return nil }
+	d.client = &http.Client{Timeout: 10 * time.Second}
+	d.Start()
+	t.Cleanup(d.Stop)
+
+	return d, store
+}
+
+func waitForDelivery(t *testing.T, store DeliveryStore, npcID string, want DeliveryStatus) *Delivery {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		deliveries, err := store.ForNPC(npcID)
+		if err != nil {
+			t.Fatalf("ForNPC() error = %v", err)
+		}
+		if len(deliveries) == 1 && deliveries[0].Status == want {
+			return deliveries[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("delivery for %s never reached status %s", npcID, want)
+	return nil
+}
+
+func TestDispatcher_Fire_DeliversToSubscribedWebhook(t *testing.T) {
+	var gotSignature, gotEvent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get("X-Webhook-Signature")
+		gotEvent = r.Header.Get("X-Webhook-Event")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	registry := NewRegistry()
+	registry.Register("npc-1", server.URL, "shh", []EventType{EventNPCTick})
+
+	d, store := newTestDispatcher(t, registry)
+
+	if err := d.Fire(context.Background(), Event{NPCID: "npc-1", Type: EventNPCTick, Payload: map[string]string{"ok": "true"}}); err != nil {
+		t.Fatalf("Fire() error = %v", err)
+	}
+
+	waitForDelivery(t, store, "npc-1", StatusDelivered)
+
+	if gotSignature == "" {
+		t.Errorf("expected the request to carry an X-Webhook-Signature header")
+	}
+	if gotEvent != string(EventNPCTick) {
+		t.Errorf("expected X-Webhook-Event = %q, got %q", EventNPCTick, gotEvent)
+	}
+}
+
+func TestDispatcher_Fire_RetriesOnServerErrorThenSucceeds(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	registry := NewRegistry()
+	registry.Register("npc-2", server.URL, "shh", nil)
+
+	d, store := newTestDispatcher(t, registry)
+
+	if err := d.Fire(context.Background(), Event{NPCID: "npc-2", Type: EventNPCRegistered, Payload: map[string]string{}}); err != nil {
+		t.Fatalf("Fire() error = %v", err)
+	}
+
+	delivery := waitForDelivery(t, store, "npc-2", StatusDelivered)
+	if delivery.Attempts < 2 {
+		t.Errorf("expected at least 2 attempts before success, got %d", delivery.Attempts)
+	}
+}
+
+func TestDispatcher_Fire_MarksFailedAfterMaxAttempts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	registry := NewRegistry()
+	registry.Register("npc-3", server.URL, "shh", nil)
+
+	d, store := newTestDispatcher(t, registry)
+
+	if err := d.Fire(context.Background(), Event{NPCID: "npc-3", Type: EventNPCRegistered, Payload: map[string]string{}}); err != nil {
+		t.Fatalf("Fire() error = %v", err)
+	}
+
+	delivery := waitForDelivery(t, store, "npc-3", StatusFailed)
+	if delivery.Attempts != d.retry.MaxAttempts {
+		t.Errorf("expected exactly %d attempts, got %d", d.retry.MaxAttempts, delivery.Attempts)
+	}
+}
@@ -0,0 +1,104 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/piercegov/llm-npc-backend/internal/api"
+	"github.com/piercegov/llm-npc-backend/internal/logging"
+)
+
+// Handlers contains the HTTP handlers for registering webhooks and
+// inspecting their delivery history.
+type Handlers struct {
+	registry *Registry
+	store    DeliveryStore
+}
+
+// NewHandlers creates a new instance of webhook handlers.
+func NewHandlers(registry *Registry, store DeliveryStore) *Handlers {
+	return &Handlers{registry: registry, store: store}
+}
+
+// RegisterRequest is the body of POST /npc/{id}/webhooks.
+type RegisterRequest struct {
+	URL    string      `json:"url"`
+	Secret string      `json:"secret"`
+	Events []EventType `json:"events,omitempty"`
+}
+
+// RegisterResponse is the response from registering a webhook.
+type RegisterResponse struct {
+	WebhookID string `json:"webhook_id"`
+	Success   bool   `json:"success"`
+}
+
+// RegisterHandler handles POST /npc/{id}/webhooks
+func (h *Handlers) RegisterHandler(w http.ResponseWriter, r *http.Request) {
+	npcID := api.PathParam(r, "id")
+	if npcID == "" {
+		api.WriteErrorResponse(w, http.StatusBadRequest, "NPC ID is required", api.ErrCodeValidation, nil, r.Context())
+		return
+	}
+
+	var req RegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.WriteErrorResponse(w, http.StatusBadRequest, "Invalid JSON", api.ErrCodeInvalidJSON, nil, r.Context())
+		return
+	}
+
+	if req.URL == "" {
+		api.WriteErrorResponse(w, http.StatusBadRequest, "URL is required", api.ErrCodeValidation, nil, r.Context())
+		return
+	}
+	if req.Secret == "" {
+		api.WriteErrorResponse(w, http.StatusBadRequest, "Secret is required", api.ErrCodeValidation, nil, r.Context())
+		return
+	}
+	if err := validateWebhookURL(req.URL); err != nil {
+		api.WriteErrorResponse(w, http.StatusBadRequest, err.Error(), api.ErrCodeValidation, nil, r.Context())
+		return
+	}
+
+	webhookID := h.registry.Register(npcID, req.URL, req.Secret, req.Events)
+	logging.Info("Webhook registered", "npc_id", npcID, "webhook_id", webhookID, "url", req.URL)
+
+	response := RegisterResponse{WebhookID: webhookID, Success: true}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeliveriesResponse is the response from listing an NPC's webhook
+// deliveries.
+type DeliveriesResponse struct {
+	Deliveries []*Delivery `json:"deliveries"`
+	Count      int         `json:"count"`
+	Success    bool        `json:"success"`
+}
+
+// DeliveriesHandler handles GET /npc/{id}/webhooks/deliveries
+func (h *Handlers) DeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	npcID := api.PathParam(r, "id")
+	if npcID == "" {
+		api.WriteErrorResponse(w, http.StatusBadRequest, "NPC ID is required", api.ErrCodeValidation, nil, r.Context())
+		return
+	}
+
+	deliveries, err := h.store.ForNPC(npcID)
+	if err != nil {
+		api.LogRequestError(r.Context(), "Failed to list webhook deliveries", err)
+		api.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to list webhook deliveries", api.ErrCodeInternalServer, nil, r.Context())
+		return
+	}
+
+	response := DeliveriesResponse{
+		Deliveries: deliveries,
+		Count:      len(deliveries),
+		Success:    true,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
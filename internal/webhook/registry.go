@@ -0,0 +1,64 @@
+package webhook
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Registry holds the webhook subscriptions registered for each NPC. It is
+// in-memory only: re-registering webhooks after a restart
+// is the caller's responsibility, while Delivery history survives restarts
+// via DeliveryStore.
+type Registry struct {
+	mu       sync.RWMutex
+	webhooks map[string][]Config // npcID -> its webhooks
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{webhooks: make(map[string][]Config)}
+}
+
+// Register adds a webhook subscription for npcID and returns its generated ID.
+func (r *Registry) Register(npcID, url, secret string, events []EventType) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg := Config{
+		ID:     uuid.New().String(),
+		NPCID:  npcID,
+		URL:    url,
+		Secret: secret,
+		Events: events,
+	}
+	r.webhooks[npcID] = append(r.webhooks[npcID], cfg)
+	return cfg.ID
+}
+
+// For returns every webhook registered for npcID that subscribes to eventType.
+func (r *Registry) For(npcID string, eventType EventType) []Config {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []Config
+	for _, cfg := range r.webhooks[npcID] {
+		if cfg.subscribes(eventType) {
+			matched = append(matched, cfg)
+		}
+	}
+	return matched
+}
+
+// Get returns the webhook with the given npcID and webhookID, if registered.
+func (r *Registry) Get(npcID, webhookID string) (Config, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, cfg := range r.webhooks[npcID] {
+		if cfg.ID == webhookID {
+			return cfg, true
+		}
+	}
+	return Config{}, false
+}
@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var deliveriesBucket = []byte("deliveries")
+
+// DeliveryStore persists webhook deliveries so their status survives a
+// restart and Dispatcher.Start can requeue any still-Pending one. A requeued
+// delivery is sent only if its WebhookID is registered in the current
+// process's Registry; otherwise it is marked Failed.
+type DeliveryStore interface {
+	Save(d *Delivery) error
+	Pending() ([]*Delivery, error)
+	ForNPC(npcID string) ([]*Delivery, error)
+}
+
+// BoltDeliveryStore persists deliveries to a local BoltDB file, the same
+// approach tools.FileSessionStore uses for sessions.
+type BoltDeliveryStore struct {
+	db *bbolt.DB
+}
+
+// NewBoltDeliveryStore opens (creating if necessary) a BoltDB-backed
+// delivery store at path.
+func NewBoltDeliveryStore(path string) (*BoltDeliveryStore, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(deliveriesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltDeliveryStore{db: db}, nil
+}
+
+// Close closes the underlying BoltDB file.
+func (s *BoltDeliveryStore) Close() error {
+	return s.db.Close()
+}
+
+// Save inserts or updates d, keyed by d.ID.
+func (s *BoltDeliveryStore) Save(d *Delivery) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(deliveriesBucket).Put([]byte(d.ID), data)
+	})
+}
+
+// Pending returns every delivery still awaiting a successful attempt, for
+// the dispatcher to resume on startup.
+func (s *BoltDeliveryStore) Pending() ([]*Delivery, error) {
+	return s.scan(func(d *Delivery) bool { return d.Status == StatusPending })
+}
+
+// ForNPC returns every delivery recorded for npcID, regardless of status.
+func (s *BoltDeliveryStore) ForNPC(npcID string) ([]*Delivery, error) {
+	return s.scan(func(d *Delivery) bool { return d.NPCID == npcID })
+}
+
+func (s *BoltDeliveryStore) scan(match func(*Delivery) bool) ([]*Delivery, error) {
+	var results []*Delivery
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(deliveriesBucket).ForEach(func(_, data []byte) error {
+			var d Delivery
+			if err := json.Unmarshal(data, &d); err != nil {
+				return err
+			}
+			if match(&d) {
+				results = append(results, &d)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
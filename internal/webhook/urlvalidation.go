@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// maxRedirects bounds how many redirect hops a webhook client will follow,
+// matching http.Client's own default cap.
+const maxRedirects = 10
+
+// validateWebhookURL rejects webhook URLs that could be used to make the
+// server issue requests on the registrant's behalf against internal
+// infrastructure (SSRF): anything other than https, and any hostname that
+// resolves to a loopback, private, link-local, or otherwise non-public
+// address (e.g. http://169.254.169.254/latest/meta-data/,
+// http://localhost:2379). This is a fast upfront check for registration and
+// before opening a connection; newWebhookHTTPClient is what actually closes
+// off DNS rebinding between this check and the real connection.
+func validateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	if parsed.Scheme != "https" {
+		return fmt.Errorf("webhook URL must use https, got %q", parsed.Scheme)
+	}
+	if parsed.Hostname() == "" {
+		return fmt.Errorf("webhook URL must have a host")
+	}
+	return validateWebhookHost(parsed.Hostname())
+}
+
+// validateWebhookHost resolves host and rejects it if any resolved address
+// is not a publicly routable unicast address.
+func validateWebhookHost(host string) error {
+	_, err := resolvePubliclyRoutableIP(context.Background(), host)
+	return err
+}
+
+// resolvePubliclyRoutableIP resolves host and returns one of its addresses,
+// rejecting the host outright if ANY resolved address is not publicly
+// routable, rather than just picking a safe one: a host that answers with a
+// mix of public and private addresses could otherwise pass validation on
+// one record and connect on another.
+//
+// Crucially, newWebhookHTTPClient's Transport calls this again at the
+// moment it actually dials, pinning the connection to the exact IP this
+// call validated. Validating a hostname once up front (e.g. at webhook
+// registration, or before a delivery attempt) and then letting a plain
+// http.Client re-resolve DNS when it connects is the textbook DNS-rebinding
+// SSRF bypass: the attacker's DNS returns a public address for the
+// validation lookup and a private one moments later for the real dial.
+func resolvePubliclyRoutableIP(ctx context.Context, host string) (net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		if !isPubliclyRoutable(ip) {
+			return nil, fmt.Errorf("webhook host %q is a non-public address", host)
+		}
+		return ip, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("resolving webhook host %q: %w", host, err)
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("webhook host %q did not resolve to any address", host)
+	}
+	for _, addr := range addrs {
+		if !isPubliclyRoutable(addr.IP) {
+			return nil, fmt.Errorf("webhook host %q resolves to non-public address %s", host, addr.IP)
+		}
+	}
+	return addrs[0].IP, nil
+}
+
+// isPubliclyRoutable reports whether ip is safe to let the dispatcher
+// connect to: not loopback, link-local, private, unspecified, or multicast.
+func isPubliclyRoutable(ip net.IP) bool {
+	switch {
+	case ip.IsLoopback(),
+		ip.IsLinkLocalUnicast(),
+		ip.IsLinkLocalMulticast(),
+		ip.IsPrivate(),
+		ip.IsUnspecified(),
+		ip.IsMulticast():
+		return false
+	}
+	return true
+}
+
+// checkRedirectRevalidate is installed as an http.Client's CheckRedirect so
+// following a webhook endpoint's redirect can't be used to reach a blocked
+// address without even having its hostname looked at; the Transport's
+// DialContext (see newWebhookHTTPClient) still does the real, rebinding-safe
+// validation for the redirect's actual connection.
+func checkRedirectRevalidate(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if err := validateWebhookURL(req.URL.String()); err != nil {
+		return fmt.Errorf("redirect blocked: %w", err)
+	}
+	return nil
+}
+
+// newWebhookHTTPClient returns an http.Client safe to dispatch webhook
+// deliveries with: its Transport resolves and validates a connection's
+// target host, then dials the exact IP it just validated, so there is no
+// window between checking a hostname and connecting to it for an attacker's
+// DNS to swap in a private address (see resolvePubliclyRoutableIP).
+func newWebhookHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			ip, err := resolvePubliclyRoutableIP(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+			return dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		},
+	}
+	return &http.Client{Timeout: timeout, Transport: transport, CheckRedirect: checkRedirectRevalidate}
+}
@@ -0,0 +1,88 @@
+// Package webhook delivers signed HTTP callbacks for NPC lifecycle and tick
+// events (registration, deletion, and completed ticks with tool uses) to
+// per-NPC endpoints registered by game clients.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// EventType identifies the kind of NPC event a webhook fires for.
+type EventType string
+
+const (
+	EventNPCRegistered EventType = "npc.registered"
+	EventNPCDeleted    EventType = "npc.deleted"
+	EventNPCTick       EventType = "npc.tick"
+)
+
+// Config is a single webhook subscription registered for an NPC: deliveries
+// are HMAC-SHA256 signed with Secret and sent to URL for each event type in
+// Events (an empty Events subscribes to every event type).
+type Config struct {
+	ID     string      `json:"id"`
+	NPCID  string      `json:"npc_id"`
+	URL    string      `json:"url"`
+	Secret string      `json:"secret"`
+	Events []EventType `json:"events,omitempty"`
+}
+
+// subscribes reports whether cfg wants deliveries for eventType.
+func (cfg Config) subscribes(eventType EventType) bool {
+	if len(cfg.Events) == 0 {
+		return true
+	}
+	for _, e := range cfg.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryStatus is the lifecycle state of a single webhook delivery attempt.
+type DeliveryStatus string
+
+const (
+	StatusPending   DeliveryStatus = "pending"
+	StatusDelivered DeliveryStatus = "delivered"
+	StatusFailed    DeliveryStatus = "failed"
+)
+
+// Delivery is one webhook's attempt to deliver one event, persisted so its
+// status survives a restart (see DeliveryStore).
+type Delivery struct {
+	ID          string          `json:"id"`
+	WebhookID   string          `json:"webhook_id"`
+	NPCID       string          `json:"npc_id"`
+	URL         string          `json:"url"`
+	EventType   EventType       `json:"event_type"`
+	Payload     json.RawMessage `json:"payload"`
+	RequestID   string          `json:"request_id,omitempty"`
+	Status      DeliveryStatus  `json:"status"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	NextAttempt time.Time       `json:"next_attempt"`
+}
+
+// Event describes an NPC occurrence to fan out to every subscribed webhook.
+type Event struct {
+	NPCID     string
+	Type      EventType
+	RequestID string
+	Payload   any
+}
+
+// sign computes the hex-encoded HMAC-SHA256 of body using secret, in the
+// "sha256=<hex>" form GitHub-style webhook consumers expect.
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
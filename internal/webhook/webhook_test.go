@@ -0,0 +1,119 @@
+package webhook
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestConfig_Subscribes(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []EventType
+		check  EventType
+		want   bool
+	}{
+		{"empty events subscribes to everything", nil, EventNPCTick, true},
+		{"matching event", []EventType{EventNPCRegistered, EventNPCTick}, EventNPCTick, true},
+		{"non-matching event", []EventType{EventNPCRegistered}, EventNPCDeleted, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Events: tt.events}
+			if got := cfg.subscribes(tt.check); got != tt.want {
+				t.Errorf("subscribes(%s) = %v, want %v", tt.check, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSign_IsDeterministicAndSecretDependent(t *testing.T) {
+	body := []byte(`{"hello":"world"}`)
+
+	sig1 := sign("secret-a", body)
+	sig2 := sign("secret-a", body)
+	if sig1 != sig2 {
+		t.Errorf("sign() is not deterministic: %q != %q", sig1, sig2)
+	}
+
+	sig3 := sign("secret-b", body)
+	if sig1 == sig3 {
+		t.Errorf("sign() should differ across secrets, got %q for both", sig1)
+	}
+}
+
+func TestRegistry_ForFiltersByEventAndNPC(t *testing.T) {
+	reg := NewRegistry()
+	tickID := reg.Register("npc-1", "https://example.com/tick", "s", []EventType{EventNPCTick})
+	reg.Register("npc-1", "https://example.com/all", "s", nil)
+	reg.Register("npc-2", "https://example.com/other-npc", "s", []EventType{EventNPCTick})
+
+	matched := reg.For("npc-1", EventNPCTick)
+	if len(matched) != 2 {
+		t.Fatalf("expected 2 webhooks subscribed to npc.tick for npc-1, got %d", len(matched))
+	}
+
+	matched = reg.For("npc-1", EventNPCDeleted)
+	if len(matched) != 1 || matched[0].URL != "https://example.com/all" {
+		t.Fatalf("expected only the wildcard webhook for npc.deleted, got %+v", matched)
+	}
+
+	if _, ok := reg.Get("npc-1", tickID); !ok {
+		t.Errorf("Get() should find the registered webhook by ID")
+	}
+	if _, ok := reg.Get("npc-1", "unknown-id"); ok {
+		t.Errorf("Get() should not find an unregistered ID")
+	}
+}
+
+func TestBoltDeliveryStore_SurvivesRestart(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "webhooks.db")
+
+	store, err := NewBoltDeliveryStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewBoltDeliveryStore() error = %v", err)
+	}
+
+	delivery := &Delivery{ID: "d-1", WebhookID: "w-1", NPCID: "npc-1", Status: StatusPending}
+	if err := store.Save(delivery); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("store.Close() error = %v", err)
+	}
+
+	reopened, err := NewBoltDeliveryStore(dbPath)
+	if err != nil {
+		t.Fatalf("reopening store error = %v", err)
+	}
+	defer reopened.Close()
+
+	pending, err := reopened.Pending()
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].ID != "d-1" {
+		t.Fatalf("expected the pending delivery to survive restart, got %+v", pending)
+	}
+
+	delivery.Status = StatusDelivered
+	if err := reopened.Save(delivery); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	pending, err = reopened.Pending()
+	if err != nil {
+		t.Fatalf("Pending() error = %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("expected no pending deliveries after marking delivered, got %+v", pending)
+	}
+
+	forNPC, err := reopened.ForNPC("npc-1")
+	if err != nil {
+		t.Fatalf("ForNPC() error = %v", err)
+	}
+	if len(forNPC) != 1 || forNPC[0].Status != StatusDelivered {
+		t.Fatalf("expected ForNPC to return the updated delivery, got %+v", forNPC)
+	}
+}